@@ -0,0 +1,54 @@
+/*
+ * backfill.go: Implements the `harvester backfill` subcommand.
+ * Ingests a data source's history for one vineyard, resuming an earlier interrupted run of the same range.
+ * Usage: harvester backfill --source weather --vineyard 12 --from 2025-03-01 --to 2025-10-31
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/service"
+)
+
+const backfillUsage = "usage: harvester backfill --source <key> --vineyard <id> --from YYYY-MM-DD --to YYYY-MM-DD"
+
+// runBackfill executes a backfill with the arguments following "backfill".
+func runBackfill(ctx context.Context, backfillService service.BackfillService, args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	source := flags.String("source", "", "data source key")
+	vineyard := flags.Int("vineyard", 0, "vineyard ID")
+	from := flags.String("from", "", "first day, YYYY-MM-DD")
+	to := flags.String("to", "", "last day, YYYY-MM-DD")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%v\n%s", err, backfillUsage)
+	}
+	if *source == "" || *vineyard <= 0 || *from == "" || *to == "" || flags.NArg() > 0 {
+		return errors.New(backfillUsage)
+	}
+
+	req := service.BackfillRequest{SourceKey: *source, VineyardID: *vineyard}
+	var err error
+	if req.From, err = time.Parse("2006-01-02", *from); err != nil {
+		return fmt.Errorf("invalid --from %q, expected YYYY-MM-DD", *from)
+	}
+	if req.To, err = time.Parse("2006-01-02", *to); err != nil {
+		return fmt.Errorf("invalid --to %q, expected YYYY-MM-DD", *to)
+	}
+
+	job, err := backfillService.Backfill(ctx, req)
+	if job != nil {
+		fmt.Printf("backfill %d: %s, %d of %d windows fetched, %d skipped, %d records\n", job.ID, job.Status,
+			job.WindowsFetched, job.WindowsTotal, job.WindowsSkipped, job.Records)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+/*
+ * jobs.go: Implements the `harvester jobs` subcommand.
+ * Reconciles the Cloud Scheduler jobs with the configured data sources, or reports what would change.
+ * Usage: harvester jobs sync [--dry-run]
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/scheduler"
+)
+
+const jobsUsage = "usage: harvester jobs sync [--dry-run]"
+
+// runJobs executes a jobs subcommand with the arguments following "jobs".
+func runJobs(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return errors.New(jobsUsage)
+	}
+
+	dryRun := false
+	for _, arg := range args[1:] {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			return fmt.Errorf("unknown flag %q\n%s", arg, jobsUsage)
+		}
+	}
+
+	schedClient, err := scheduler.NewSchedulerClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer schedClient.Client.Close()
+
+	report, err := schedClient.Reconcile(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tJOB\tSOURCE\tCHANGES")
+	failed := 0
+	for _, job := range report.Jobs {
+		details := strings.Join(job.Changes, ",")
+		if job.Error != "" {
+			failed++
+			details = "error: " + job.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.Action, job.JobName, job.SourceKey, details)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Println("dry run: no changes were made")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d job(s) failed to reconcile", failed)
+	}
+	return nil
+}
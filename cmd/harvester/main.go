@@ -12,18 +12,26 @@ import (
 	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/api"
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
 	"github.com/sthompson732/viticulture-harvester-app/internal/config"
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/mapping"
+	"github.com/sthompson732/viticulture-harvester-app/internal/notify"
 	"github.com/sthompson732/viticulture-harvester-app/internal/scheduler"
 	"github.com/sthompson732/viticulture-harvester-app/internal/server"
 	"github.com/sthompson732/viticulture-harvester-app/internal/service"
 	"github.com/sthompson732/viticulture-harvester-app/internal/storage"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
 )
 
 func main() {
-	ctx := context.Background()
+	// SIGINT and SIGTERM cancel ctx, which stops the server, the scheduler and background workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Load configuration from file
 	cfgPath := os.Getenv("CONFIG_PATH")
@@ -35,43 +43,179 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// `harvester jobs ...` only talks to Cloud Scheduler, so it runs before the database is opened
+	if len(os.Args) > 1 && os.Args[1] == "jobs" {
+		if err := runJobs(ctx, cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Job sync failed: %v", err)
+		}
+		return
+	}
+
+	// Reject invalid response mappings before anything is scheduled
+	for key, source := range cfg.DataSources {
+		if source.Mapping == nil {
+			continue
+		}
+		if err := mapping.Validate(*source.Mapping); err != nil {
+			log.Fatalf("Invalid mapping for data source %s: %v", key, err)
+		}
+	}
+
+	climateSettings, err := climate.ParseSettings(cfg.Climate)
+	if err != nil {
+		log.Fatalf("Invalid climate settings: %v", err)
+	}
+
 	// Initialize the database
 	database, err := db.NewDB(cfg.Database.ConnectionString)
 	if err != nil {
 		log.Fatalf("Failed to initialize the database: %v", err)
 	}
 
+	// `harvester migrate ...` manages the schema and exits without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, database, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	if cfg.Database.AutoMigrate {
+		applied, err := database.MigrateUp(ctx)
+		if err != nil {
+			log.Fatalf("Failed to apply database migrations: %v", err)
+		}
+		if len(applied) > 0 {
+			log.Printf("Applied database migrations: %v", applied)
+		}
+	}
+
+	// Notifications are emailed through the SMTP server in notifications.emailService when it is enabled
+	mailTransport, err := notify.NewTransport(cfg.Notifications.EmailService)
+	if err != nil {
+		log.Fatalf("Invalid email settings: %v", err)
+	}
+	notificationService, err := service.NewNotificationService(database, climateSettings, mailTransport,
+		cfg.Notifications.Outbox)
+	if err != nil {
+		log.Fatalf("Invalid outbox settings: %v", err)
+	}
+
 	// Initialize the storage service
-	storageService, err := storage.NewStorageService(ctx, cfg.CloudStorage.BucketName, cfg.CloudStorage.CredentialsPath)
+	storageService, err := storage.NewBlobStore(ctx, cfg.CloudStorage)
 	if err != nil {
 		log.Fatalf("Failed to initialize storage service: %v", err)
 	}
 
+	// Outbound provider calls share one retry policy and a circuit breaker per data source
+	clients, err := transport.NewRegistry(cfg.IngestionSettings)
+	if err != nil {
+		log.Fatalf("Invalid ingestion settings: %v", err)
+	}
+
+	// Data events are POSTed to the webhooks subscribed to them
+	webhookService, err := service.NewWebhookService(database, cfg.Notifications.Webhooks)
+	if err != nil {
+		log.Fatalf("Invalid webhook settings: %v", err)
+	}
+
 	// Initialize data services
-	vineyardService := service.NewVineyardService(database)
+	climateService := service.NewClimateService(database, climateSettings)
+	phenologyService := service.NewPhenologyService(database, climateService, climateSettings)
+	vineyardService := service.NewVineyardService(database, phenologyService)
 	imageService := service.NewImageService(database, storageService)
 	soilDataService := service.NewSoilDataService(database)
-	pestService := service.NewPestService(database)
-	weatherService := service.NewWeatherService(database)
-	satelliteService := service.NewSatelliteService(database, storageService)
+	pestService := service.NewPestService(database, webhookService)
+	alertService := service.NewAlertService(database, climateSettings, notificationService, webhookService,
+		phenologyService)
+	forecastService := service.NewHarvestForecastService(database, climateService, climateSettings)
+	weatherService := service.NewWeatherService(database, alertService, webhookService, forecastService)
+	satelliteService := service.NewSatelliteService(database, storageService, webhookService)
+	ingestionRunService := service.NewIngestionRunService(database, notificationService, webhookService)
+	diseaseService := service.NewDiseaseService(database, climateSettings)
+	ingestionService := service.NewIngestionService(cfg, vineyardService, weatherService, satelliteService,
+		soilDataService, pestService, ingestionRunService, diseaseService, clients)
+	backfillService := service.NewBackfillService(cfg, database, vineyardService, ingestionService, diseaseService)
+	blockService := service.NewBlockService(database)
+	spatialService := service.NewSpatialService(database)
+	exportService := service.NewExportService(database)
+	maturityService := service.NewMaturityService(database, climateSettings, forecastService)
+	sprayService := service.NewSprayService(database, climateSettings)
+	harvestService := service.NewHarvestService(database, climateService, climateSettings, sprayService)
+
+	// `harvester backfill ...` ingests history and exits without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(ctx, backfillService, os.Args[2:]); err != nil {
+			log.Fatalf("Backfill failed: %v", err)
+		}
+		return
+	}
 
 	// Set up the API router
-	router := api.NewRouter(vineyardService, imageService, soilDataService, pestService, weatherService, satelliteService)
+	router := api.NewRouter(&api.AppHandler{
+		VineyardService:  vineyardService,
+		ImageService:     imageService,
+		SoilDataService:  soilDataService,
+		PestService:      pestService,
+		WeatherService:   weatherService,
+		SatelliteService: satelliteService,
+		IngestionService: ingestionService,
+		IngestionRuns:    ingestionRunService,
+		Backfills:        backfillService,
+		Blocks:           blockService,
+		Spatial:          spatialService,
+		Export:           exportService,
+		Climate:          climateService,
+		Disease:          diseaseService,
+		Alerts:           alertService,
+		Notifications:    notificationService,
+		Webhooks:         webhookService,
+		Maturity:         maturityService,
+		Harvests:         harvestService,
+		Forecasts:        forecastService,
+		Phenology:        phenologyService,
+		Sprays:           sprayService,
+		Transport:        clients,
+		Cfg:              cfg,
+	})
+
+	// Queued email is sent in the background and survives restarts in the outbox table
+	go notificationService.RunOutbox(ctx)
+	go webhookService.RunDeliveries(ctx)
+	// New weather and maturity records only schedule a forecast recompute; it runs here
+	go forecastService.RunRecompute(ctx)
+
+	// Schedule data source jobs before starting the server, which blocks
+	var localScheduler *scheduler.LocalScheduler
+	switch cfg.Scheduler.Mode {
+	case "local":
+		localScheduler, err = scheduler.NewLocalScheduler(cfg, ingestionService, clients, ingestionRunService)
+		if err != nil {
+			log.Fatalf("Failed to create local scheduler: %v", err)
+		}
+		localScheduler.Start(ctx)
+	case "", "cloud":
+		schedClient, err := scheduler.NewSchedulerClient(ctx, cfg)
+		if err != nil {
+			log.Fatalf("Failed to create scheduler client: %v", err)
+		}
+
+		// Dynamically schedule jobs based on data source configurations
+		if err := schedClient.SetupJobs(ctx); err != nil {
+			log.Fatalf("Failed to set up scheduler jobs: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown scheduler mode %q", cfg.Scheduler.Mode)
+	}
 
 	// Initialize and start the server
 	srv := server.NewServer(router)
-	if err := srv.Start(cfg.App.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	err = srv.Start(ctx, cfg.App.Port)
+	// Runs cut short by the shutdown are recorded as failed rather than left running
+	if localScheduler != nil {
+		localScheduler.Stop()
 	}
-
-	// Initialize Scheduler Client and set up jobs
-	schedClient, err := scheduler.NewSchedulerClient(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to create scheduler client: %v", err)
-	}
-
-	// Dynamically schedule jobs based on data source configurations
-	if err := schedClient.SetupJobs(ctx); err != nil {
-		log.Fatalf("Failed to set up scheduler jobs: %v", err)
+		log.Fatalf("Failed to start server: %v", err)
 	}
 }
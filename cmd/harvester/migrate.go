@@ -0,0 +1,78 @@
+/*
+ * migrate.go: Implements the `harvester migrate` subcommand.
+ * Applies, rolls back, or reports on the embedded schema migrations.
+ * Usage: harvester migrate up | down [steps] | status
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+)
+
+const migrateUsage = "usage: harvester migrate up | down [steps] | status"
+
+// runMigrate executes a migrate subcommand with the arguments following "migrate".
+func runMigrate(ctx context.Context, database *db.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(ctx)
+		for _, version := range applied {
+			fmt.Printf("applied migration %04d\n", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := database.MigrateDown(ctx, steps)
+		for _, version := range reverted {
+			fmt.Printf("rolled back migration %04d\n", version)
+		}
+		return err
+
+	case "status":
+		statuses, err := database.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
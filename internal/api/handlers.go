@@ -10,16 +10,24 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/phenology"
 	"github.com/sthompson732/viticulture-harvester-app/internal/service"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
 	"github.com/sthompson732/viticulture-harvester-app/pkg/util"
 )
 
@@ -30,79 +38,217 @@ type AppHandler struct {
 	PestService      service.PestService
 	WeatherService   service.WeatherService
 	SatelliteService service.SatelliteService
+	IngestionService service.IngestionService
+	IngestionRuns    service.IngestionRunService
+	Backfills        service.BackfillService
+	Blocks           service.BlockService
+	Spatial          service.SpatialService
+	Export           service.ExportService
+	Climate          service.ClimateService
+	Disease          service.DiseaseService
+	Alerts           service.AlertService
+	Notifications    service.NotificationService
+	Webhooks         service.WebhookService
+	Maturity         service.MaturityService
+	Harvests         service.HarvestService
+	Forecasts        service.HarvestForecastService
+	Phenology        service.PhenologyService
+	Sprays           service.SprayService
+	Transport        *transport.Registry
 	Cfg              *config.Config
 }
 
-// FetchDataFromSource dynamically handles data retrieval and processing for configured data sources
+// FetchDataFromSource dynamically handles data retrieval and processing for configured data sources.
+// Every vineyard is fetched unless the vineyard query parameter selects one; date and start/end
+// (YYYY-MM-DD) fill the endpoint template. The response is the per-vineyard IngestionSummary.
+// Cloud Scheduler jobs add trigger=scheduled so their runs are told apart from manual fetches.
 func (h *AppHandler) FetchDataFromSource(w http.ResponseWriter, r *http.Request) {
 	sourceKey := mux.Vars(r)["source"]
-	dataSource, ok := h.Cfg.DataSources[sourceKey]
-	if !ok {
-		http.Error(w, "Data source configuration not found", http.StatusBadRequest)
+
+	opts, err := parseFetchOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Fetch the data from the external source
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Fetching every vineyard takes longer than a single request; this matches Cloud Scheduler's default deadline
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Minute)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, dataSource.HttpMethod, dataSource.Endpoint, nil)
+	summary, err := h.IngestionService.FetchDataFromSource(ctx, sourceKey, opts)
 	if err != nil {
-		http.Error(w, "Failed to create request: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, service.ErrUnknownSource):
+			http.Error(w, "Data source configuration not found", http.StatusBadRequest)
+		case errors.Is(err, service.ErrUnsupportedSource):
+			http.Error(w, "Unsupported data source", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to fetch data: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	if dataSource.APIKey != "" {
-		req.Header.Add("Authorization", "Bearer "+dataSource.APIKey)
+	util.JSONResponse(w, summaryStatus(summary), summary)
+}
+
+// summaryStatus maps an ingestion summary to the response status.
+func summaryStatus(summary *service.IngestionSummary) int {
+	// Partial failures are reported per vineyard; the run only fails outright when nothing succeeded
+	if summary.Failed == 0 {
+		return http.StatusCreated
+	}
+	if summary.Succeeded == 0 {
+		return http.StatusBadGateway
+	}
+	return http.StatusMultiStatus
+}
+
+// parseFetchOptions reads the vineyard, date, start and end query parameters of a fetch request.
+func parseFetchOptions(r *http.Request) (service.FetchOptions, error) {
+	const layout = "2006-01-02"
+	var opts service.FetchOptions
+	query := r.URL.Query()
+
+	if v := query.Get("vineyard"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return opts, errors.New("invalid vineyard ID")
+		}
+		opts.VineyardID = id
+	}
+	if v := query.Get("date"); v != "" {
+		date, err := time.Parse(layout, v)
+		if err != nil {
+			return opts, errors.New("invalid date, expected YYYY-MM-DD")
+		}
+		opts.Date = date
+	}
+	start, end := query.Get("start"), query.Get("end")
+	if start != "" || end != "" {
+		startDate, endDate, err := util.ParseDateRange(start, end)
+		if err != nil {
+			return opts, err
+		}
+		opts.StartDate, opts.EndDate = startDate, endDate
 	}
+	if query.Get("trigger") == model.RunTriggerScheduled {
+		opts.Trigger = model.RunTriggerScheduled
+	}
+	return opts, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// ListIngestionRuns returns ingestion runs, newest first. Query parameters source, vineyard, status,
+// trigger, since and until (YYYY-MM-DD or RFC 3339) filter the list; limit caps it.
+func (h *AppHandler) ListIngestionRuns(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRunFilter(r)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	runs, err := h.IngestionRuns.ListRuns(r.Context(), filter)
 	if err != nil {
-		http.Error(w, "Failed to fetch data: "+err.Error(), http.StatusInternalServerError)
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not list ingestion runs")
 		return
 	}
-	defer resp.Body.Close()
+	util.JSONResponse(w, http.StatusOK, runs)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		http.Error(w, "Failed to fetch data, server returned: "+resp.Status, http.StatusInternalServerError)
+// GetIngestionRun returns a single ingestion run.
+func (h *AppHandler) GetIngestionRun(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid ingestion run ID")
 		return
 	}
+	run, err := h.IngestionRuns.GetRun(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.ErrorResponse(w, http.StatusNotFound, "Ingestion run not found")
+			return
+		}
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not fetch ingestion run")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, run)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// RetryIngestionRun repeats a failed run for the same vineyard and window.
+func (h *AppHandler) RetryIngestionRun(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid ingestion run ID")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Minute)
+	defer cancel()
+	summary, err := h.IngestionService.RetryRun(ctx, id)
 	if err != nil {
-		http.Error(w, "Failed to read response body: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			util.ErrorResponse(w, http.StatusNotFound, "Ingestion run not found")
+		case errors.Is(err, service.ErrRunNotRetryable):
+			util.ErrorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrUnknownSource), errors.Is(err, service.ErrUnsupportedSource):
+			util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			util.ErrorResponse(w, http.StatusInternalServerError, "Failed to retry ingestion run: "+err.Error())
+		}
 		return
 	}
+	util.JSONResponse(w, summaryStatus(summary), summary)
+}
 
-	// Process the data
-	switch sourceKey {
-	case "weather":
-		var data model.WeatherData
-		if err := json.Unmarshal(body, &data); err != nil {
-			http.Error(w, "Error decoding weather data: "+err.Error(), http.StatusInternalServerError)
-			return
+// inputError responds 400 with the reason when err is a rejected geometry, or a service.ErrInvalidBlock
+// for a block that is not one of the record's vineyard. It reports whether it responded, so record
+// handlers only fall through to 500 for other errors.
+func inputError(w http.ResponseWriter, err error) bool {
+	var geomErr *geo.ValidationError
+	switch {
+	case errors.As(err, &geomErr):
+		util.ErrorResponse(w, http.StatusBadRequest, geomErr.Error())
+	case errors.Is(err, service.ErrInvalidBlock):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// parseRunFilter reads the query parameters of an ingestion run listing.
+func parseRunFilter(r *http.Request) (model.IngestionRunFilter, error) {
+	query := r.URL.Query()
+	filter := model.IngestionRunFilter{
+		SourceKey: query.Get("source"),
+		Status:    query.Get("status"),
+		Trigger:   query.Get("trigger"),
+	}
+	if v := query.Get("vineyard"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return filter, errors.New("invalid vineyard ID")
 		}
-		if err := h.WeatherService.CreateWeatherData(r.Context(), &data); err != nil {
-			http.Error(w, "Failed to save weather data: "+err.Error(), http.StatusInternalServerError)
-			return
+		filter.VineyardID = id
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("invalid limit")
 		}
-	case "satellite":
-		var data model.SatelliteData
-		if err := json.Unmarshal(body, &data); err != nil {
-			http.Error(w, "Error decoding satellite data: "+err.Error(), http.StatusInternalServerError)
-			return
+		filter.Limit = limit
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		v := query.Get(name)
+		if v == "" {
+			continue
 		}
-		if err := h.SatelliteService.SaveSatelliteData(r.Context(), &data, nil); err != nil {
-			http.Error(w, "Failed to save satellite data: "+err.Error(), http.StatusInternalServerError)
-			return
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", v); err != nil {
+				return filter, errors.New("invalid " + name + ", expected YYYY-MM-DD or RFC 3339")
+			}
 		}
-	default:
-		http.Error(w, "Unsupported data source", http.StatusBadRequest)
-		return
+		*dst = t
 	}
-
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte("Data fetched and processed successfully"))
+	return filter, nil
 }
 
 // Handlers for Vineyard
@@ -111,10 +257,16 @@ func (h *AppHandler) FetchDataFromSource(w http.ResponseWriter, r *http.Request)
 func (h *AppHandler) CreateVineyard(w http.ResponseWriter, r *http.Request) {
 	var vineyard model.Vineyard
 	if err := json.NewDecoder(r.Body).Decode(&vineyard); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	if err := h.VineyardService.CreateVineyard(r.Context(), &vineyard); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to create vineyard")
 		return
 	}
@@ -145,10 +297,17 @@ func (h *AppHandler) UpdateVineyard(w http.ResponseWriter, r *http.Request) {
 	}
 	var vineyard model.Vineyard
 	if err := json.NewDecoder(r.Body).Decode(&vineyard); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	if err := h.VineyardService.UpdateVineyard(r.Context(), id, &vineyard); err != nil {
+	vineyard.ID = id
+	if err := h.VineyardService.UpdateVineyard(r.Context(), &vineyard); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to update vineyard")
 		return
 	}
@@ -194,15 +353,137 @@ func (h *AppHandler) GetVineyardWithEnvironmentalData(w http.ResponseWriter, r *
 	util.JSONResponse(w, http.StatusOK, vineyard)
 }
 
+// Handlers for Vineyard Blocks
+
+func (h *AppHandler) CreateBlock(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var block model.Block
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		if inputError(w, err) {
+			return
+		}
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	block.VineyardID = vineyardID
+	if err := h.Blocks.CreateBlock(r.Context(), &block); err != nil {
+		blockError(w, err, "Failed to create block")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, block)
+}
+
+func (h *AppHandler) GetBlock(w http.ResponseWriter, r *http.Request) {
+	vineyardID, blockID, ok := blockVars(w, r)
+	if !ok {
+		return
+	}
+	block, err := h.Blocks.GetBlock(r.Context(), vineyardID, blockID)
+	if err != nil {
+		blockError(w, err, "Failed to fetch block")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, block)
+}
+
+func (h *AppHandler) UpdateBlock(w http.ResponseWriter, r *http.Request) {
+	vineyardID, blockID, ok := blockVars(w, r)
+	if !ok {
+		return
+	}
+	var block model.Block
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		if inputError(w, err) {
+			return
+		}
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	block.ID = blockID
+	block.VineyardID = vineyardID
+	if err := h.Blocks.UpdateBlock(r.Context(), &block); err != nil {
+		blockError(w, err, "Failed to update block")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, block)
+}
+
+// DeleteBlock removes a block. Records assigned to it keep their data and lose the assignment.
+func (h *AppHandler) DeleteBlock(w http.ResponseWriter, r *http.Request) {
+	vineyardID, blockID, ok := blockVars(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Blocks.DeleteBlock(r.Context(), vineyardID, blockID); err != nil {
+		blockError(w, err, "Failed to delete block")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *AppHandler) ListBlocks(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	blocks, err := h.Blocks.ListBlocks(r.Context(), vineyardID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch blocks")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, blocks)
+}
+
+// blockVars reads the vineyard and block IDs of a block route, responding 400 when either is malformed.
+func blockVars(w http.ResponseWriter, r *http.Request) (vineyardID, blockID int, ok bool) {
+	vars := mux.Vars(r)
+	vineyardID, err := strconv.Atoi(vars["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return 0, 0, false
+	}
+	blockID, err = strconv.Atoi(vars["blockID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid block ID")
+		return 0, 0, false
+	}
+	return vineyardID, blockID, true
+}
+
+// blockError maps a block service error to a response, falling back to 500 with message.
+func blockError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case inputError(w, err):
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, "Block not found")
+	case errors.Is(err, db.ErrDuplicate):
+		util.ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
 // Handlers for Images
 
 func (h *AppHandler) SaveImage(w http.ResponseWriter, r *http.Request) {
 	var image model.Image
 	if err := json.NewDecoder(r.Body).Decode(&image); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	if err := h.ImageService.SaveImage(r.Context(), &image); err != nil {
+	// JSON requests carry metadata for an image that is already hosted at image.URL
+	if err := h.ImageService.SaveImage(r.Context(), &image, nil); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not save image")
 		return
 	}
@@ -231,11 +512,17 @@ func (h *AppHandler) UpdateImage(w http.ResponseWriter, r *http.Request) {
 	}
 	var image model.Image
 	if err := json.NewDecoder(r.Body).Decode(&image); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	image.ID = id
 	if err := h.ImageService.UpdateImage(r.Context(), &image); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not update image")
 		return
 	}
@@ -301,7 +588,7 @@ func (h *AppHandler) ListImages(w http.ResponseWriter, r *http.Request) {
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
 		return
 	}
-	images, err := h.ImageService.ListImages(r.Context(), vineyardID)
+	images, err := h.ImageService.ListImagesByVineyard(r.Context(), vineyardID)
 	if err != nil {
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not list images")
 		return
@@ -322,12 +609,18 @@ func (h *AppHandler) CreateSoilData(w http.ResponseWriter, r *http.Request) {
 
 	var soilData model.SoilData
 	if err := json.NewDecoder(r.Body).Decode(&soilData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	soilData.VineyardID = vineyardID
 
 	if err := h.SoilDataService.CreateSoilData(r.Context(), &soilData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not create soil data")
 		return
 	}
@@ -349,19 +642,25 @@ func (h *AppHandler) GetSoilData(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AppHandler) UpdateSoilData(w http.ResponseWriter, r *http.Request) {
-	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid soil data ID")
 		return
 	}
 	var soilData model.SoilData
 	if err := json.NewDecoder(r.Body).Decode(&soilData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	soilData.VineyardID = vineyardID
+	soilData.ID = id
 
-	if err := h.SoilDataService.UpdateSoilData(r.Context(), vineyardID, &soilData); err != nil {
+	if err := h.SoilDataService.UpdateSoilData(r.Context(), &soilData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not update soil data")
 		return
 	}
@@ -425,10 +724,20 @@ func (h *AppHandler) ListSoilDataByDateRange(w http.ResponseWriter, r *http.Requ
 func (h *AppHandler) CreatePestData(w http.ResponseWriter, r *http.Request) {
 	var pestData model.PestData
 	if err := json.NewDecoder(r.Body).Decode(&pestData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	if err := h.PestService.CreatePestData(r.Context(), &pestData); err != nil {
+		if inputError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPestData) {
+			util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to create pest data")
 		return
 	}
@@ -459,11 +768,21 @@ func (h *AppHandler) UpdatePestData(w http.ResponseWriter, r *http.Request) {
 	}
 	var pestData model.PestData
 	if err := json.NewDecoder(r.Body).Decode(&pestData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	pestData.ID = id
 	if err := h.PestService.UpdatePestData(r.Context(), &pestData); err != nil {
+		if inputError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPestData) {
+			util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not update pest data")
 		return
 	}
@@ -489,7 +808,7 @@ func (h *AppHandler) ListPests(w http.ResponseWriter, r *http.Request) {
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
 		return
 	}
-	pests, err := h.PestService.ListPests(r.Context(), vineyardID)
+	pests, err := h.PestService.ListPestDataByVineyard(r.Context(), vineyardID)
 	if err != nil {
 		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to list pests")
 		return
@@ -503,7 +822,7 @@ func (h *AppHandler) ListPestData(w http.ResponseWriter, r *http.Request) {
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
 		return
 	}
-	pests, err := h.PestService.ListPestData(r.Context(), vineyardID)
+	pests, err := h.PestService.ListPestDataByVineyard(r.Context(), vineyardID)
 	if err != nil {
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not list pest data")
 		return
@@ -519,7 +838,14 @@ func (h *AppHandler) FilterPestData(w http.ResponseWriter, r *http.Request) {
 	}
 	pestType := r.URL.Query().Get("type")
 	severity := r.URL.Query().Get("severity")
-	pests, err := h.PestService.FilterPestData(r.Context(), vineyardID, pestType, severity)
+	kind := r.URL.Query().Get("kind")
+	switch kind {
+	case "", model.PestKindObservation, model.PestKindRisk:
+	default:
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid kind, expected observation or risk")
+		return
+	}
+	pests, err := h.PestService.FilterPestData(r.Context(), vineyardID, pestType, severity, kind)
 	if err != nil {
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not filter pest data")
 		return
@@ -532,10 +858,16 @@ func (h *AppHandler) FilterPestData(w http.ResponseWriter, r *http.Request) {
 func (h *AppHandler) CreateWeatherData(w http.ResponseWriter, r *http.Request) {
 	var weatherData model.WeatherData
 	if err := json.NewDecoder(r.Body).Decode(&weatherData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	if err := h.WeatherService.CreateWeatherData(r.Context(), &weatherData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to create weather data")
 		return
 	}
@@ -564,11 +896,17 @@ func (h *AppHandler) UpdateWeatherData(w http.ResponseWriter, r *http.Request) {
 	}
 	var weatherData model.WeatherData
 	if err := json.NewDecoder(r.Body).Decode(&weatherData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	weatherData.ID = id
 	if err := h.WeatherService.UpdateWeatherData(r.Context(), &weatherData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not update weather data")
 		return
 	}
@@ -628,10 +966,16 @@ func (h *AppHandler) ListWeatherDataByDateRange(w http.ResponseWriter, r *http.R
 func (h *AppHandler) CreateSatelliteData(w http.ResponseWriter, r *http.Request) {
 	var satelliteData model.SatelliteData
 	if err := json.NewDecoder(r.Body).Decode(&satelliteData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	if err := h.SatelliteService.CreateSatelliteData(r.Context(), &satelliteData); err != nil {
+	if err := h.SatelliteService.SaveSatelliteData(r.Context(), &satelliteData, nil); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to create satellite data")
 		return
 	}
@@ -662,11 +1006,17 @@ func (h *AppHandler) UpdateSatelliteData(w http.ResponseWriter, r *http.Request)
 	}
 	var satelliteData model.SatelliteData
 	if err := json.NewDecoder(r.Body).Decode(&satelliteData); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 	satelliteData.ID = id
-	if err := h.SatelliteService.UpdateSatelliteData(r.Context(), &satelliteData); err != nil {
+	if err := h.SatelliteService.UpdateSatelliteData(r.Context(), &satelliteData, nil); err != nil {
+		if inputError(w, err) {
+			return
+		}
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not update satellite data")
 		return
 	}
@@ -695,7 +1045,7 @@ func (h *AppHandler) ListSatelliteData(w http.ResponseWriter, r *http.Request) {
 		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
 		return
 	}
-	satelliteData, err := h.SatelliteService.ListSatelliteData(r.Context(), vineyardID)
+	satelliteData, err := h.SatelliteService.ListSatelliteDataByVineyard(r.Context(), vineyardID)
 	if err != nil {
 		util.ErrorResponse(w, http.StatusInternalServerError, "Could not list satellite data")
 		return
@@ -741,3 +1091,1648 @@ func (h *AppHandler) GetRecentSatelliteImages(w http.ResponseWriter, r *http.Req
 	}
 	util.JSONResponse(w, http.StatusOK, images)
 }
+
+// Handlers for Spatial Queries
+
+// maxGeometryBody caps the size of a drawn search area.
+const maxGeometryBody = 1 << 20
+
+// FindVineyardsIntersecting returns the vineyards whose bounding box intersects the GeoJSON Polygon
+// or MultiPolygon in the request body.
+func (h *AppHandler) FindVineyardsIntersecting(w http.ResponseWriter, r *http.Request) {
+	area, err := readArea(w, r)
+	if err != nil {
+		if !inputError(w, err) {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		}
+		return
+	}
+	vineyards, err := h.Spatial.VineyardsIntersecting(r.Context(), area)
+	if err != nil {
+		if inputError(w, err) {
+			return
+		}
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not search vineyards")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, vineyards)
+}
+
+// FindVineyardsNear returns the vineyards within km kilometres of the point given by lon and lat, nearest first.
+func (h *AppHandler) FindVineyardsNear(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lon, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	if lonErr != nil || latErr != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "lon and lat are required")
+		return
+	}
+	point := geo.Point{Lon: lon, Lat: lat}
+	if err := point.Validate(); err != nil {
+		inputError(w, err)
+		return
+	}
+	radiusKm, err := strconv.ParseFloat(query.Get("km"), 64)
+	if err != nil || radiusKm <= 0 || radiusKm > service.MaxSearchRadiusKm {
+		util.ErrorResponse(w, http.StatusBadRequest, "km must be a distance between 0 and "+
+			strconv.Itoa(service.MaxSearchRadiusKm))
+		return
+	}
+	vineyards, err := h.Spatial.VineyardsNear(r.Context(), point, radiusKm)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not search vineyards")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, vineyards)
+}
+
+// FindPestsWithin returns the pest observations inside the GeoJSON Polygon or MultiPolygon in the
+// request body, newest first. The vineyard query parameter limits the search to one vineyard.
+func (h *AppHandler) FindPestsWithin(w http.ResponseWriter, r *http.Request) {
+	var vineyardID int
+	if v := r.URL.Query().Get("vineyard"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+			return
+		}
+		vineyardID = id
+	}
+	area, err := readArea(w, r)
+	if err != nil {
+		if !inputError(w, err) {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		}
+		return
+	}
+	pests, err := h.Spatial.PestsWithin(r.Context(), area, vineyardID)
+	if err != nil {
+		if inputError(w, err) {
+			return
+		}
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not search pest data")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, pests)
+}
+
+// GetSatelliteCoverage returns the satellite scenes whose bounding box covers at least min percent
+// (default 100) of the vineyard's bounding box, best coverage first.
+func (h *AppHandler) GetSatelliteCoverage(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	minPercent := 100.0
+	if v := r.URL.Query().Get("min"); v != "" {
+		minPercent, err = strconv.ParseFloat(v, 64)
+		if err != nil || minPercent < 0 || minPercent > 100 {
+			util.ErrorResponse(w, http.StatusBadRequest, "min must be a percentage between 0 and 100")
+			return
+		}
+	}
+	scenes, err := h.Spatial.SatelliteCoverage(r.Context(), vineyardID, minPercent)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			util.ErrorResponse(w, http.StatusNotFound, "Vineyard not found")
+		case errors.Is(err, service.ErrNoBoundingBox):
+			util.ErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			util.ErrorResponse(w, http.StatusInternalServerError, "Could not search satellite imagery")
+		}
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, scenes)
+}
+
+// readArea decodes the search area in a request body.
+func readArea(w http.ResponseWriter, r *http.Request) (geo.Geometry, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGeometryBody))
+	if err != nil {
+		return nil, err
+	}
+	return geo.ParseArea(body)
+}
+
+// Handlers for Climate
+
+// GetGrowingDegreeDays returns a vineyard's daily growing degree days from from to to (YYYY-MM-DD),
+// accumulated from budbreak, with the season's Winkler, Huglin and BEDD indices. method and cap
+// override the configured degree day method and cap temperature.
+func (h *AppHandler) GetGrowingDegreeDays(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	query, err := parseGDDQuery(r)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report, err := h.Climate.GrowingDegreeDays(r.Context(), vineyardID, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			util.ErrorResponse(w, http.StatusNotFound, "Vineyard not found")
+		case errors.Is(err, service.ErrInvalidClimateQuery):
+			util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNoBoundingBox):
+			util.ErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			util.ErrorResponse(w, http.StatusInternalServerError, "Could not compute growing degree days")
+		}
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, report)
+}
+
+// parseGDDQuery reads the query parameters of a growing degree day report.
+func parseGDDQuery(r *http.Request) (model.GDDQuery, error) {
+	values := r.URL.Query()
+	query := model.GDDQuery{Method: values.Get("method")}
+	err := parseDates(values, map[string]*time.Time{"from": &query.From, "to": &query.To, "budbreak": &query.Budbreak})
+	if err != nil {
+		return query, err
+	}
+	if v := values.Get("cap"); v != "" {
+		capTemp, err := strconv.ParseFloat(v, 64)
+		if err != nil || capTemp < 0 {
+			return query, errors.New("invalid cap temperature")
+		}
+		query.CapTemp = &capTemp
+	}
+	return query, nil
+}
+
+// parseDates reads optional YYYY-MM-DD query parameters into the times they are mapped to.
+func parseDates(values url.Values, dates map[string]*time.Time) error {
+	for name, dst := range dates {
+		if v := values.Get(name); v != "" {
+			date, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return errors.New("invalid " + name + " date, expected YYYY-MM-DD")
+			}
+			*dst = date
+		}
+	}
+	return nil
+}
+
+// Handlers for Disease Risk
+
+// GetDiseaseRisk returns a disease model's daily risk index for a vineyard from from to to
+// (YYYY-MM-DD). to defaults to today and from to the start of the growing season.
+func (h *AppHandler) GetDiseaseRisk(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	vineyardID, err := strconv.Atoi(vars["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var from, to time.Time
+	if err := parseDates(r.URL.Query(), map[string]*time.Time{"from": &from, "to": &to}); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	risks, err := h.Disease.ListRisk(r.Context(), vineyardID, vars["model"], from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			util.ErrorResponse(w, http.StatusNotFound, "Vineyard not found")
+		case errors.Is(err, service.ErrUnknownDiseaseModel):
+			util.ErrorResponse(w, http.StatusNotFound, err.Error())
+		default:
+			util.ErrorResponse(w, http.StatusInternalServerError, "Could not fetch disease risk")
+		}
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, risks)
+}
+
+// RunDiseaseModels recomputes every disease model for a vineyard from from (required) to to, which
+// defaults to today, e.g. after weather history was loaded outside of ingestion.
+func (h *AppHandler) RunDiseaseModels(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var from, to time.Time
+	if err := parseDates(r.URL.Query(), map[string]*time.Time{"from": &from, "to": &to}); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from.IsZero() {
+		util.ErrorResponse(w, http.StatusBadRequest, "from is required")
+		return
+	}
+	if !to.IsZero() && to.Before(from) {
+		util.ErrorResponse(w, http.StatusBadRequest, "to is before from")
+		return
+	}
+	if err := h.Disease.RunModels(r.Context(), vineyardID, from, to); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.ErrorResponse(w, http.StatusNotFound, "Vineyard not found")
+			return
+		}
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not run disease models")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// Handlers for Alerts
+
+func (h *AppHandler) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var rule model.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.VineyardID = vineyardID
+	if err := h.Alerts.CreateRule(r.Context(), &rule); err != nil {
+		alertError(w, err, "Vineyard not found", "Failed to create alert rule")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, rule)
+}
+
+func (h *AppHandler) GetAlertRule(w http.ResponseWriter, r *http.Request) {
+	vineyardID, ruleID, ok := alertRuleVars(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.Alerts.GetRule(r.Context(), vineyardID, ruleID)
+	if err != nil {
+		alertError(w, err, "Alert rule not found", "Failed to fetch alert rule")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, rule)
+}
+
+func (h *AppHandler) UpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	vineyardID, ruleID, ok := alertRuleVars(w, r)
+	if !ok {
+		return
+	}
+	var rule model.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = ruleID
+	rule.VineyardID = vineyardID
+	if err := h.Alerts.UpdateRule(r.Context(), &rule); err != nil {
+		alertError(w, err, "Alert rule not found", "Failed to update alert rule")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, rule)
+}
+
+// DeleteAlertRule removes a rule. Alerts it raised are kept.
+func (h *AppHandler) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	vineyardID, ruleID, ok := alertRuleVars(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Alerts.DeleteRule(r.Context(), vineyardID, ruleID); err != nil {
+		alertError(w, err, "Alert rule not found", "Failed to delete alert rule")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *AppHandler) ListAlertRules(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	rules, err := h.Alerts.ListRules(r.Context(), vineyardID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch alert rules")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, rules)
+}
+
+// ListAlerts returns a vineyard's alerts, newest first. status (open, acknowledged or resolved) filters
+// the list; limit caps it.
+func (h *AppHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", model.AlertStatusOpen, model.AlertStatusAcknowledged, model.AlertStatusResolved:
+	default:
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid status, expected open, acknowledged or resolved")
+		return
+	}
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+	alerts, err := h.Alerts.ListAlerts(r.Context(), vineyardID, status, limit)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch alerts")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, alerts)
+}
+
+func (h *AppHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid alert ID")
+		return
+	}
+	alert, err := h.Alerts.GetAlert(r.Context(), id)
+	if err != nil {
+		alertError(w, err, "Alert not found", "Failed to fetch alert")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, alert)
+}
+
+// AcknowledgeAlert marks an alert as seen, optionally recording who saw it with {"by": "..."}.
+func (h *AppHandler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid alert ID")
+		return
+	}
+	var body struct {
+		By string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	alert, err := h.Alerts.AcknowledgeAlert(r.Context(), id, body.By)
+	if err != nil {
+		alertError(w, err, "Alert not found", "Failed to acknowledge alert")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, alert)
+}
+
+func (h *AppHandler) ResolveAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid alert ID")
+		return
+	}
+	alert, err := h.Alerts.ResolveAlert(r.Context(), id)
+	if err != nil {
+		alertError(w, err, "Alert not found", "Failed to resolve alert")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, alert)
+}
+
+// alertRuleVars reads the vineyard and rule IDs of an alert rule route, responding 400 when either is malformed.
+func alertRuleVars(w http.ResponseWriter, r *http.Request) (vineyardID, ruleID int, ok bool) {
+	vars := mux.Vars(r)
+	vineyardID, err := strconv.Atoi(vars["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return 0, 0, false
+	}
+	ruleID, err = strconv.Atoi(vars["ruleID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid alert rule ID")
+		return 0, 0, false
+	}
+	return vineyardID, ruleID, true
+}
+
+// alertError maps an alert service error to a response, using notFound for sql.ErrNoRows and falling
+// back to 500 with message.
+func alertError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAlertRule):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrAlertResolved):
+		util.ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for Notifications
+
+func (h *AppHandler) CreateSubscriber(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var sub model.NotificationSubscriber
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	sub.VineyardID = vineyardID
+	if err := h.Notifications.CreateSubscriber(r.Context(), &sub); err != nil {
+		subscriberError(w, err, "Vineyard not found", "Failed to create subscriber")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, sub)
+}
+
+func (h *AppHandler) GetSubscriber(w http.ResponseWriter, r *http.Request) {
+	vineyardID, subscriberID, ok := subscriberVars(w, r)
+	if !ok {
+		return
+	}
+	sub, err := h.Notifications.GetSubscriber(r.Context(), vineyardID, subscriberID)
+	if err != nil {
+		subscriberError(w, err, "Subscriber not found", "Failed to fetch subscriber")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, sub)
+}
+
+func (h *AppHandler) UpdateSubscriber(w http.ResponseWriter, r *http.Request) {
+	vineyardID, subscriberID, ok := subscriberVars(w, r)
+	if !ok {
+		return
+	}
+	var sub model.NotificationSubscriber
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	sub.ID = subscriberID
+	sub.VineyardID = vineyardID
+	if err := h.Notifications.UpdateSubscriber(r.Context(), &sub); err != nil {
+		subscriberError(w, err, "Subscriber not found", "Failed to update subscriber")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, sub)
+}
+
+func (h *AppHandler) DeleteSubscriber(w http.ResponseWriter, r *http.Request) {
+	vineyardID, subscriberID, ok := subscriberVars(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Notifications.DeleteSubscriber(r.Context(), vineyardID, subscriberID); err != nil {
+		subscriberError(w, err, "Subscriber not found", "Failed to delete subscriber")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *AppHandler) ListSubscribers(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	subs, err := h.Notifications.ListSubscribers(r.Context(), vineyardID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch subscribers")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, subs)
+}
+
+// GetWeeklyReport returns the summary emailed as the weekly report for the seven days ending on to
+// (YYYY-MM-DD), by default yesterday.
+func (h *AppHandler) GetWeeklyReport(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var to time.Time
+	if err := parseDates(r.URL.Query(), map[string]*time.Time{"to": &to}); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report, err := h.Notifications.WeeklyReport(r.Context(), vineyardID, to)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.ErrorResponse(w, http.StatusNotFound, "Vineyard not found")
+			return
+		}
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to build weekly report")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, report)
+}
+
+// SendWeeklyReports queues the weekly report for every vineyard with report subscribers. It is meant
+// to be called by a weekly scheduler job; to (YYYY-MM-DD) defaults to yesterday.
+func (h *AppHandler) SendWeeklyReports(w http.ResponseWriter, r *http.Request) {
+	var to time.Time
+	if err := parseDates(r.URL.Query(), map[string]*time.Time{"to": &to}); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	queued, err := h.Notifications.SendWeeklyReports(r.Context(), to)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to queue weekly reports")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]int{"queued": queued})
+}
+
+// ListOutbox returns queued and delivered email, newest first. status (pending, sent or failed)
+// filters the list; limit caps it.
+func (h *AppHandler) ListOutbox(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", model.OutboxPending, model.OutboxSent, model.OutboxFailed:
+	default:
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid status, expected pending, sent or failed")
+		return
+	}
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+	msgs, err := h.Notifications.ListOutbox(r.Context(), status, limit)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch outbox")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, msgs)
+}
+
+// subscriberVars reads the vineyard and subscriber IDs of a subscriber route, responding 400 when either is malformed.
+func subscriberVars(w http.ResponseWriter, r *http.Request) (vineyardID, subscriberID int, ok bool) {
+	vars := mux.Vars(r)
+	vineyardID, err := strconv.Atoi(vars["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return 0, 0, false
+	}
+	subscriberID, err = strconv.Atoi(vars["subscriberID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid subscriber ID")
+		return 0, 0, false
+	}
+	return vineyardID, subscriberID, true
+}
+
+// subscriberError maps a subscriber error to a response, using notFound for sql.ErrNoRows and falling
+// back to 500 with message.
+func subscriberError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSubscriber):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, db.ErrDuplicate):
+		util.ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for Webhooks
+
+// CreateWebhook registers a URL for events. The secret is required and is never returned.
+func (h *AppHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook model.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.Webhooks.CreateWebhook(r.Context(), &hook); err != nil {
+		webhookError(w, err, "Webhook not found", "Failed to create webhook")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, hook)
+}
+
+func (h *AppHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	hook, err := h.Webhooks.GetWebhook(r.Context(), id)
+	if err != nil {
+		webhookError(w, err, "Webhook not found", "Failed to fetch webhook")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, hook)
+}
+
+// UpdateWebhook replaces a webhook's settings. Leaving out the secret keeps the current one.
+func (h *AppHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	var hook model.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	hook.ID = id
+	if err := h.Webhooks.UpdateWebhook(r.Context(), &hook); err != nil {
+		webhookError(w, err, "Webhook not found", "Failed to update webhook")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, hook)
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (h *AppHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	if err := h.Webhooks.DeleteWebhook(r.Context(), id); err != nil {
+		webhookError(w, err, "Webhook not found", "Failed to delete webhook")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *AppHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.Webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, hooks)
+}
+
+// ListWebhookDeliveries returns a webhook's deliveries, newest first. status (pending, succeeded or
+// failed) filters the list; limit caps it.
+func (h *AppHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed:
+	default:
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid status, expected pending, succeeded or failed")
+		return
+	}
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+	deliveries, err := h.Webhooks.ListDeliveries(r.Context(), id, status, limit)
+	if err != nil {
+		webhookError(w, err, "Webhook not found", "Failed to fetch webhook deliveries")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, deliveries)
+}
+
+// GetWebhookDelivery returns a delivery with the log of its attempts.
+func (h *AppHandler) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryVars(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := h.Webhooks.GetDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		webhookError(w, err, "Webhook delivery not found", "Failed to fetch webhook delivery")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, delivery)
+}
+
+// ReplayWebhookDelivery sends a delivery's payload again as a new delivery, which is returned.
+func (h *AppHandler) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryVars(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := h.Webhooks.ReplayDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		webhookError(w, err, "Webhook delivery not found", "Failed to replay webhook delivery")
+		return
+	}
+	util.JSONResponse(w, http.StatusAccepted, delivery)
+}
+
+// deliveryVars reads the webhook and delivery IDs of a delivery route, responding 400 when either is malformed.
+func deliveryVars(w http.ResponseWriter, r *http.Request) (webhookID, deliveryID int, ok bool) {
+	vars := mux.Vars(r)
+	webhookID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID")
+		return 0, 0, false
+	}
+	deliveryID, err = strconv.Atoi(vars["deliveryID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid webhook delivery ID")
+		return 0, 0, false
+	}
+	return webhookID, deliveryID, true
+}
+
+// webhookError maps a webhook service error to a response, using notFound for sql.ErrNoRows and falling
+// back to 500 with message.
+func webhookError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhook):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for Maturity Samples
+
+// CreateMaturitySample records a sample of a vineyard, or of one of its blocks when block_id is given.
+func (h *AppHandler) CreateMaturitySample(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var sample model.MaturitySample
+	if err := json.NewDecoder(r.Body).Decode(&sample); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	sample.VineyardID = vineyardID
+	if err := h.Maturity.CreateSample(r.Context(), &sample); err != nil {
+		maturityError(w, err, "Vineyard not found", "Failed to create maturity sample")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, sample)
+}
+
+func (h *AppHandler) GetMaturitySample(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid maturity sample ID")
+		return
+	}
+	sample, err := h.Maturity.GetSample(r.Context(), id)
+	if err != nil {
+		maturityError(w, err, "Maturity sample not found", "Failed to fetch maturity sample")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, sample)
+}
+
+// UpdateMaturitySample replaces a sample's date, block, measurements, notes and photo link.
+func (h *AppHandler) UpdateMaturitySample(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid maturity sample ID")
+		return
+	}
+	var sample model.MaturitySample
+	if err := json.NewDecoder(r.Body).Decode(&sample); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	sample.ID = id
+	if err := h.Maturity.UpdateSample(r.Context(), &sample); err != nil {
+		maturityError(w, err, "Maturity sample not found", "Failed to update maturity sample")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, sample)
+}
+
+func (h *AppHandler) DeleteMaturitySample(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid maturity sample ID")
+		return
+	}
+	if err := h.Maturity.DeleteSample(r.Context(), id); err != nil {
+		maturityError(w, err, "Maturity sample not found", "Failed to delete maturity sample")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListMaturitySamples returns a vineyard's samples by date; blockId selects those of one block.
+func (h *AppHandler) ListMaturitySamples(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var blockID int
+	if v := r.URL.Query().Get("blockId"); v != "" {
+		if blockID, err = strconv.Atoi(v); err != nil || blockID <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid block ID")
+			return
+		}
+	}
+	samples, err := h.Maturity.ListSamples(r.Context(), vineyardID, blockID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch maturity samples")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, samples)
+}
+
+// ListMaturitySamplesByDateRange returns a vineyard's samples taken from start to end, both included.
+func (h *AppHandler) ListMaturitySamplesByDateRange(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	start, end, err := util.ParseDateRange(r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid date range")
+		return
+	}
+	samples, err := h.Maturity.ListSamplesByDateRange(r.Context(), vineyardID, start, end)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch maturity samples")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, samples)
+}
+
+// GetMaturityTrend fits each block's Brix readings from from to to (YYYY-MM-DD) and projects when it
+// reaches targetBrix.
+func (h *AppHandler) GetMaturityTrend(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	values := r.URL.Query()
+	var query model.MaturityTrendQuery
+	if err := parseDates(values, map[string]*time.Time{"from": &query.From, "to": &query.To}); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if v := values.Get("targetBrix"); v != "" {
+		if query.TargetBrix, err = strconv.ParseFloat(v, 64); err != nil {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid targetBrix")
+			return
+		}
+	}
+	trend, err := h.Maturity.Trend(r.Context(), vineyardID, query)
+	if err != nil {
+		maturityError(w, err, "Vineyard not found", "Failed to compute maturity trend")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, trend)
+}
+
+// maturityError maps a maturity service error to a response, using notFound for sql.ErrNoRows and
+// falling back to 500 with message.
+func maturityError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMaturitySample), errors.Is(err, service.ErrInvalidBlock):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for Harvests
+
+// CreateHarvest records a pick of a vineyard, or of one of its blocks when block_id is given.
+func (h *AppHandler) CreateHarvest(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var harvest model.Harvest
+	if err := json.NewDecoder(r.Body).Decode(&harvest); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	harvest.VineyardID = vineyardID
+	if err := h.Harvests.CreateHarvest(r.Context(), &harvest); err != nil {
+		harvestError(w, err, "Vineyard not found", "Failed to create harvest")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, harvest)
+}
+
+func (h *AppHandler) GetHarvest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid harvest ID")
+		return
+	}
+	harvest, err := h.Harvests.GetHarvest(r.Context(), id)
+	if err != nil {
+		harvestError(w, err, "Harvest not found", "Failed to fetch harvest")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, harvest)
+}
+
+// UpdateHarvest replaces a harvest's date, block and delivery details.
+func (h *AppHandler) UpdateHarvest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid harvest ID")
+		return
+	}
+	var harvest model.Harvest
+	if err := json.NewDecoder(r.Body).Decode(&harvest); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	harvest.ID = id
+	if err := h.Harvests.UpdateHarvest(r.Context(), &harvest); err != nil {
+		harvestError(w, err, "Harvest not found", "Failed to update harvest")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, harvest)
+}
+
+func (h *AppHandler) DeleteHarvest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid harvest ID")
+		return
+	}
+	if err := h.Harvests.DeleteHarvest(r.Context(), id); err != nil {
+		harvestError(w, err, "Harvest not found", "Failed to delete harvest")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListHarvests returns a vineyard's harvests by date; blockId selects those of one block.
+func (h *AppHandler) ListHarvests(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var blockID int
+	if v := r.URL.Query().Get("blockId"); v != "" {
+		if blockID, err = strconv.Atoi(v); err != nil || blockID <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid block ID")
+			return
+		}
+	}
+	harvests, err := h.Harvests.ListHarvests(r.Context(), vineyardID, blockID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch harvests")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, harvests)
+}
+
+// ListHarvestsByDateRange returns a vineyard's harvests from start to end, both included.
+func (h *AppHandler) ListHarvestsByDateRange(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	start, end, err := util.ParseDateRange(r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid date range")
+		return
+	}
+	harvests, err := h.Harvests.ListHarvestsByDateRange(r.Context(), vineyardID, start, end)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch harvests")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, harvests)
+}
+
+// GetSeasonYield returns the yield of each block in season, by default the current one.
+func (h *AppHandler) GetSeasonYield(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	seasons, err := parseSeasons(r.URL.Query(), "season")
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report, err := h.Harvests.SeasonYield(r.Context(), vineyardID, seasons["season"])
+	if err != nil {
+		harvestError(w, err, "Vineyard not found", "Failed to compute yield")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, report)
+}
+
+// CompareSeasonYields compares the yield of the seasons from from to to with their growing degree
+// days, pest pressure and soil moisture.
+func (h *AppHandler) CompareSeasonYields(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	seasons, err := parseSeasons(r.URL.Query(), "from", "to")
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	comparison, err := h.Harvests.CompareSeasons(r.Context(), vineyardID, seasons["from"], seasons["to"])
+	if err != nil {
+		harvestError(w, err, "Vineyard not found", "Failed to compare yields")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, comparison)
+}
+
+// GetHarvestForecast returns the current pick window of each block, computed from the latest data.
+func (h *AppHandler) GetHarvestForecast(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	forecast, err := h.Forecasts.Forecast(r.Context(), vineyardID)
+	if err != nil {
+		harvestError(w, err, "Vineyard not found", "Failed to forecast harvest")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, forecast)
+}
+
+// RecomputeHarvestForecast computes the forecast now and stores the blocks' forecasts that changed.
+func (h *AppHandler) RecomputeHarvestForecast(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	forecast, err := h.Forecasts.Recompute(r.Context(), vineyardID, model.ForecastTriggerManual)
+	if err != nil {
+		harvestError(w, err, "Vineyard not found", "Failed to recompute harvest forecast")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, forecast)
+}
+
+// GetHarvestForecastHistory returns the forecasts stored for season, by default the current one,
+// with their error against the actual picks; blockId selects one block.
+func (h *AppHandler) GetHarvestForecastHistory(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	query := r.URL.Query()
+	seasons, err := parseSeasons(query, "season")
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var blockID int
+	if v := query.Get("blockId"); v != "" {
+		if blockID, err = strconv.Atoi(v); err != nil || blockID <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid block ID")
+			return
+		}
+	}
+	history, err := h.Forecasts.History(r.Context(), vineyardID, seasons["season"], blockID)
+	if err != nil {
+		harvestError(w, err, "Vineyard not found", "Failed to fetch harvest forecast history")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, history)
+}
+
+// parseSeasons reads optional season year query parameters; missing ones are 0.
+func parseSeasons(values url.Values, names ...string) (map[string]int, error) {
+	seasons := make(map[string]int)
+	for _, name := range names {
+		if v := values.Get(name); v != "" {
+			season, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, errors.New("invalid " + name + " season, expected a year")
+			}
+			seasons[name] = season
+		}
+	}
+	return seasons, nil
+}
+
+// harvestError maps a harvest service error to a response, using notFound for sql.ErrNoRows and
+// falling back to 500 with message.
+func harvestError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidHarvest), errors.Is(err, service.ErrInvalidBlock):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrPHIViolation):
+		util.ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for Phenology
+
+// CreatePhenologyObservation records a stage seen in a vineyard, or in one of its blocks when block_id
+// is given.
+func (h *AppHandler) CreatePhenologyObservation(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var obs model.PhenologyObservation
+	if err := json.NewDecoder(r.Body).Decode(&obs); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	obs.VineyardID = vineyardID
+	if err := h.Phenology.CreateObservation(r.Context(), &obs); err != nil {
+		phenologyError(w, err, "Vineyard not found", "Failed to create phenology observation")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, obs)
+}
+
+func (h *AppHandler) GetPhenologyObservation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid phenology observation ID")
+		return
+	}
+	obs, err := h.Phenology.GetObservation(r.Context(), id)
+	if err != nil {
+		phenologyError(w, err, "Phenology observation not found", "Failed to fetch phenology observation")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, obs)
+}
+
+// UpdatePhenologyObservation replaces an observation's date, block, stage, image and notes.
+func (h *AppHandler) UpdatePhenologyObservation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid phenology observation ID")
+		return
+	}
+	var obs model.PhenologyObservation
+	if err := json.NewDecoder(r.Body).Decode(&obs); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	obs.ID = id
+	if err := h.Phenology.UpdateObservation(r.Context(), &obs); err != nil {
+		phenologyError(w, err, "Phenology observation not found", "Failed to update phenology observation")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, obs)
+}
+
+func (h *AppHandler) DeletePhenologyObservation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid phenology observation ID")
+		return
+	}
+	if err := h.Phenology.DeleteObservation(r.Context(), id); err != nil {
+		phenologyError(w, err, "Phenology observation not found", "Failed to delete phenology observation")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListPhenologyObservations returns a vineyard's observations by date; blockId selects those of one block.
+func (h *AppHandler) ListPhenologyObservations(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var blockID int
+	if v := r.URL.Query().Get("blockId"); v != "" {
+		if blockID, err = strconv.Atoi(v); err != nil || blockID <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid block ID")
+			return
+		}
+	}
+	observations, err := h.Phenology.ListObservations(r.Context(), vineyardID, blockID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch phenology observations")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, observations)
+}
+
+// ListPhenologyObservationsByDateRange returns a vineyard's observations made from start to end, both
+// included.
+func (h *AppHandler) ListPhenologyObservationsByDateRange(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	start, end, err := util.ParseDateRange(r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid date range")
+		return
+	}
+	observations, err := h.Phenology.ListObservationsByDateRange(r.Context(), vineyardID, start, end)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch phenology observations")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, observations)
+}
+
+// GetCurrentPhenology returns the current stage of each block of a vineyard, estimated from GDD since
+// the latest observation.
+func (h *AppHandler) GetCurrentPhenology(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	stages, err := h.Phenology.CurrentStages(r.Context(), vineyardID)
+	if err != nil {
+		phenologyError(w, err, "Vineyard not found", "Failed to estimate phenology stages")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, stages)
+}
+
+// ListPhenologyStages returns the E-L stages with their BBCH codes and typical GDD from budburst.
+func (h *AppHandler) ListPhenologyStages(w http.ResponseWriter, r *http.Request) {
+	util.JSONResponse(w, http.StatusOK, phenology.Stages)
+}
+
+// phenologyError maps a phenology service error to a response, using notFound for sql.ErrNoRows and
+// falling back to 500 with message.
+func phenologyError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPhenologyObservation), errors.Is(err, service.ErrInvalidBlock):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for Spray Applications
+
+// CreateSprayApplication records an application to a vineyard, or to a block or area of it.
+func (h *AppHandler) CreateSprayApplication(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var app model.SprayApplication
+	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	app.VineyardID = vineyardID
+	if err := h.Sprays.CreateApplication(r.Context(), &app); err != nil {
+		sprayError(w, err, "Vineyard not found", "Failed to create spray application")
+		return
+	}
+	util.JSONResponse(w, http.StatusCreated, app)
+}
+
+func (h *AppHandler) GetSprayApplication(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid spray application ID")
+		return
+	}
+	app, err := h.Sprays.GetApplication(r.Context(), id)
+	if err != nil {
+		sprayError(w, err, "Spray application not found", "Failed to fetch spray application")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, app)
+}
+
+// UpdateSprayApplication replaces everything about an application but its vineyard.
+func (h *AppHandler) UpdateSprayApplication(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid spray application ID")
+		return
+	}
+	var app model.SprayApplication
+	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	app.ID = id
+	if err := h.Sprays.UpdateApplication(r.Context(), &app); err != nil {
+		sprayError(w, err, "Spray application not found", "Failed to update spray application")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, app)
+}
+
+func (h *AppHandler) DeleteSprayApplication(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid spray application ID")
+		return
+	}
+	if err := h.Sprays.DeleteApplication(r.Context(), id); err != nil {
+		sprayError(w, err, "Spray application not found", "Failed to delete spray application")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListSprayApplications returns a vineyard's applications, oldest first; blockId selects those of one block.
+func (h *AppHandler) ListSprayApplications(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	var blockID int
+	if v := r.URL.Query().Get("blockId"); v != "" {
+		if blockID, err = strconv.Atoi(v); err != nil || blockID <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid block ID")
+			return
+		}
+	}
+	apps, err := h.Sprays.ListApplications(r.Context(), vineyardID, blockID)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch spray applications")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, apps)
+}
+
+// ListSprayApplicationsByDateRange returns a vineyard's applications made from start to end, both included.
+func (h *AppHandler) ListSprayApplicationsByDateRange(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	start, end, err := util.ParseDateRange(r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid date range")
+		return
+	}
+	apps, err := h.Sprays.ListApplicationsByDateRange(r.Context(), vineyardID, start, end)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch spray applications")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, apps)
+}
+
+// ListActiveSprayApplications returns a vineyard's applications still inside their REI or PHI.
+func (h *AppHandler) ListActiveSprayApplications(w http.ResponseWriter, r *http.Request) {
+	vineyardID, err := strconv.Atoi(mux.Vars(r)["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	apps, err := h.Sprays.ActiveApplications(r.Context(), vineyardID)
+	if err != nil {
+		sprayError(w, err, "Vineyard not found", "Failed to fetch active spray applications")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, apps)
+}
+
+// sprayError maps a spray service error to a response, using notFound for sql.ErrNoRows and falling
+// back to 500 with message.
+func sprayError(w http.ResponseWriter, err error, notFound, message string) {
+	switch {
+	case inputError(w, err):
+	case errors.Is(err, service.ErrInvalidSprayApplication), errors.Is(err, service.ErrInvalidBlock):
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		util.ErrorResponse(w, http.StatusNotFound, notFound)
+	default:
+		util.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// Handlers for GeoJSON Export
+
+// ExportVineyardsGeoJSON streams every vineyard as a GeoJSON FeatureCollection.
+func (h *AppHandler) ExportVineyardsGeoJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", geo.ContentType)
+	fw := geo.NewFeatureWriter(w)
+	h.finishExport(w, fw, "vineyards", h.Export.ExportVineyards(r.Context(), fw))
+}
+
+// ExportDatasetGeoJSON streams a vineyard's pests, soil, weather, images or satellite records as a
+// GeoJSON FeatureCollection. start and end (YYYY-MM-DD) select a date range and limit keeps the newest
+// records; pests also accept type and severity.
+func (h *AppHandler) ExportDatasetGeoJSON(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	vineyardID, err := strconv.Atoi(vars["vineyardID"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid vineyard ID")
+		return
+	}
+	filter, err := parseRecordFilter(r)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", geo.ContentType)
+	fw := geo.NewFeatureWriter(w)
+	err = h.Export.ExportDataset(r.Context(), vars["dataset"], vineyardID, filter, fw)
+	if err != nil && !fw.Started() {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			util.ErrorResponse(w, http.StatusNotFound, "Vineyard not found")
+			return
+		case errors.Is(err, service.ErrUnknownDataset):
+			util.ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+	}
+	h.finishExport(w, fw, vars["dataset"], err)
+}
+
+// finishExport closes the collection, or reports err. Once features have been sent the status can no
+// longer change, so the document is left unterminated for the client to detect.
+func (h *AppHandler) finishExport(w http.ResponseWriter, fw *geo.FeatureWriter, dataset string, err error) {
+	if err == nil {
+		err = fw.Close()
+		if err == nil {
+			return
+		}
+	}
+	if !fw.Started() {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not export "+dataset)
+		return
+	}
+	log.Printf("GeoJSON export of %s failed after %d features: %v", dataset, fw.Count(), err)
+}
+
+// parseRecordFilter reads the export query parameters, which match the JSON list endpoints.
+func parseRecordFilter(r *http.Request) (model.RecordFilter, error) {
+	query := r.URL.Query()
+	filter := model.RecordFilter{
+		PestType: query.Get("type"),
+		Severity: query.Get("severity"),
+		Kind:     query.Get("kind"),
+	}
+	switch filter.Kind {
+	case "", model.PestKindObservation, model.PestKindRisk:
+	default:
+		return filter, errors.New("invalid kind, expected observation or risk")
+	}
+	if query.Get("start") != "" || query.Get("end") != "" {
+		start, end, err := util.ParseDateRange(query.Get("start"), query.Get("end"))
+		if err != nil {
+			return filter, errors.New("invalid date range")
+		}
+		filter.Start, filter.End = start, end
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// Handlers for Admin
+
+// ListCircuitBreakers reports the circuit breaker state of every data source that has made a request.
+func (h *AppHandler) ListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	util.JSONResponse(w, http.StatusOK, h.Transport.States())
+}
+
+// ResetCircuitBreaker closes a data source's circuit so requests resume before the open timeout.
+func (h *AppHandler) ResetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	source := mux.Vars(r)["source"]
+	if !h.Transport.Reset(source) {
+		util.ErrorResponse(w, http.StatusNotFound, "No circuit breaker for data source")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, h.Transport.Breaker(source).State())
+}
+
+// StartBackfill starts, or resumes, a backfill in the background. The body gives source, vineyard,
+// and the inclusive from/to days (YYYY-MM-DD); the response is the job to poll.
+func (h *AppHandler) StartBackfill(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Source   string `json:"source"`
+		Vineyard int    `json:"vineyard"`
+		From     string `json:"from"`
+		To       string `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	from, to, err := util.ParseDateRange(body.From, body.To)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid date range")
+		return
+	}
+
+	job, err := h.Backfills.Start(r.Context(), service.BackfillRequest{
+		SourceKey:  body.Source,
+		VineyardID: body.Vineyard,
+		From:       from,
+		To:         to,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBackfillActive):
+			util.ErrorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrUnknownSource), errors.Is(err, service.ErrUnsupportedSource),
+			errors.Is(err, service.ErrBackfillUnsupported):
+			util.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			util.ErrorResponse(w, http.StatusInternalServerError, "Could not start backfill: "+err.Error())
+		}
+		return
+	}
+	util.JSONResponse(w, http.StatusAccepted, job)
+}
+
+// ListBackfills returns the most recent backfill jobs.
+func (h *AppHandler) ListBackfills(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			util.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+	jobs, err := h.Backfills.ListJobs(r.Context(), limit)
+	if err != nil {
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not list backfills")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, jobs)
+}
+
+// GetBackfill returns a backfill job and its progress.
+func (h *AppHandler) GetBackfill(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		util.ErrorResponse(w, http.StatusBadRequest, "Invalid backfill job ID")
+		return
+	}
+	job, err := h.Backfills.GetJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.ErrorResponse(w, http.StatusNotFound, "Backfill job not found")
+			return
+		}
+		util.ErrorResponse(w, http.StatusInternalServerError, "Could not fetch backfill job")
+		return
+	}
+	util.JSONResponse(w, http.StatusOK, job)
+}
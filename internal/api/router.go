@@ -13,31 +13,19 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"github.com/sthompson732/viticulture-harvester-app/internal/config"
-	"github.com/sthompson732/viticulture-harvester-app/internal/service"
 )
 
-func NewRouter(vineyardService service.VineyardService, imageService service.ImageService,
-	soilDataService service.SoilDataService, pestService service.PestService,
-	weatherService service.WeatherService, satelliteService service.SatelliteService, cfg *config.Config) *mux.Router {
+// NewRouter routes requests to the handler behind the logging and API key middleware.
+func NewRouter(handler *AppHandler) *mux.Router {
 	router := mux.NewRouter()
 
-	handler := &AppHandler{
-		VineyardService:  vineyardService,
-		ImageService:     imageService,
-		SoilDataService:  soilDataService,
-		PestService:      pestService,
-		WeatherService:   weatherService,
-		SatelliteService: satelliteService,
-	}
-
 	// Middleware for logging and API key verification
 	router.Use(loggingMiddleware)
 	// Middleware to validate API keys
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			apiKey := r.Header.Get("X-API-Key")
-			if !contains(cfg.ValidAPIKeys, apiKey) {
+			if !contains(handler.Cfg.ValidAPIKeys, apiKey) {
 				http.Error(w, "Unauthorized: Invalid API Key", http.StatusUnauthorized)
 				return
 			}
@@ -60,8 +48,20 @@ func defineRoutes(router *mux.Router, handler *AppHandler) {
 	router.HandleFunc("/vineyards", handler.ListVineyards).Methods("GET")
 	router.HandleFunc("/vineyards/{id}/environmental-data", handler.GetVineyardWithEnvironmentalData).Methods("GET")
 
+	// Vineyard block routes
+	router.HandleFunc("/vineyards/{vineyardID}/blocks", handler.CreateBlock).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/blocks", handler.ListBlocks).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/blocks/{blockID}", handler.GetBlock).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/blocks/{blockID}", handler.UpdateBlock).Methods("PUT")
+	router.HandleFunc("/vineyards/{vineyardID}/blocks/{blockID}", handler.DeleteBlock).Methods("DELETE")
+
 	// Dynamic route for data fetching based on the data sources defined in config
-	router.HandleFunc("/fetch-data", handler.FetchDataFromSource).Methods("GET")
+	router.HandleFunc("/fetch-data/{source}", handler.FetchDataFromSource).Methods("GET")
+
+	// Ingestion run history
+	router.HandleFunc("/ingestion/runs", handler.ListIngestionRuns).Methods("GET")
+	router.HandleFunc("/ingestion/runs/{id}", handler.GetIngestionRun).Methods("GET")
+	router.HandleFunc("/ingestion/runs/{id}/retry", handler.RetryIngestionRun).Methods("POST")
 
 	// Image routes
 	router.HandleFunc("/images", handler.SaveImage).Methods("POST")
@@ -104,6 +104,103 @@ func defineRoutes(router *mux.Router, handler *AppHandler) {
 	router.HandleFunc("/vineyards/{vineyardID}/satellite", handler.ListSatelliteData).Methods("GET")
 	router.HandleFunc("/vineyards/{vineyardID}/satellite/date-range", handler.ListSatelliteImageryByDateRange).Methods("POST")
 	router.HandleFunc("/vineyards/{vineyardID}/satellite/recent", handler.GetRecentSatelliteImages).Methods("GET")
+
+	// Spatial query routes
+	router.HandleFunc("/spatial/vineyards/intersecting", handler.FindVineyardsIntersecting).Methods("POST")
+	router.HandleFunc("/spatial/vineyards/near", handler.FindVineyardsNear).Methods("GET")
+	router.HandleFunc("/spatial/pests/within", handler.FindPestsWithin).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/satellite/coverage", handler.GetSatelliteCoverage).Methods("GET")
+
+	// GeoJSON export routes
+	router.HandleFunc("/vineyards.geojson", handler.ExportVineyardsGeoJSON).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/{dataset:pests|soil|weather|images|satellite}.geojson",
+		handler.ExportDatasetGeoJSON).Methods("GET")
+
+	// Climate routes
+	router.HandleFunc("/vineyards/{vineyardID}/climate/gdd", handler.GetGrowingDegreeDays).Methods("GET")
+
+	// Disease risk routes
+	router.HandleFunc("/vineyards/{vineyardID}/disease/run", handler.RunDiseaseModels).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/disease/{model}", handler.GetDiseaseRisk).Methods("GET")
+
+	// Alert routes
+	router.HandleFunc("/vineyards/{vineyardID}/alert-rules", handler.CreateAlertRule).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/alert-rules", handler.ListAlertRules).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/alert-rules/{ruleID}", handler.GetAlertRule).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/alert-rules/{ruleID}", handler.UpdateAlertRule).Methods("PUT")
+	router.HandleFunc("/vineyards/{vineyardID}/alert-rules/{ruleID}", handler.DeleteAlertRule).Methods("DELETE")
+	router.HandleFunc("/vineyards/{vineyardID}/alerts", handler.ListAlerts).Methods("GET")
+	router.HandleFunc("/alerts/{id}", handler.GetAlert).Methods("GET")
+	router.HandleFunc("/alerts/{id}/acknowledge", handler.AcknowledgeAlert).Methods("POST")
+	router.HandleFunc("/alerts/{id}/resolve", handler.ResolveAlert).Methods("POST")
+
+	// Notification routes
+	router.HandleFunc("/vineyards/{vineyardID}/subscribers", handler.CreateSubscriber).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/subscribers", handler.ListSubscribers).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/subscribers/{subscriberID}", handler.GetSubscriber).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/subscribers/{subscriberID}", handler.UpdateSubscriber).Methods("PUT")
+	router.HandleFunc("/vineyards/{vineyardID}/subscribers/{subscriberID}", handler.DeleteSubscriber).Methods("DELETE")
+	router.HandleFunc("/vineyards/{vineyardID}/reports/weekly", handler.GetWeeklyReport).Methods("GET")
+	router.HandleFunc("/notifications/weekly-reports", handler.SendWeeklyReports).Methods("POST")
+
+	// Webhook routes
+	router.HandleFunc("/webhooks", handler.CreateWebhook).Methods("POST")
+	router.HandleFunc("/webhooks", handler.ListWebhooks).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", handler.GetWebhook).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", handler.UpdateWebhook).Methods("PUT")
+	router.HandleFunc("/webhooks/{id}", handler.DeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/deliveries", handler.ListWebhookDeliveries).Methods("GET")
+	router.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}", handler.GetWebhookDelivery).Methods("GET")
+	router.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}/replay", handler.ReplayWebhookDelivery).Methods("POST")
+
+	// Maturity sample routes
+	router.HandleFunc("/vineyards/{vineyardID}/maturity-samples", handler.CreateMaturitySample).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/maturity-samples", handler.ListMaturitySamples).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/maturity-samples/date-range", handler.ListMaturitySamplesByDateRange).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/maturity-samples/trend", handler.GetMaturityTrend).Methods("GET")
+	router.HandleFunc("/maturity-samples/{id}", handler.GetMaturitySample).Methods("GET")
+	router.HandleFunc("/maturity-samples/{id}", handler.UpdateMaturitySample).Methods("PUT")
+	router.HandleFunc("/maturity-samples/{id}", handler.DeleteMaturitySample).Methods("DELETE")
+
+	// Harvest routes
+	router.HandleFunc("/vineyards/{vineyardID}/harvests", handler.CreateHarvest).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/harvests", handler.ListHarvests).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/harvests/date-range", handler.ListHarvestsByDateRange).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/yield", handler.GetSeasonYield).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/yield/comparison", handler.CompareSeasonYields).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/harvest-forecast", handler.GetHarvestForecast).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/harvest-forecast/recompute", handler.RecomputeHarvestForecast).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/harvest-forecast/history", handler.GetHarvestForecastHistory).Methods("GET")
+	router.HandleFunc("/harvests/{id}", handler.GetHarvest).Methods("GET")
+	router.HandleFunc("/harvests/{id}", handler.UpdateHarvest).Methods("PUT")
+	router.HandleFunc("/harvests/{id}", handler.DeleteHarvest).Methods("DELETE")
+
+	// Phenology routes
+	router.HandleFunc("/phenology/stages", handler.ListPhenologyStages).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/phenology", handler.CreatePhenologyObservation).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/phenology", handler.ListPhenologyObservations).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/phenology/date-range", handler.ListPhenologyObservationsByDateRange).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/phenology/current", handler.GetCurrentPhenology).Methods("GET")
+	router.HandleFunc("/phenology/{id}", handler.GetPhenologyObservation).Methods("GET")
+	router.HandleFunc("/phenology/{id}", handler.UpdatePhenologyObservation).Methods("PUT")
+	router.HandleFunc("/phenology/{id}", handler.DeletePhenologyObservation).Methods("DELETE")
+
+	// Spray application routes
+	router.HandleFunc("/vineyards/{vineyardID}/spray-applications", handler.CreateSprayApplication).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/spray-applications", handler.ListSprayApplications).Methods("GET")
+	router.HandleFunc("/vineyards/{vineyardID}/spray-applications/date-range", handler.ListSprayApplicationsByDateRange).Methods("POST")
+	router.HandleFunc("/vineyards/{vineyardID}/spray-applications/active", handler.ListActiveSprayApplications).Methods("GET")
+	router.HandleFunc("/spray-applications/{id}", handler.GetSprayApplication).Methods("GET")
+	router.HandleFunc("/spray-applications/{id}", handler.UpdateSprayApplication).Methods("PUT")
+	router.HandleFunc("/spray-applications/{id}", handler.DeleteSprayApplication).Methods("DELETE")
+
+	// Admin routes
+	router.HandleFunc("/admin/circuit-breakers", handler.ListCircuitBreakers).Methods("GET")
+	router.HandleFunc("/admin/circuit-breakers/{source}/reset", handler.ResetCircuitBreaker).Methods("POST")
+	router.HandleFunc("/admin/backfills", handler.StartBackfill).Methods("POST")
+	router.HandleFunc("/admin/backfills", handler.ListBackfills).Methods("GET")
+	router.HandleFunc("/admin/backfills/{id}", handler.GetBackfill).Methods("GET")
+	router.HandleFunc("/admin/outbox", handler.ListOutbox).Methods("GET")
 }
 
 // loggingMiddleware logs the HTTP request method and URL path.
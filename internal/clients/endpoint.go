@@ -0,0 +1,218 @@
+/*
+ * endpoint.go: Expands the placeholders in data source endpoint URLs.
+ * Usage:
+ *   - ExpandEndpoint fills {lat}, {lon}, {date}, {startDate}, {endDate}, {polygon}, {lonLeft},
+ *     {latBottom}, {lonRight}, {latTop} and {apiKey} from EndpointVars.
+ *   - Values are path-escaped before the '?' and query-escaped after it.
+ *   - Unknown placeholders, and placeholders with no value available, are reported as errors
+ *     instead of being sent to the provider verbatim.
+ *   - AppendParams adds the source's params to the expanded URL.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// Placeholder names supported in endpoint templates.
+const (
+	PlaceholderLat       = "lat"
+	PlaceholderLon       = "lon"
+	PlaceholderDate      = "date"
+	PlaceholderStartDate = "startDate"
+	PlaceholderEndDate   = "endDate"
+	PlaceholderPolygon   = "polygon"
+	PlaceholderLonLeft   = "lonLeft"
+	PlaceholderLatBottom = "latBottom"
+	PlaceholderLonRight  = "lonRight"
+	PlaceholderLatTop    = "latTop"
+	PlaceholderAPIKey    = "apiKey"
+)
+
+var (
+	// ErrUnknownPlaceholder is returned for placeholders the expander does not recognise.
+	ErrUnknownPlaceholder = errors.New("unknown endpoint placeholder")
+	// ErrMissingValue is returned when a placeholder has no value, e.g. {lat} without a vineyard.
+	ErrMissingValue = errors.New("no value for endpoint placeholder")
+)
+
+// endpointDateLayout is the format used for all date placeholders.
+const endpointDateLayout = "2006-01-02"
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9]*)\}`)
+
+// EndpointVars holds the values available to an endpoint template.
+type EndpointVars struct {
+	APIKey    string
+	Date      time.Time // Run date, {date}
+	StartDate time.Time // Start of the ingestion window, {startDate}
+	EndDate   time.Time // End of the ingestion window, {endDate}
+
+	// Location fills {lat} and {lon}. When nil the centroid of Geometry is used.
+	Location *geo.Point
+	// Geometry fills {polygon} and the bounding box placeholders.
+	Geometry *model.VineyardGeometry
+}
+
+// NewEndpointVars fills the API key and the ingestion window ending on date from a data source config.
+func NewEndpointVars(source config.DataSourceConfig, date time.Time) (EndpointVars, error) {
+	window, err := source.IngestionWindow()
+	if err != nil {
+		return EndpointVars{}, err
+	}
+	return EndpointVars{
+		APIKey:    source.APIKey,
+		Date:      date,
+		StartDate: date.Add(-window),
+		EndDate:   date,
+	}, nil
+}
+
+// Placeholders returns the placeholder names used in an endpoint template, in order of appearance.
+func Placeholders(template string) []string {
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// NeedsVineyard reports whether the template uses placeholders derived from a vineyard's geometry.
+func NeedsVineyard(template string) bool {
+	for _, name := range Placeholders(template) {
+		switch name {
+		case PlaceholderLat, PlaceholderLon, PlaceholderPolygon,
+			PlaceholderLonLeft, PlaceholderLatBottom, PlaceholderLonRight, PlaceholderLatTop:
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandEndpoint replaces every placeholder in the template with its URL-encoded value.
+func ExpandEndpoint(template string, vars EndpointVars) (string, error) {
+	queryStart := strings.IndexByte(template, '?')
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(template, -1) {
+		name := template[loc[2]:loc[3]]
+		value, err := vars.value(name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(template[last:loc[0]])
+		if queryStart >= 0 && loc[0] > queryStart {
+			b.WriteString(url.QueryEscape(value))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+		last = loc[1]
+	}
+	b.WriteString(template[last:])
+
+	expanded := b.String()
+	if _, err := url.Parse(expanded); err != nil {
+		return "", fmt.Errorf("expanded endpoint is not a valid URL: %w", err)
+	}
+	return expanded, nil
+}
+
+// AppendParams adds a data source's extra query parameters to an expanded endpoint, in key order so
+// the URL is stable between runs.
+func AppendParams(endpoint string, params map[string]string) string {
+	if len(params) == 0 {
+		return endpoint
+	}
+	query := make(url.Values)
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode()
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+// value returns the unescaped value of a placeholder.
+func (v EndpointVars) value(name string) (string, error) {
+	missing := func() (string, error) {
+		return "", fmt.Errorf("%w: {%s}", ErrMissingValue, name)
+	}
+	date := func(t time.Time) (string, error) {
+		if t.IsZero() {
+			return missing()
+		}
+		return t.Format(endpointDateLayout), nil
+	}
+
+	switch name {
+	case PlaceholderAPIKey:
+		if v.APIKey == "" {
+			return missing()
+		}
+		return v.APIKey, nil
+	case PlaceholderDate:
+		return date(v.Date)
+	case PlaceholderStartDate:
+		return date(v.StartDate)
+	case PlaceholderEndDate:
+		return date(v.EndDate)
+	case PlaceholderLat, PlaceholderLon:
+		location := v.Location
+		if location == nil && v.Geometry != nil {
+			location = &v.Geometry.Centroid
+		}
+		if location == nil {
+			return missing()
+		}
+		if name == PlaceholderLat {
+			return formatCoordinate(location.Lat), nil
+		}
+		return formatCoordinate(location.Lon), nil
+	case PlaceholderPolygon:
+		if v.Geometry == nil || len(v.Geometry.Polygon) == 0 {
+			return missing()
+		}
+		polygon, err := json.Marshal(v.Geometry.Polygon)
+		if err != nil {
+			return "", err
+		}
+		return string(polygon), nil
+	case PlaceholderLonLeft, PlaceholderLatBottom, PlaceholderLonRight, PlaceholderLatTop:
+		if v.Geometry == nil {
+			return missing()
+		}
+		switch name {
+		case PlaceholderLonLeft:
+			return formatCoordinate(v.Geometry.MinLon), nil
+		case PlaceholderLatBottom:
+			return formatCoordinate(v.Geometry.MinLat), nil
+		case PlaceholderLonRight:
+			return formatCoordinate(v.Geometry.MaxLon), nil
+		default:
+			return formatCoordinate(v.Geometry.MaxLat), nil
+		}
+	default:
+		return "", fmt.Errorf("%w: {%s}", ErrUnknownPlaceholder, name)
+	}
+}
+
+// formatCoordinate prints degrees in the shortest form that round-trips, without trailing zeros.
+func formatCoordinate(deg float64) string {
+	return strconv.FormatFloat(deg, 'f', -1, 64)
+}
@@ -0,0 +1,38 @@
+/*
+ * http.go: Builds requests to configured data sources.
+ * Usage: NewSourceRequest builds the request for a configured data source, the way Cloud Scheduler
+ *        jobs call it. The provider clients send their requests through transport.Registry.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+)
+
+// NewSourceRequest builds a request to an expanded data source endpoint with the source's params,
+// headers and, for POST, PUT and PATCH, body. Sources without an httpMethod use GET.
+func NewSourceRequest(ctx context.Context, source config.DataSourceConfig, endpoint string) (*http.Request, error) {
+	method := strings.ToUpper(source.HttpMethod)
+	if method == "" {
+		method = http.MethodGet
+	}
+	body := ""
+	if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+		body = source.Body
+	}
+	req, err := http.NewRequestWithContext(ctx, method, AppendParams(endpoint, source.Params), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range source.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
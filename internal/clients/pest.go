@@ -22,27 +22,40 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
 )
 
 type PestClient struct {
-	APIKey  string
-	BaseURL string
-	Client  *http.Client
+	APIKey   string
+	Endpoint string // URL template, see ExpandEndpoint
+	Client   *http.Client
 }
 
-func NewPestClient(apiKey, baseURL string) *PestClient {
+// NewPestClient requests through the registry's client for the "pest" source, so calls are retried
+// and share its circuit breaker.
+func NewPestClient(apiKey, endpoint string, clients *transport.Registry) *PestClient {
 	return &PestClient{
-		APIKey:  apiKey,
-		BaseURL: baseURL,
-		Client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		APIKey:   apiKey,
+		Endpoint: endpoint,
+		Client:   clients.Client("pest"),
 	}
 }
 
-func (pc *PestClient) FetchPestData(ctx context.Context, location string) (*model.PestData, error) {
-	reqURL := fmt.Sprintf("%s/api/pests?location=%s&apikey=%s", pc.BaseURL, location, pc.APIKey)
+// FetchPestData expands the endpoint template for the given point and requests the current pest data.
+func (pc *PestClient) FetchPestData(ctx context.Context, lat, lon float64) (*model.PestData, error) {
+	now := time.Now().UTC()
+	reqURL, err := ExpandEndpoint(pc.Endpoint, EndpointVars{
+		APIKey:   pc.APIKey,
+		Date:     now,
+		EndDate:  now,
+		Location: &geo.Point{Lon: lon, Lat: lat},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building pest request URL: %w", err)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -16,43 +16,51 @@ import (
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
 )
 
 // SatelliteClient is configured to fetch satellite imagery data.
 type SatelliteClient struct {
 	Config *config.Config
+	Client *http.Client
 }
 
 // NewSatelliteClient initializes a SatelliteClient with application configuration.
-func NewSatelliteClient(cfg *config.Config) *SatelliteClient {
+// Requests go through the registry's client for the "satellite" source.
+func NewSatelliteClient(cfg *config.Config, clients *transport.Registry) *SatelliteClient {
 	return &SatelliteClient{
 		Config: cfg,
+		Client: clients.Client("satellite"),
 	}
 }
 
 // FetchData makes an HTTP request to the satellite imagery API and returns structured data.
-func (c *SatelliteClient) FetchData(ctx context.Context, lat, long string, startDate, endDate time.Time) (*model.SatelliteData, error) {
+// The endpoint template's {date} is the end of the requested range.
+func (c *SatelliteClient) FetchData(ctx context.Context, lat, lon float64, startDate, endDate time.Time) (*model.SatelliteData, error) {
 	satelliteConfig, ok := c.Config.DataSources["satellite"]
 	if !ok {
 		return nil, fmt.Errorf("satellite data source configuration not found")
 	}
 
-	reqURL := fmt.Sprintf("%s?lat=%s&lon=%s&start_date=%s&end_date=%s&api_key=%s",
-		satelliteConfig.Endpoint,
-		lat, long,
-		startDate.Format("2006-01-02"),
-		endDate.Format("2006-01-02"),
-		satelliteConfig.APIKey,
-	)
+	reqURL, err := ExpandEndpoint(satelliteConfig.Endpoint, EndpointVars{
+		APIKey:    satelliteConfig.APIKey,
+		Date:      endDate,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Location:  &geo.Point{Lon: lon, Lat: lat},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building satellite request URL: %w", err)
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating new request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
@@ -15,41 +15,50 @@ import (
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
 )
 
 // SoilClient manages interactions with a soil data API.
 type SoilClient struct {
 	Config *config.Config
+	Client *http.Client
 }
 
 // NewSoilClient creates a new instance of SoilClient with configuration settings.
-func NewSoilClient(cfg *config.Config) *SoilClient {
+// Requests go through the registry's client for the "soil" source.
+func NewSoilClient(cfg *config.Config, clients *transport.Registry) *SoilClient {
 	return &SoilClient{
 		Config: cfg,
+		Client: clients.Client("soil"),
 	}
 }
 
 // FetchData queries the soil data API and returns structured information.
-func (c *SoilClient) FetchData(ctx context.Context, lat, long string) (*model.SoilData, error) {
+func (c *SoilClient) FetchData(ctx context.Context, lat, lon float64) (*model.SoilData, error) {
 	soilConfig, ok := c.Config.DataSources["soil"]
 	if !ok {
 		return nil, fmt.Errorf("soil data source configuration not found")
 	}
 
-	reqURL := fmt.Sprintf("%s?latitude=%s&longitude=%s&api_key=%s",
-		soilConfig.Endpoint,
-		lat, long,
-		soilConfig.APIKey,
-	)
+	now := time.Now().UTC()
+	reqURL, err := ExpandEndpoint(soilConfig.Endpoint, EndpointVars{
+		APIKey:   soilConfig.APIKey,
+		Date:     now,
+		EndDate:  now,
+		Location: &geo.Point{Lon: lon, Lat: lat},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building soil request URL: %w", err)
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
 	}
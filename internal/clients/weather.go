@@ -22,27 +22,40 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
 )
 
 type WeatherClient struct {
-	APIKey  string
-	BaseURL string
-	Client  *http.Client
+	APIKey   string
+	Endpoint string // URL template, see ExpandEndpoint
+	Client   *http.Client
 }
 
-func NewWeatherClient(apiKey, baseURL string) *WeatherClient {
+// NewWeatherClient requests through the registry's client for the "weather" source, so calls are
+// retried and share its circuit breaker.
+func NewWeatherClient(apiKey, endpoint string, clients *transport.Registry) *WeatherClient {
 	return &WeatherClient{
-		APIKey:  apiKey,
-		BaseURL: baseURL,
-		Client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		APIKey:   apiKey,
+		Endpoint: endpoint,
+		Client:   clients.Client("weather"),
 	}
 }
 
-func (wc *WeatherClient) FetchWeatherData(ctx context.Context, location string) (*model.WeatherData, error) {
-	reqURL := fmt.Sprintf("%s/weather?location=%s&apikey=%s", wc.BaseURL, location, wc.APIKey)
+// FetchWeatherData expands the endpoint template for the given point and requests the current weather data.
+func (wc *WeatherClient) FetchWeatherData(ctx context.Context, lat, lon float64) (*model.WeatherData, error) {
+	now := time.Now().UTC()
+	reqURL, err := ExpandEndpoint(wc.Endpoint, EndpointVars{
+		APIKey:   wc.APIKey,
+		Date:     now,
+		EndDate:  now,
+		Location: &geo.Point{Lon: lon, Lat: lat},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building weather request URL: %w", err)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
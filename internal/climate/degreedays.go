@@ -0,0 +1,109 @@
+/*
+ * degreedays.go: Growing degree days from daily minimum and maximum temperatures.
+ * Usage: ParseSettings turns the climate section of the config into Settings, whose DegreeDays computes
+ *        one day's GDD with the simple average or single sine method. Temperatures are in °C and
+ *        days are calendar days in the configured time zone.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package climate
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+)
+
+// Degree day methods.
+const (
+	MethodAverage = "average" // (min + max) / 2 - base
+	MethodSine    = "sine"    // Single sine (Baskerville-Emin), the area of a sine curve through min and max above base
+)
+
+// DefaultBaseTemp is the base temperature for vines, below which they are assumed not to grow.
+const DefaultBaseTemp = 10.0
+
+// Settings controls how growing degree days are computed.
+type Settings struct {
+	Method   string
+	BaseTemp float64
+	CapTemp  float64 // Horizontal cutoff; temperatures above it count as CapTemp. 0 for none
+	Location *time.Location
+}
+
+// ParseSettings applies defaults to the climate config and validates it.
+func ParseSettings(cfg config.ClimateConfig) (Settings, error) {
+	s := Settings{Method: cfg.Method, BaseTemp: cfg.BaseTemp, CapTemp: cfg.CapTemp, Location: time.UTC}
+	if s.Method == "" {
+		s.Method = MethodAverage
+	}
+	if s.BaseTemp == 0 {
+		s.BaseTemp = DefaultBaseTemp
+	}
+	if cfg.TimeZone != "" {
+		loc, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			return s, fmt.Errorf("invalid time zone %q", cfg.TimeZone)
+		}
+		s.Location = loc
+	}
+	return s, s.Validate()
+}
+
+// Validate checks the method and that the cap, when set, is above the base.
+func (s Settings) Validate() error {
+	if s.Method != MethodAverage && s.Method != MethodSine {
+		return fmt.Errorf("unknown degree day method %q, expected %s or %s", s.Method, MethodAverage, MethodSine)
+	}
+	if s.CapTemp != 0 && s.CapTemp <= s.BaseTemp {
+		return fmt.Errorf("cap temperature %g must be above the base temperature %g", s.CapTemp, s.BaseTemp)
+	}
+	return nil
+}
+
+// DegreeDays returns the growing degree days of a day with the given temperature range.
+func (s Settings) DegreeDays(min, max float64) float64 {
+	if max < min {
+		min, max = max, min
+	}
+	upper := math.Inf(1)
+	if s.CapTemp != 0 {
+		upper = s.CapTemp
+	}
+	if s.Method == MethodSine {
+		return sineDegreeDays(min, max, s.BaseTemp, upper)
+	}
+	return math.Max(0, (math.Min(min, upper)+math.Min(max, upper))/2-s.BaseTemp)
+}
+
+// sineDegreeDays integrates a sine curve from min to max between the base and upper thresholds,
+// following the UC IPM single sine method with a horizontal cutoff.
+func sineDegreeDays(min, max, base, upper float64) float64 {
+	switch {
+	case max <= base:
+		return 0
+	case min >= upper:
+		return upper - base
+	case min >= base && max <= upper:
+		return (min+max)/2 - base
+	}
+
+	mean := (min + max) / 2
+	amplitude := (max - min) / 2
+	// Phase angles where the curve crosses the thresholds; -π/2 and π/2 when it stays inside them.
+	lower, cutoff := -math.Pi/2, math.Pi/2
+	if min < base {
+		lower = math.Asin((base - mean) / amplitude)
+	}
+	if max > upper {
+		cutoff = math.Asin((upper - mean) / amplitude)
+	}
+	dd := (mean-base)*(cutoff-lower) + amplitude*(math.Cos(lower)-math.Cos(cutoff))
+	if max > upper {
+		dd += (upper - base) * (math.Pi/2 - cutoff)
+	}
+	return dd / math.Pi
+}
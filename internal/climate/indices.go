@@ -0,0 +1,180 @@
+/*
+ * indices.go: Heat summation indices of a growing season.
+ * Usage: Each index sums daily values over a fixed window from the conventional season start, 1 April in
+ *        the northern hemisphere and 1 October in the southern. Winkler and BEDD run for seven months
+ *        (to 31 October or 30 April), Huglin for six (to 30 September or 31 March). Days without
+ *        observations are left out, so an index over a window with gaps is reported as incomplete.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package climate
+
+import (
+	"math"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// SeasonStart returns the conventional start of the growing season containing day.
+func SeasonStart(day time.Time, latitude float64) time.Time {
+	month := time.April
+	if latitude < 0 {
+		month = time.October
+	}
+	start := time.Date(day.Year(), month, 1, 0, 0, 0, 0, day.Location())
+	if day.Before(start) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	return start
+}
+
+// SeasonYear returns the season containing day, named by the year its fruit is picked in: the year it
+// starts in the northern hemisphere and the year after in the southern.
+func SeasonYear(day time.Time, latitude float64) int {
+	year := SeasonStart(day, latitude).Year()
+	if latitude < 0 {
+		year++
+	}
+	return year
+}
+
+// Season returns the first and last day of the season SeasonYear names year.
+func Season(year int, latitude float64, loc *time.Location) (from, to time.Time) {
+	from = time.Date(year, time.April, 1, 0, 0, 0, 0, loc)
+	if latitude < 0 {
+		from = time.Date(year-1, time.October, 1, 0, 0, 0, 0, loc)
+	}
+	return from, from.AddDate(1, 0, -1)
+}
+
+// Winkler sums average-method degree days, base 10°C without a cap, and classifies the total into
+// Winkler regions I to V.
+func Winkler(days []model.DailyTemperature, seasonStart, to time.Time) model.HeatIndex {
+	idx, ended := summarize(days, seasonStart, to, 7, func(d model.DailyTemperature) float64 {
+		return math.Max(0, (d.Min+d.Max)/2-DefaultBaseTemp)
+	})
+	if ended {
+		idx.Class = winklerRegion(idx.Value)
+	}
+	return idx
+}
+
+// Huglin sums the average of the mean and maximum temperatures above 10°C, weighted by Huglin's day
+// length coefficient for the latitude, and classifies the total after Tonietto and Carbonneau (2004).
+func Huglin(days []model.DailyTemperature, seasonStart, to time.Time, latitude float64) model.HeatIndex {
+	k := huglinCoefficient(latitude)
+	idx, ended := summarize(days, seasonStart, to, 6, func(d model.DailyTemperature) float64 {
+		mean := (d.Min + d.Max) / 2
+		return math.Max(0, (mean-DefaultBaseTemp+d.Max-DefaultBaseTemp)/2) * k
+	})
+	if ended {
+		idx.Class = huglinClass(idx.Value)
+	}
+	return idx
+}
+
+// BEDD sums Gladstones' biologically effective degree days: average-method degree days scaled by
+// latitude, adjusted for the diurnal range and capped at 9 per day.
+func BEDD(days []model.DailyTemperature, seasonStart, to time.Time, latitude float64) model.HeatIndex {
+	k := gladstonesCoefficient(latitude)
+	idx, _ := summarize(days, seasonStart, to, 7, func(d model.DailyTemperature) float64 {
+		dd := (d.Min+d.Max)/2 - DefaultBaseTemp
+		if dd <= 0 {
+			return 0
+		}
+		dd *= k
+		switch dtr := d.Max - d.Min; {
+		case dtr > 13:
+			dd += 0.25 * (dtr - 13)
+		case dtr < 10:
+			dd += 0.25 * (dtr - 10)
+		}
+		return math.Min(9, math.Max(0, dd))
+	})
+	return idx
+}
+
+// summarize sums daily over the months-long window from seasonStart, stopping at to. It reports
+// whether the window has ended by to.
+func summarize(days []model.DailyTemperature, seasonStart, to time.Time, months int,
+	daily func(model.DailyTemperature) float64) (model.HeatIndex, bool) {
+	end := seasonStart.AddDate(0, months, -1)
+	idx := model.HeatIndex{From: seasonStart, To: end}
+	ended := !to.Before(end)
+	if !ended {
+		idx.To = to
+	}
+	for _, d := range days {
+		if d.Date.Before(idx.From) || d.Date.After(idx.To) {
+			continue
+		}
+		idx.Value += daily(d)
+		idx.Days++
+	}
+	idx.Value = Round(idx.Value)
+	idx.Complete = ended && idx.Days == DayCount(idx.From, idx.To)
+	return idx, ended
+}
+
+// DayCount returns the number of calendar days from from to to, both included.
+func DayCount(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours()/24)) + 1
+}
+
+// Round rounds degree days to one decimal for reporting.
+func Round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func winklerRegion(gdd float64) string {
+	switch {
+	case gdd < 1389:
+		return "Region I"
+	case gdd < 1667:
+		return "Region II"
+	case gdd < 1944:
+		return "Region III"
+	case gdd < 2222:
+		return "Region IV"
+	default:
+		return "Region V"
+	}
+}
+
+func huglinClass(hi float64) string {
+	switch {
+	case hi <= 1500:
+		return "very cool"
+	case hi <= 1800:
+		return "cool"
+	case hi <= 2100:
+		return "temperate"
+	case hi <= 2400:
+		return "warm temperate"
+	case hi <= 3000:
+		return "warm"
+	default:
+		return "very warm"
+	}
+}
+
+// huglinCoefficient is 1 below 40° of latitude, then 1.02 rising by 0.01 every 2° to 1.06 from 48°.
+func huglinCoefficient(latitude float64) float64 {
+	lat := math.Abs(latitude)
+	switch {
+	case lat < 40:
+		return 1
+	case lat >= 48:
+		return 1.06
+	default:
+		return 1.02 + 0.01*math.Floor((lat-40)/2)
+	}
+}
+
+// gladstonesCoefficient rises linearly from 1 at 40° of latitude to 1.045 at 50°.
+func gladstonesCoefficient(latitude float64) float64 {
+	lat := math.Min(math.Max(math.Abs(latitude), 40), 50)
+	return 1 + 0.045*(lat-40)/10
+}
@@ -8,8 +8,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,25 +25,47 @@ type Config struct {
 	CloudStorage      CloudStorageConfig          `yaml:"cloudStorage"`
 	DataSources       map[string]DataSourceConfig `yaml:"dataSources"` // Changed to a map
 	IngestionSettings IngestionSettingsConfig     `yaml:"ingestionSettings"`
+	Scheduler         SchedulerConfig             `yaml:"scheduler"`
 	Notifications     NotificationsConfig         `yaml:"notifications"`
+	Climate           ClimateConfig               `yaml:"climate"`
 	ProjectID         string                      `yaml:"projectID"`
 	LocationID        string                      `yaml:"locationID"`
 	ValidAPIKeys      []string                    `yaml:"validApiKeys"`
 }
 
 type AppConfig struct {
-	Port     string `yaml:"port"`
-	LogLevel string `yaml:"logLevel"`
+	Port      string `yaml:"port"`
+	LogLevel  string `yaml:"logLevel"`
+	PublicURL string `yaml:"publicURL"` // Externally reachable base URL, used as the Cloud Scheduler target for templated endpoints
 }
 
 type DatabaseConfig struct {
 	Type             string `yaml:"type"`
 	ConnectionString string `yaml:"connectionString"`
+	AutoMigrate      bool   `yaml:"autoMigrate"` // Apply pending schema migrations at startup
 }
 
 type CloudStorageConfig struct {
-	BucketName      string `yaml:"bucketName"`
-	CredentialsPath string `yaml:"credentialsPath"`
+	Backend         string             `yaml:"backend"` // gcs (default), local or s3
+	BucketName      string             `yaml:"bucketName"`
+	CredentialsPath string             `yaml:"credentialsPath"`
+	Local           LocalStorageConfig `yaml:"local"`
+	S3              S3StorageConfig    `yaml:"s3"`
+}
+
+// LocalStorageConfig configures the filesystem storage backend
+type LocalStorageConfig struct {
+	RootDir string `yaml:"rootDir"`
+	BaseURL string `yaml:"baseURL"` // Optional URL prefix returned for stored files
+}
+
+// S3StorageConfig configures the S3-compatible storage backend, e.g. AWS or MinIO
+type S3StorageConfig struct {
+	Endpoint        string `yaml:"endpoint"` // e.g. http://localhost:9000
+	Region          string `yaml:"region"`
+	AccessKeyID     string `yaml:"accessKeyID"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	UsePathStyle    bool   `yaml:"usePathStyle"` // Required for MinIO
 }
 
 // DataSourceConfig generalized for all data sources
@@ -49,24 +76,193 @@ type DataSourceConfig struct {
 	HttpMethod  string            `yaml:"httpMethod"`
 	Endpoint    string            `yaml:"endpoint"`
 	APIKey      string            `yaml:"apiKey"`
-	Params      map[string]string `yaml:"params"`  // Additional parameters for API calls
-	Headers     map[string]string `yaml:"headers"` // Custom headers for API calls
-	Body        string            `yaml:"body"`    // Added for PUT/PATCH/POST
+	Params      map[string]string `yaml:"params"`   // Additional parameters for API calls
+	Headers     map[string]string `yaml:"headers"`  // Custom headers for API calls
+	Body        string            `yaml:"body"`     // Added for PUT/PATCH/POST
+	Window      string            `yaml:"window"`   // Ingestion window ending on the run date, e.g. "24h" or "7d"; fills {startDate}/{endDate}
+	Mapping     *MappingConfig    `yaml:"mapping"`  // Maps the provider's JSON response onto a model; see internal/mapping
+	Backfill    BackfillConfig    `yaml:"backfill"` // Chunking and pacing for `harvester backfill`
 	Description string            `yaml:"description"`
 }
 
+// BackfillConfig controls how a historical range is split into provider requests
+type BackfillConfig struct {
+	Chunk             string `yaml:"chunk"`             // Days per request, e.g. "30d"; defaults to the source's window, at least one day
+	RequestsPerMinute int    `yaml:"requestsPerMinute"` // Defaults to DefaultBackfillRequestsPerMinute
+}
+
+// MappingConfig declares how a provider's JSON response becomes model records
+type MappingConfig struct {
+	Model  string                  `yaml:"model"`  // weather, soil, pest or satellite
+	Root   string                  `yaml:"root"`   // Optional selector of an array of records; the whole response is one record when empty
+	Fields map[string]FieldMapping `yaml:"fields"` // Keyed by the model's JSON field name, nested fields use dots (location.latitude)
+}
+
+// FieldMapping selects one model field from a response record.
+// In YAML it is either a bare selector string or a mapping with path, convert and optional.
+type FieldMapping struct {
+	Path     string `yaml:"path"`     // JSONPath-style selector, e.g. main.temp or $.weather[0].description
+	Convert  string `yaml:"convert"`  // Optional conversion, e.g. kelvinToCelsius or unixTime
+	Optional bool   `yaml:"optional"` // Leave the field unset instead of failing when the selector matches nothing
+}
+
+// UnmarshalYAML accepts the bare selector shorthand, e.g. `humidity: main.humidity`.
+func (fm *FieldMapping) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		fm.Path = value.Value
+		return nil
+	}
+	type plain FieldMapping
+	return value.Decode((*plain)(fm))
+}
+
+// Outbox defaults applied when OutboxConfig leaves a value unset.
+const (
+	DefaultOutboxPollInterval = 30 * time.Second
+	DefaultOutboxMaxAttempts  = 8
+	DefaultOutboxBatchSize    = 20
+)
+
+// Interval parses PollInterval, defaulting to DefaultOutboxPollInterval.
+func (o OutboxConfig) Interval() (time.Duration, error) {
+	return parsePositiveDuration("outbox pollInterval", o.PollInterval, DefaultOutboxPollInterval)
+}
+
+// parsePositiveDuration parses a duration setting named name, returning def when it is unset.
+func parsePositiveDuration(name, value string, def time.Duration) (time.Duration, error) {
+	if strings.TrimSpace(value) == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return d, nil
+}
+
+// Webhook defaults applied when WebhookConfig leaves a value unset.
+const (
+	DefaultWebhookPollInterval = 10 * time.Second
+	DefaultWebhookMaxAttempts  = 10
+	DefaultWebhookBatchSize    = 20
+	DefaultWebhookTimeout      = 10 * time.Second
+)
+
+// Interval parses PollInterval, defaulting to DefaultWebhookPollInterval.
+func (w WebhookConfig) Interval() (time.Duration, error) {
+	return parsePositiveDuration("webhooks pollInterval", w.PollInterval, DefaultWebhookPollInterval)
+}
+
+// RequestTimeout parses Timeout, defaulting to DefaultWebhookTimeout.
+func (w WebhookConfig) RequestTimeout() (time.Duration, error) {
+	return parsePositiveDuration("webhooks timeout", w.Timeout, DefaultWebhookTimeout)
+}
+
+// DefaultIngestionWindow is used for data sources that do not set a window.
+const DefaultIngestionWindow = 24 * time.Hour
+
+// DefaultBackfillRequestsPerMinute paces backfills of data sources that do not set requestsPerMinute.
+const DefaultBackfillRequestsPerMinute = 30
+
+// IngestionWindow parses Window, which accepts Go durations plus a whole-day "d" suffix.
+func (ds DataSourceConfig) IngestionWindow() (time.Duration, error) {
+	if strings.TrimSpace(ds.Window) == "" {
+		return DefaultIngestionWindow, nil
+	}
+	d, err := parseWindow(ds.Window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q", ds.Window)
+	}
+	return d, nil
+}
+
+// BackfillChunkDays returns the number of days fetched per backfill request: Backfill.Chunk,
+// else the ingestion window, rounded down to whole days and at least one.
+func (ds DataSourceConfig) BackfillChunkDays() (int, error) {
+	chunk, err := ds.IngestionWindow()
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(ds.Backfill.Chunk) != "" {
+		if chunk, err = parseWindow(ds.Backfill.Chunk); err != nil {
+			return 0, fmt.Errorf("invalid backfill chunk %q", ds.Backfill.Chunk)
+		}
+	}
+	days := int(chunk / (24 * time.Hour))
+	if days < 1 {
+		days = 1
+	}
+	return days, nil
+}
+
+// parseWindow accepts Go durations plus a whole-day "d" suffix.
+func parseWindow(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, errors.New("invalid day count")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid duration")
+	}
+	return d, nil
+}
+
 type IngestionSettingsConfig struct {
-	RetryPolicy        RetryPolicyConfig `yaml:"retryPolicy"`
-	ParallelIngestions int               `yaml:"parallelIngestions"`
+	RetryPolicy        RetryPolicyConfig    `yaml:"retryPolicy"`
+	CircuitBreaker     CircuitBreakerConfig `yaml:"circuitBreaker"`
+	RequestTimeout     string               `yaml:"requestTimeout"` // Per attempt, e.g. "10s"
+	ParallelIngestions int                  `yaml:"parallelIngestions"`
+}
+
+// SchedulerConfig selects where data source schedules are executed
+type SchedulerConfig struct {
+	Mode string `yaml:"mode"` // cloud (Google Cloud Scheduler, default) or local (in-process cron)
 }
 
 type RetryPolicyConfig struct {
 	MaxRetries      int    `yaml:"maxRetries"`
-	BackoffInterval string `yaml:"backoffInterval"`
+	BackoffInterval string `yaml:"backoffInterval"` // Delay before the first retry, doubled for each further retry
+	MaxBackoff      string `yaml:"maxBackoff"`      // Upper bound for a single delay, including Retry-After
+}
+
+// CircuitBreakerConfig controls when calls to a failing provider are stopped
+type CircuitBreakerConfig struct {
+	FailureThreshold int    `yaml:"failureThreshold"` // Consecutive failed requests that open the circuit
+	OpenTimeout      string `yaml:"openTimeout"`      // How long the circuit stays open before a trial request
+}
+
+// ClimateConfig controls how growing degree days are computed from weather observations
+type ClimateConfig struct {
+	Method   string  `yaml:"method"`   // average (default) or sine
+	BaseTemp float64 `yaml:"baseTemp"` // °C, defaults to 10
+	CapTemp  float64 `yaml:"capTemp"`  // °C; higher temperatures count as the cap. 0 for none
+	TimeZone string  `yaml:"timeZone"` // Observations are grouped into calendar days in this zone, default UTC
 }
 
 type NotificationsConfig struct {
 	EmailService EmailServiceConfig `yaml:"emailService"`
+	Outbox       OutboxConfig       `yaml:"outbox"`
+	Webhooks     WebhookConfig      `yaml:"webhooks"`
+}
+
+// OutboxConfig controls delivery of queued email
+type OutboxConfig struct {
+	PollInterval string `yaml:"pollInterval"` // How often due messages are sent, e.g. "30s"
+	MaxAttempts  int    `yaml:"maxAttempts"`  // Failed sends are retried with backoff up to this many attempts
+	BatchSize    int    `yaml:"batchSize"`    // Messages sent per poll
+}
+
+// WebhookConfig controls delivery of webhook events
+type WebhookConfig struct {
+	PollInterval string `yaml:"pollInterval"` // How often due deliveries are sent, e.g. "10s"
+	MaxAttempts  int    `yaml:"maxAttempts"`  // Failed deliveries are retried with backoff up to this many attempts
+	BatchSize    int    `yaml:"batchSize"`    // Deliveries sent per poll
+	Timeout      string `yaml:"timeout"`      // Per attempt, e.g. "10s"
 }
 
 type EmailServiceConfig struct {
@@ -12,10 +12,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
 )
 
@@ -23,6 +25,9 @@ type DB struct {
 	*sql.DB
 }
 
+// ErrDuplicate is returned when a write would break a unique constraint, e.g. a second block with the same name.
+var ErrDuplicate = errors.New("duplicate record")
+
 func NewDB(dsn string) (*DB, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
@@ -55,11 +60,13 @@ func (db *DB) SaveData(ctx context.Context, data interface{}, dataType string) e
 // Image methods
 
 func (db *DB) SaveImage(ctx context.Context, image *model.Image) error {
-	const query = `
-    INSERT INTO images (vineyard_id, url, description, captured_at, bbox)
-    VALUES ($1, $2, $3, $4, $5)
-    RETURNING id`
-	err := db.QueryRowContext(ctx, query, image.VineyardID, image.URL, image.Description, image.CapturedAt, image.BoundingBox).Scan(&image.ID)
+	query := `
+    INSERT INTO images (vineyard_id, image_url, description, captured_at, bbox, block_id)
+    VALUES ($1, $2, $3, $4, ST_SetSRID(ST_GeomFromGeoJSON($5), 4326),
+            ` + blockAt("$6", "$1", "ST_PointOnSurface(ST_SetSRID(ST_GeomFromGeoJSON($5), 4326))") + `)
+    RETURNING id, block_id`
+	err := db.QueryRowContext(ctx, query, image.VineyardID, image.URL, image.Description, image.CapturedAt, image.BoundingBox,
+		image.BlockID).Scan(&image.ID, &image.BlockID)
 	if err != nil {
 		return fmt.Errorf("inserting image: %w", err)
 	}
@@ -68,11 +75,11 @@ func (db *DB) SaveImage(ctx context.Context, image *model.Image) error {
 
 func (db *DB) GetImage(ctx context.Context, id int) (*model.Image, error) {
 	const query = `
-    SELECT id, vineyard_id, url, captured_at
+    SELECT id, vineyard_id, block_id, image_url, description, captured_at, ST_AsGeoJSON(bbox)
     FROM images
     WHERE id = $1`
 	img := &model.Image{}
-	err := db.QueryRowContext(ctx, query, id).Scan(&img.ID, &img.VineyardID, &img.URL, &img.CapturedAt)
+	err := db.QueryRowContext(ctx, query, id).Scan(&img.ID, &img.VineyardID, &img.BlockID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox)
 	if err != nil {
 		return nil, fmt.Errorf("retrieving image by ID: %w", err)
 	}
@@ -89,7 +96,7 @@ func (db *DB) DeleteImage(ctx context.Context, id int) error {
 }
 
 func (db *DB) FindImagesByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.Image, error) {
-	query := `SELECT id, vineyard_id, image_url, description, captured_at, bbox FROM images
+	query := `SELECT id, vineyard_id, block_id, image_url, description, captured_at, ST_AsGeoJSON(bbox) FROM images
               WHERE vineyard_id = $1 AND captured_at BETWEEN $2 AND $3`
 	rows, err := db.QueryContext(ctx, query, vineyardID, start, end)
 	if err != nil {
@@ -100,7 +107,7 @@ func (db *DB) FindImagesByDateRange(ctx context.Context, vineyardID int, start,
 	var images []model.Image
 	for rows.Next() {
 		var img model.Image
-		if err := rows.Scan(&img.ID, &img.VineyardID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox); err != nil {
+		if err := rows.Scan(&img.ID, &img.VineyardID, &img.BlockID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox); err != nil {
 			return nil, fmt.Errorf("error scanning image: %w", err)
 		}
 		images = append(images, img)
@@ -112,7 +119,7 @@ func (db *DB) FindImagesByDateRange(ctx context.Context, vineyardID int, start,
 }
 
 func (db *DB) GetRecentImages(ctx context.Context, vineyardID int, limit int) ([]model.Image, error) {
-	query := `SELECT id, vineyard_id, image_url, description, captured_at, bbox FROM images
+	query := `SELECT id, vineyard_id, block_id, image_url, description, captured_at, ST_AsGeoJSON(bbox) FROM images
               WHERE vineyard_id = $1 ORDER BY captured_at DESC LIMIT $2`
 	rows, err := db.QueryContext(ctx, query, vineyardID, limit)
 	if err != nil {
@@ -123,7 +130,7 @@ func (db *DB) GetRecentImages(ctx context.Context, vineyardID int, limit int) ([
 	var images []model.Image
 	for rows.Next() {
 		var img model.Image
-		if err := rows.Scan(&img.ID, &img.VineyardID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox); err != nil {
+		if err := rows.Scan(&img.ID, &img.VineyardID, &img.BlockID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox); err != nil {
 			return nil, fmt.Errorf("error scanning image: %w", err)
 		}
 		images = append(images, img)
@@ -137,7 +144,7 @@ func (db *DB) GetRecentImages(ctx context.Context, vineyardID int, limit int) ([
 // ListImagesByVineyard retrieves all images for a specific vineyard.
 func (db *DB) ListImagesByVineyard(ctx context.Context, vineyardID int) ([]model.Image, error) {
 	const query = `
-    SELECT id, vineyard_id, image_url, description, captured_at, bbox
+    SELECT id, vineyard_id, block_id, image_url, description, captured_at, ST_AsGeoJSON(bbox)
     FROM images
     WHERE vineyard_id = $1`
 	rows, err := db.QueryContext(ctx, query, vineyardID)
@@ -149,7 +156,7 @@ func (db *DB) ListImagesByVineyard(ctx context.Context, vineyardID int) ([]model
 	var images []model.Image
 	for rows.Next() {
 		var img model.Image
-		err := rows.Scan(&img.ID, &img.VineyardID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox)
+		err := rows.Scan(&img.ID, &img.VineyardID, &img.BlockID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox)
 		if err != nil {
 			return nil, fmt.Errorf("scanning image: %w", err)
 		}
@@ -163,11 +170,14 @@ func (db *DB) ListImagesByVineyard(ctx context.Context, vineyardID int) ([]model
 
 // UpdateImage updates the details for an existing image.
 func (db *DB) UpdateImage(ctx context.Context, image *model.Image) error {
-	const query = `
+	query := `
     UPDATE images
-    SET vineyard_id = $1, image_url = $2, description = $3, captured_at = $4, bbox = $5
-    WHERE id = $6`
-	_, err := db.ExecContext(ctx, query, image.VineyardID, image.URL, image.Description, image.CapturedAt, image.BoundingBox, image.ID)
+    SET vineyard_id = $1, image_url = $2, description = $3, captured_at = $4, bbox = ST_SetSRID(ST_GeomFromGeoJSON($5), 4326),
+        block_id = ` + blockAt("$6", "$1", "ST_PointOnSurface(ST_SetSRID(ST_GeomFromGeoJSON($5), 4326))") + `
+    WHERE id = $7
+    RETURNING block_id`
+	err := db.QueryRowContext(ctx, query, image.VineyardID, image.URL, image.Description, image.CapturedAt, image.BoundingBox,
+		image.BlockID, image.ID).Scan(&image.BlockID)
 	if err != nil {
 		return fmt.Errorf("updating image: %w", err)
 	}
@@ -175,14 +185,18 @@ func (db *DB) UpdateImage(ctx context.Context, image *model.Image) error {
 }
 
 // Vineyard methods
+
+// lastSuccessfulSyncColumn selects a vineyard's latest successful ingestion run; the vineyards table is aliased v.
+const lastSuccessfulSyncColumn = `SELECT MAX(r.finished_at) FROM ingestion_runs r WHERE r.vineyard_id = v.id AND r.status = 'succeeded'`
+
 // SaveVineyard inserts a new Vineyard record into the database.
 func (db *DB) SaveVineyard(ctx context.Context, vineyard *model.Vineyard) error {
 	// Assuming a simplified structure; adjust according to our schema
 	const query = `
-    INSERT INTO vineyards (name, location) 
-    VALUES ($1, $2) 
+    INSERT INTO vineyards (name, location, bbox)
+    VALUES ($1, $2, ST_SetSRID(ST_GeomFromGeoJSON($3), 4326))
     RETURNING id`
-	err := db.QueryRowContext(ctx, query, vineyard.Name, vineyard.Location).Scan(&vineyard.ID)
+	err := db.QueryRowContext(ctx, query, vineyard.Name, vineyard.Location, vineyard.BoundingBox).Scan(&vineyard.ID)
 	if err != nil {
 		return fmt.Errorf("inserting vineyard: %w", err)
 	}
@@ -192,14 +206,16 @@ func (db *DB) SaveVineyard(ctx context.Context, vineyard *model.Vineyard) error
 // GetVineyard retrieves a Vineyard by ID.
 func (db *DB) GetVineyard(ctx context.Context, id int) (*model.Vineyard, error) {
 	const query = `
-    SELECT id, name, location
-    FROM vineyards
+    SELECT id, name, location, ST_AsGeoJSON(bbox), (` + lastSuccessfulSyncColumn + `)
+    FROM vineyards v
     WHERE id = $1`
 	vineyard := &model.Vineyard{}
-	err := db.QueryRowContext(ctx, query, id).Scan(&vineyard.ID, &vineyard.Name, &vineyard.Location)
+	var lastSync sql.NullTime
+	err := db.QueryRowContext(ctx, query, id).Scan(&vineyard.ID, &vineyard.Name, &vineyard.Location, &vineyard.BoundingBox, &lastSync)
 	if err != nil {
 		return nil, fmt.Errorf("retrieving vineyard by ID: %w", err)
 	}
+	vineyard.LastSuccessfulSync = nullTimePtr(lastSync)
 	return vineyard, nil
 }
 
@@ -207,9 +223,9 @@ func (db *DB) GetVineyard(ctx context.Context, id int) (*model.Vineyard, error)
 func (db *DB) UpdateVineyard(ctx context.Context, vineyard *model.Vineyard) error {
 	const query = `
     UPDATE vineyards
-    SET name = $1, location = $2
-    WHERE id = $3`
-	_, err := db.ExecContext(ctx, query, vineyard.Name, vineyard.Location, vineyard.ID)
+    SET name = $1, location = $2, bbox = ST_SetSRID(ST_GeomFromGeoJSON($3), 4326)
+    WHERE id = $4`
+	_, err := db.ExecContext(ctx, query, vineyard.Name, vineyard.Location, vineyard.BoundingBox, vineyard.ID)
 	if err != nil {
 		return fmt.Errorf("updating vineyard: %w", err)
 	}
@@ -229,8 +245,9 @@ func (db *DB) DeleteVineyard(ctx context.Context, id int) error {
 // ListVineyards retrieves all vineyard entries from the database.
 func (db *DB) ListVineyards(ctx context.Context) ([]model.Vineyard, error) {
 	const query = `
-    SELECT id, name, location, ST_AsText(bbox) AS bbox_text
-    FROM vineyards`
+    SELECT id, name, COALESCE(location, ''), ST_AsGeoJSON(bbox), (` + lastSuccessfulSyncColumn + `)
+    FROM vineyards v
+    ORDER BY id`
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -241,13 +258,12 @@ func (db *DB) ListVineyards(ctx context.Context) ([]model.Vineyard, error) {
 	var vineyards []model.Vineyard
 	for rows.Next() {
 		var vineyard model.Vineyard
-		var bboxText string // We use this to hold the bbox polygon text
-		err := rows.Scan(&vineyard.ID, &vineyard.Name, &vineyard.Location, &bboxText)
+		var lastSync sql.NullTime
+		err := rows.Scan(&vineyard.ID, &vineyard.Name, &vineyard.Location, &vineyard.BoundingBox, &lastSync)
 		if err != nil {
 			return nil, fmt.Errorf("scanning vineyard: %w", err)
 		}
-		// Convert bbox text back to polygon type if necessary, this step may require further parsing depending on how you handle geometries
-		vineyard.BoundingBox = bboxText
+		vineyard.LastSuccessfulSync = nullTimePtr(lastSync)
 		vineyards = append(vineyards, vineyard)
 	}
 
@@ -257,12 +273,132 @@ func (db *DB) ListVineyards(ctx context.Context) ([]model.Vineyard, error) {
 	return vineyards, nil
 }
 
+// GetVineyardGeometry derives the centroid, extent and polygon of a vineyard's bounding box.
+func (db *DB) GetVineyardGeometry(ctx context.Context, id int) (*model.VineyardGeometry, error) {
+	const query = `
+    SELECT id, ST_AsGeoJSON(ST_Centroid(bbox)),
+           ST_XMin(bbox), ST_YMin(bbox), ST_XMax(bbox), ST_YMax(bbox), ST_AsGeoJSON(bbox)
+    FROM vineyards
+    WHERE id = $1 AND bbox IS NOT NULL`
+	g := &model.VineyardGeometry{}
+	err := db.QueryRowContext(ctx, query, id).Scan(&g.VineyardID, &g.Centroid,
+		&g.MinLon, &g.MinLat, &g.MaxLon, &g.MaxLat, &g.Polygon)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("vineyard %d not found or has no bounding box", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("retrieving vineyard geometry: %w", err)
+	}
+	return g, nil
+}
+
+// Block methods
+
+const blockColumns = `id, vineyard_id, name, COALESCE(varietal, ''), COALESCE(clone, ''), COALESCE(rootstock, ''),
+    planting_year, row_spacing_m, vine_spacing_m, COALESCE(row_orientation, ''), COALESCE(trellis_system, ''), ST_AsGeoJSON(geom)`
+
+// SaveBlock inserts a new block.
+func (db *DB) SaveBlock(ctx context.Context, block *model.Block) error {
+	const query = `
+    INSERT INTO blocks (vineyard_id, name, varietal, clone, rootstock, planting_year, row_spacing_m, vine_spacing_m,
+                        row_orientation, trellis_system, geom)
+    VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''),
+            ST_SetSRID(ST_GeomFromGeoJSON($11), 4326))
+    RETURNING id`
+	err := db.QueryRowContext(ctx, query, block.VineyardID, block.Name, block.Varietal, block.Clone, block.Rootstock,
+		block.PlantingYear, block.RowSpacing, block.VineSpacing, block.RowOrientation, block.TrellisSystem, block.Boundary).Scan(&block.ID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("inserting block %q: %w", block.Name, ErrDuplicate)
+	}
+	if err != nil {
+		return fmt.Errorf("inserting block: %w", err)
+	}
+	return nil
+}
+
+// GetBlock retrieves a block of a vineyard by ID.
+func (db *DB) GetBlock(ctx context.Context, vineyardID, id int) (*model.Block, error) {
+	const query = `SELECT ` + blockColumns + ` FROM blocks WHERE id = $1 AND vineyard_id = $2`
+	block, err := scanBlock(db.QueryRowContext(ctx, query, id, vineyardID))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving block by ID: %w", err)
+	}
+	return block, nil
+}
+
+// UpdateBlock updates a block's details. It returns sql.ErrNoRows when the vineyard has no such block.
+func (db *DB) UpdateBlock(ctx context.Context, block *model.Block) error {
+	const query = `
+    UPDATE blocks
+    SET name = $1, varietal = NULLIF($2, ''), clone = NULLIF($3, ''), rootstock = NULLIF($4, ''), planting_year = $5,
+        row_spacing_m = $6, vine_spacing_m = $7, row_orientation = NULLIF($8, ''), trellis_system = NULLIF($9, ''),
+        geom = ST_SetSRID(ST_GeomFromGeoJSON($10), 4326)
+    WHERE id = $11 AND vineyard_id = $12`
+	res, err := db.ExecContext(ctx, query, block.Name, block.Varietal, block.Clone, block.Rootstock, block.PlantingYear,
+		block.RowSpacing, block.VineSpacing, block.RowOrientation, block.TrellisSystem, block.Boundary, block.ID, block.VineyardID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("updating block %q: %w", block.Name, ErrDuplicate)
+	}
+	if err != nil {
+		return fmt.Errorf("updating block: %w", err)
+	}
+	return expectRow(res, "updating block")
+}
+
+// DeleteBlock removes a block. Records assigned to it keep existing without a block.
+func (db *DB) DeleteBlock(ctx context.Context, vineyardID, id int) error {
+	const query = `DELETE FROM blocks WHERE id = $1 AND vineyard_id = $2`
+	res, err := db.ExecContext(ctx, query, id, vineyardID)
+	if err != nil {
+		return fmt.Errorf("deleting block: %w", err)
+	}
+	return expectRow(res, "deleting block")
+}
+
+// ListBlocks retrieves a vineyard's blocks ordered by name.
+func (db *DB) ListBlocks(ctx context.Context, vineyardID int) ([]model.Block, error) {
+	const query = `SELECT ` + blockColumns + ` FROM blocks WHERE vineyard_id = $1 ORDER BY name, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID)
+	if err != nil {
+		return nil, fmt.Errorf("querying blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []model.Block
+	for rows.Next() {
+		block, err := scanBlock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning block: %w", err)
+		}
+		blocks = append(blocks, *block)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading block rows: %w", err)
+	}
+	return blocks, nil
+}
+
+func scanBlock(row interface{ Scan(...interface{}) error }) (*model.Block, error) {
+	block := &model.Block{}
+	var plantingYear sql.NullInt64
+	var rowSpacing, vineSpacing sql.NullFloat64
+	err := row.Scan(&block.ID, &block.VineyardID, &block.Name, &block.Varietal, &block.Clone, &block.Rootstock,
+		&plantingYear, &rowSpacing, &vineSpacing, &block.RowOrientation, &block.TrellisSystem, &block.Boundary)
+	if err != nil {
+		return nil, err
+	}
+	block.PlantingYear = nullIntPtr(plantingYear)
+	block.RowSpacing = nullFloatPtr(rowSpacing)
+	block.VineSpacing = nullFloatPtr(vineSpacing)
+	return block, nil
+}
+
 // Satellite Imagery methods
 // SaveSatelliteImagery stores new satellite imagery data.
 func (db *DB) SaveSatelliteImagery(ctx context.Context, sd *model.SatelliteData) error {
 	query := `
     INSERT INTO satellite_imagery (vineyard_id, image_url, captured_at, bbox)
-    VALUES ($1, $2, $3, $4)
+    VALUES ($1, $2, $3, ST_SetSRID(ST_GeomFromGeoJSON($4), 4326))
     RETURNING id`
 	err := db.QueryRowContext(ctx, query, sd.VineyardID, sd.ImageURL, sd.CapturedAt, sd.BoundingBox).Scan(&sd.ID)
 	if err != nil {
@@ -274,7 +410,7 @@ func (db *DB) SaveSatelliteImagery(ctx context.Context, sd *model.SatelliteData)
 // GetSatelliteImagery retrieves a single satellite imagery record by ID.
 func (db *DB) GetSatelliteImagery(ctx context.Context, id int) (*model.SatelliteData, error) {
 	query := `
-    SELECT id, vineyard_id, image_url, captured_at, bbox
+    SELECT id, vineyard_id, image_url, captured_at, ST_AsGeoJSON(bbox)
     FROM satellite_imagery
     WHERE id = $1`
 	var sd model.SatelliteData
@@ -300,7 +436,7 @@ func (db *DB) DeleteSatelliteImagery(ctx context.Context, id int) error {
 func (db *DB) UpdateSatelliteImagery(ctx context.Context, sd *model.SatelliteData) error {
 	query := `
     UPDATE satellite_imagery
-    SET image_url = $1, captured_at = $2, bbox = $3, vineyard_id = $4
+    SET image_url = $1, captured_at = $2, bbox = ST_SetSRID(ST_GeomFromGeoJSON($3), 4326), vineyard_id = $4
     WHERE id = $5`
 	_, err := db.ExecContext(ctx, query, sd.ImageURL, sd.CapturedAt, sd.BoundingBox, sd.VineyardID, sd.ID)
 	if err != nil {
@@ -312,8 +448,8 @@ func (db *DB) UpdateSatelliteImagery(ctx context.Context, sd *model.SatelliteDat
 // SaveSatelliteImageryMetadata stores metadata about satellite imagery for a vineyard.
 func (db *DB) SaveSatelliteImageryMetadata(ctx context.Context, data *model.SatelliteData, vineyardID int) error {
 	// SQL execution logic here, for example:
-	const query = `INSERT INTO satellite_imagery (vineyard_id, image_url, resolution, captured_at, bounding_box)
-                   VALUES ($1, $2, $3, $4, $5)`
+	const query = `INSERT INTO satellite_imagery (vineyard_id, image_url, resolution, captured_at, bbox)
+                   VALUES ($1, $2, $3, $4, ST_SetSRID(ST_GeomFromGeoJSON($5), 4326))`
 	_, err := db.ExecContext(ctx, query, vineyardID, data.ImageURL, data.Resolution, data.CapturedAt, data.BoundingBox)
 	if err != nil {
 		return fmt.Errorf("inserting satellite imagery metadata: %w", err)
@@ -347,7 +483,7 @@ func (db *DB) GetSatelliteImageryForVineyard(ctx context.Context, vineyardID int
 // ListSatelliteImageryByVineyard retrieves all satellite imagery for a specific vineyard.
 func (db *DB) ListSatelliteImageryByVineyard(ctx context.Context, vineyardID int) ([]model.SatelliteData, error) {
 	const query = `
-    SELECT id, vineyard_id, image_url, resolution, captured_at, bbox
+    SELECT id, vineyard_id, image_url, resolution, captured_at, ST_AsGeoJSON(bbox)
     FROM satellite_imagery
     WHERE vineyard_id = $1`
 	rows, err := db.QueryContext(ctx, query, vineyardID)
@@ -371,10 +507,39 @@ func (db *DB) ListSatelliteImageryByVineyard(ctx context.Context, vineyardID int
 	return images, nil
 }
 
+// GetRecentSatelliteImagery retrieves the latest satellite imagery for a vineyard, newest first.
+func (db *DB) GetRecentSatelliteImagery(ctx context.Context, vineyardID int, limit int) ([]model.SatelliteData, error) {
+	const query = `
+    SELECT id, vineyard_id, image_url, resolution, captured_at, ST_AsGeoJSON(bbox)
+    FROM satellite_imagery
+    WHERE vineyard_id = $1
+    ORDER BY captured_at DESC
+    LIMIT $2`
+	rows, err := db.QueryContext(ctx, query, vineyardID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying recent satellite imagery: %w", err)
+	}
+	defer rows.Close()
+
+	var images []model.SatelliteData
+	for rows.Next() {
+		var img model.SatelliteData
+		err := rows.Scan(&img.ID, &img.VineyardID, &img.ImageURL, &img.Resolution, &img.CapturedAt, &img.BoundingBox)
+		if err != nil {
+			return nil, fmt.Errorf("scanning satellite imagery: %w", err)
+		}
+		images = append(images, img)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading satellite imagery rows: %w", err)
+	}
+	return images, nil
+}
+
 // ListSatelliteImageryByDateRange retrieves satellite imagery within a specified date range for a vineyard.
 func (db *DB) ListSatelliteImageryByDateRange(ctx context.Context, vineyardID int, startDate, endDate time.Time) ([]model.SatelliteData, error) {
 	const query = `
-    SELECT id, vineyard_id, image_url, resolution, captured_at, ST_AsText(bbox) AS bbox_text
+    SELECT id, vineyard_id, image_url, resolution, captured_at, ST_AsGeoJSON(bbox)
     FROM satellite_imagery
     WHERE vineyard_id = $1 AND captured_at BETWEEN $2 AND $3`
 
@@ -387,12 +552,10 @@ func (db *DB) ListSatelliteImageryByDateRange(ctx context.Context, vineyardID in
 	var images []model.SatelliteData
 	for rows.Next() {
 		var img model.SatelliteData
-		var bboxText string
-		err := rows.Scan(&img.ID, &img.VineyardID, &img.ImageURL, &img.Resolution, &img.CapturedAt, &bboxText)
+		err := rows.Scan(&img.ID, &img.VineyardID, &img.ImageURL, &img.Resolution, &img.CapturedAt, &img.BoundingBox)
 		if err != nil {
 			return nil, fmt.Errorf("scanning satellite imagery: %w", err)
 		}
-		img.BoundingBox = bboxText // Assuming bbox is needed as text; convert if necessary to your preferred format
 		images = append(images, img)
 	}
 
@@ -405,15 +568,17 @@ func (db *DB) ListSatelliteImageryByDateRange(ctx context.Context, vineyardID in
 // Soil methods
 // SaveSoilData inserts a new SoilData record into the database.
 func (db *DB) SaveSoilData(ctx context.Context, soilData *model.SoilData) error {
-	const query = `
-    INSERT INTO soil_data (vineyard_id, data, location, sampled_at)
-    VALUES ($1, $2, ST_SetSRID(ST_MakePoint($3, $4), 4326), $5)
-    RETURNING id`
-	jsonData, err := json.Marshal(soilData)
+	query := `
+    INSERT INTO soil_data (vineyard_id, data, location, sampled_at, block_id)
+    VALUES ($1, $2, ST_SetSRID(ST_GeomFromGeoJSON($3), 4326), $4,
+            ` + blockAt("$5", "$1", "ST_SetSRID(ST_GeomFromGeoJSON($3), 4326)") + `)
+    RETURNING id, block_id`
+	jsonData, err := marshalSoilData(soilData)
 	if err != nil {
 		return fmt.Errorf("error marshaling soil data: %w", err)
 	}
-	err = db.QueryRowContext(ctx, query, soilData.VineyardID, jsonData, soilData.Location.X, soilData.Location.Y, soilData.SampledAt).Scan(&soilData.ID)
+	err = db.QueryRowContext(ctx, query, soilData.VineyardID, jsonData, soilData.Location, soilData.SampledAt,
+		soilData.BlockID).Scan(&soilData.ID, &soilData.BlockID)
 	if err != nil {
 		return fmt.Errorf("error inserting soil data: %w", err)
 	}
@@ -432,17 +597,20 @@ func (db *DB) DeleteSoilData(ctx context.Context, id int) error {
 
 // UpdateSoilData updates a given SoilData's details.
 func (db *DB) UpdateSoilData(ctx context.Context, soilData *model.SoilData) error {
-	jsonData, err := json.Marshal(soilData)
+	jsonData, err := marshalSoilData(soilData)
 	if err != nil {
 		return fmt.Errorf("error marshaling soil data: %w", err)
 	}
 
 	query := `
     UPDATE soil_data
-    SET data = $1, location = ST_SetSRID(ST_MakePoint($2, $3), 4326), sampled_at = $4
-    WHERE id = $5`
+    SET data = $1, location = ST_SetSRID(ST_GeomFromGeoJSON($2), 4326), sampled_at = $3,
+        block_id = ` + blockAt("$4", "soil_data.vineyard_id", "ST_SetSRID(ST_GeomFromGeoJSON($2), 4326)") + `
+    WHERE id = $5
+    RETURNING block_id`
 
-	_, err = db.ExecContext(ctx, query, jsonData, soilData.Location.X, soilData.Location.Y, soilData.SampledAt, soilData.ID)
+	err = db.QueryRowContext(ctx, query, jsonData, soilData.Location, soilData.SampledAt, soilData.BlockID,
+		soilData.ID).Scan(&soilData.BlockID)
 	if err != nil {
 		return fmt.Errorf("updating soil data: %w", err)
 	}
@@ -452,7 +620,7 @@ func (db *DB) UpdateSoilData(ctx context.Context, soilData *model.SoilData) erro
 // ListSoilDataForVineyard retrieves all SoilData for a specific vineyard.
 func (db *DB) ListSoilDataForVineyard(ctx context.Context, vineyardID int) ([]model.SoilData, error) {
 	const query = `
-    SELECT id, vineyard_id, data, ST_X(location) AS longitude, ST_Y(location) AS latitude, sampled_at
+    SELECT id, vineyard_id, block_id, data, ST_AsGeoJSON(location), sampled_at
     FROM soil_data
     WHERE vineyard_id = $1`
 
@@ -466,7 +634,7 @@ func (db *DB) ListSoilDataForVineyard(ctx context.Context, vineyardID int) ([]mo
 	for rows.Next() {
 		var soil model.SoilData
 		var jsonData []byte
-		if err := rows.Scan(&soil.ID, &soil.VineyardID, &jsonData, &soil.Location.X, &soil.Location.Y, &soil.SampledAt); err != nil {
+		if err := rows.Scan(&soil.ID, &soil.VineyardID, &soil.BlockID, &jsonData, &soil.Location, &soil.SampledAt); err != nil {
 			return nil, fmt.Errorf("scanning soil data: %w", err)
 		}
 		if err = json.Unmarshal(jsonData, &soil); err != nil {
@@ -483,7 +651,7 @@ func (db *DB) ListSoilDataForVineyard(ctx context.Context, vineyardID int) ([]mo
 // ListSoilDataByDateRange retrieves soil data within a specified date range for a vineyard.
 func (db *DB) ListSoilDataByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.SoilData, error) {
 	const query = `
-    SELECT id, vineyard_id, data, ST_X(location) AS longitude, ST_Y(location) AS latitude, sampled_at
+    SELECT id, vineyard_id, block_id, data, ST_AsGeoJSON(location), sampled_at
     FROM soil_data
     WHERE vineyard_id = $1 AND sampled_at BETWEEN $2 AND $3`
 
@@ -497,7 +665,7 @@ func (db *DB) ListSoilDataByDateRange(ctx context.Context, vineyardID int, start
 	for rows.Next() {
 		var soil model.SoilData
 		var jsonData []byte
-		if err := rows.Scan(&soil.ID, &soil.VineyardID, &jsonData, &soil.Location.X, &soil.Location.Y, &soil.SampledAt); err != nil {
+		if err := rows.Scan(&soil.ID, &soil.VineyardID, &soil.BlockID, &jsonData, &soil.Location, &soil.SampledAt); err != nil {
 			return nil, fmt.Errorf("scanning soil data: %w", err)
 		}
 		if err = json.Unmarshal(jsonData, &soil); err != nil {
@@ -514,13 +682,13 @@ func (db *DB) ListSoilDataByDateRange(ctx context.Context, vineyardID int, start
 // GetSoilData retrieves SoilData by ID.
 func (db *DB) GetSoilData(ctx context.Context, id int) (*model.SoilData, error) {
 	const query = `
-    SELECT id, vineyard_id, data, ST_X(location) AS longitude, ST_Y(location) AS latitude, sampled_at
+    SELECT id, vineyard_id, block_id, data, ST_AsGeoJSON(location), sampled_at
     FROM soil_data
     WHERE id = $1`
 	soilData := &model.SoilData{}
 	row := db.QueryRowContext(ctx, query, id)
 	var jsonData []byte
-	err := row.Scan(&soilData.ID, &soilData.VineyardID, &jsonData, &soilData.Location.X, &soilData.Location.Y, &soilData.SampledAt)
+	err := row.Scan(&soilData.ID, &soilData.VineyardID, &soilData.BlockID, &jsonData, &soilData.Location, &soilData.SampledAt)
 	if err != nil {
 		return nil, fmt.Errorf("retrieving soil data by ID: %w", err)
 	}
@@ -584,11 +752,13 @@ func (db *DB) GetSoilDataForVineyard(ctx context.Context, vineyardID int) ([]mod
 // Pest methods
 // SavePestData inserts a new PestData record into the database.
 func (db *DB) SavePestData(ctx context.Context, pest *model.PestData) error {
-	const query = `
-    INSERT INTO pest_data (vineyard_id, description, observation_date, location, pest_type, severity)
-    VALUES ($1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, $7)
-    RETURNING id`
-	err := db.QueryRowContext(ctx, query, pest.VineyardID, pest.Description, pest.ObservationDate, pest.Location.X, pest.Location.Y, pest.PestType, pest.Severity).Scan(&pest.ID)
+	query := `
+    INSERT INTO pest_data (vineyard_id, description, observation_date, location, pest_type, severity, block_id, kind)
+    VALUES ($1, $2, $3, ST_SetSRID(ST_GeomFromGeoJSON($4), 4326), $5, $6,
+            ` + blockAt("$7", "$1", "ST_SetSRID(ST_GeomFromGeoJSON($4), 4326)") + `, $8)
+    RETURNING id, block_id`
+	err := db.QueryRowContext(ctx, query, pest.VineyardID, pest.Description, pest.ObservationDate, pest.Location, pest.Type, pest.Severity,
+		pest.BlockID, pest.Kind).Scan(&pest.ID, &pest.BlockID)
 	if err != nil {
 		return fmt.Errorf("inserting pest data: %w", err)
 	}
@@ -598,11 +768,11 @@ func (db *DB) SavePestData(ctx context.Context, pest *model.PestData) error {
 // GetPestData retrieves a PestData by ID.
 func (db *DB) GetPestData(ctx context.Context, id int) (*model.PestData, error) {
 	const query = `
-    SELECT id, vineyard_id, description, observation_date, ST_X(location) AS longitude, ST_Y(location) AS latitude, pest_type, severity
+    SELECT id, vineyard_id, block_id, description, observation_date, ST_AsGeoJSON(location), pest_type, severity, kind
     FROM pest_data
     WHERE id = $1`
 	pest := &model.PestData{}
-	err := db.QueryRowContext(ctx, query, id).Scan(&pest.ID, &pest.VineyardID, &pest.Description, &pest.ObservationDate, &pest.Location.X, &pest.Location.Y, &pest.Type, &pest.Severity)
+	err := db.QueryRowContext(ctx, query, id).Scan(&pest.ID, &pest.VineyardID, &pest.BlockID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity, &pest.Kind)
 	if err != nil {
 		return nil, fmt.Errorf("retrieving pest data by ID: %w", err)
 	}
@@ -611,11 +781,14 @@ func (db *DB) GetPestData(ctx context.Context, id int) (*model.PestData, error)
 
 // UpdatePestData updates a given PestData's details.
 func (db *DB) UpdatePestData(ctx context.Context, pest *model.PestData) error {
-	const query = `
+	query := `
     UPDATE pest_data
-    SET description = $1, observation_date = $2, location = ST_SetSRID(ST_MakePoint($3, $4), 4326), pest_type = $5, severity = $6
-    WHERE id = $7`
-	_, err := db.ExecContext(ctx, query, pest.Description, pest.ObservationDate, pest.Location.X, pest.Location.Y, pest.Type, pest.Severity, pest.ID)
+    SET description = $1, observation_date = $2, location = ST_SetSRID(ST_GeomFromGeoJSON($3), 4326), pest_type = $4, severity = $5,
+        block_id = ` + blockAt("$6", "pest_data.vineyard_id", "ST_SetSRID(ST_GeomFromGeoJSON($3), 4326)") + `
+    WHERE id = $7
+    RETURNING block_id`
+	err := db.QueryRowContext(ctx, query, pest.Description, pest.ObservationDate, pest.Location, pest.Type, pest.Severity,
+		pest.BlockID, pest.ID).Scan(&pest.BlockID)
 	if err != nil {
 		return fmt.Errorf("updating pest data: %w", err)
 	}
@@ -635,7 +808,7 @@ func (db *DB) DeletePestData(ctx context.Context, id int) error {
 // ListPestDataByVineyard retrieves all PestData for a specific vineyard.
 func (db *DB) ListPestDataByVineyard(ctx context.Context, vineyardID int) ([]model.PestData, error) {
 	const query = `
-    SELECT id, vineyard_id, description, observation_date, ST_X(location) AS longitude, ST_Y(location) AS latitude, pest_type, severity
+    SELECT id, vineyard_id, block_id, description, observation_date, ST_AsGeoJSON(location), pest_type, severity, kind
     FROM pest_data
     WHERE vineyard_id = $1`
 	rows, err := db.QueryContext(ctx, query, vineyardID)
@@ -647,7 +820,7 @@ func (db *DB) ListPestDataByVineyard(ctx context.Context, vineyardID int) ([]mod
 	var pests []model.PestData
 	for rows.Next() {
 		var pest model.PestData
-		if err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.Description, &pest.ObservationDate, &pest.Location.X, &pest.Location.Y, &pest.Type, &pest.Severity); err != nil {
+		if err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.BlockID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity, &pest.Kind); err != nil {
 			return nil, fmt.Errorf("scanning pest data: %w", err)
 		}
 		pests = append(pests, pest)
@@ -661,7 +834,7 @@ func (db *DB) ListPestDataByVineyard(ctx context.Context, vineyardID int) ([]mod
 // ListPestDataByDateRange retrieves PestData for a specific vineyard within a date range.
 func (db *DB) ListPestDataByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.PestData, error) {
 	const query = `
-    SELECT id, vineyard_id, description, observation_date, ST_X(location) AS longitude, ST_Y(location) AS latitude, pest_type, severity
+    SELECT id, vineyard_id, block_id, description, observation_date, ST_AsGeoJSON(location), pest_type, severity, kind
     FROM pest_data
     WHERE vineyard_id = $1 AND observation_date BETWEEN $2 AND $3`
 	rows, err := db.QueryContext(ctx, query, vineyardID, start, end)
@@ -673,7 +846,7 @@ func (db *DB) ListPestDataByDateRange(ctx context.Context, vineyardID int, start
 	var pests []model.PestData
 	for rows.Next() {
 		var pest model.PestData
-		if err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.Description, &pest.ObservationDate, &pest.Location.X, &pest.Location.Y, &pest.Type, &pest.Severity); err != nil {
+		if err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.BlockID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity, &pest.Kind); err != nil {
 			return nil, fmt.Errorf("scanning pest data: %w", err)
 		}
 		pests = append(pests, pest)
@@ -684,10 +857,13 @@ func (db *DB) ListPestDataByDateRange(ctx context.Context, vineyardID int, start
 	return pests, nil
 }
 
-func (db *DB) FilterPestData(ctx context.Context, vineyardID int, pestType, severity string) ([]model.PestData, error) {
-	query := `SELECT id, vineyard_id, description, observation_date, location, pest_type, severity FROM pest_data
-              WHERE vineyard_id = $1 AND pest_type = $2 AND severity = $3`
-	rows, err := db.QueryContext(ctx, query, vineyardID, pestType, severity)
+// FilterPestData retrieves a vineyard's pest records of a type, severity and kind, newest first. An
+// empty kind matches both observations and model risks.
+func (db *DB) FilterPestData(ctx context.Context, vineyardID int, pestType, severity, kind string) ([]model.PestData, error) {
+	query := `SELECT id, vineyard_id, block_id, description, observation_date, ST_AsGeoJSON(location), pest_type, severity, kind FROM pest_data
+              WHERE vineyard_id = $1 AND pest_type = $2 AND severity = $3 AND ($4 = '' OR kind = $4)
+              ORDER BY observation_date DESC, id DESC`
+	rows, err := db.QueryContext(ctx, query, vineyardID, pestType, severity, kind)
 	if err != nil {
 		return nil, fmt.Errorf("error querying filtered pest data: %w", err)
 	}
@@ -696,7 +872,7 @@ func (db *DB) FilterPestData(ctx context.Context, vineyardID int, pestType, seve
 	var pests []model.PestData
 	for rows.Next() {
 		var pest model.PestData
-		err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity)
+		err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.BlockID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity, &pest.Kind)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning pest data: %w", err)
 		}
@@ -711,11 +887,14 @@ func (db *DB) FilterPestData(ctx context.Context, vineyardID int, pestType, seve
 // Weather methods
 // SaveWeatherData inserts a new WeatherData record into the database.
 func (db *DB) SaveWeatherData(ctx context.Context, weather *model.WeatherData) error {
-	const query = `
-    INSERT INTO weather_data (vineyard_id, temperature, humidity, observation_time, location)
-    VALUES ($1, $2, $3, $4, ST_SetSRID(ST_MakePoint($5, $6), 4326))
-    RETURNING id`
-	err := db.QueryRowContext(ctx, query, weather.VineyardID, weather.Temperature, weather.Humidity, weather.ObservationTime, weather.Location.X, weather.Location.Y).Scan(&weather.ID)
+	query := `
+    INSERT INTO weather_data (vineyard_id, temperature, humidity, observation_time, location, block_id,
+                              precipitation_mm, leaf_wetness_minutes, wind_speed_ms, dew_point)
+    VALUES ($1, $2, $3, $4, ST_SetSRID(ST_GeomFromGeoJSON($5), 4326),
+            ` + blockAt("$6", "$1", "ST_SetSRID(ST_GeomFromGeoJSON($5), 4326)") + `, $7, $8, $9, $10)
+    RETURNING id, block_id`
+	err := db.QueryRowContext(ctx, query, weather.VineyardID, weather.Temperature, weather.Humidity, weather.ObservationTime, weather.Location,
+		weather.BlockID, weather.Precipitation, weather.LeafWetness, weather.WindSpeed, weather.DewPoint).Scan(&weather.ID, &weather.BlockID)
 	if err != nil {
 		return fmt.Errorf("inserting weather data: %w", err)
 	}
@@ -725,11 +904,13 @@ func (db *DB) SaveWeatherData(ctx context.Context, weather *model.WeatherData) e
 // GetWeatherData retrieves a WeatherData by ID.
 func (db *DB) GetWeatherData(ctx context.Context, id int) (*model.WeatherData, error) {
 	const query = `
-    SELECT id, vineyard_id, temperature, humidity, observation_time, ST_X(location) AS longitude, ST_Y(location) AS latitude
+    SELECT id, vineyard_id, block_id, temperature, humidity, observation_time, ST_AsGeoJSON(location),
+           precipitation_mm, leaf_wetness_minutes, wind_speed_ms, dew_point
     FROM weather_data
     WHERE id = $1`
 	weather := &model.WeatherData{}
-	err := db.QueryRowContext(ctx, query, id).Scan(&weather.ID, &weather.VineyardID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location.X, &weather.Location.Y)
+	err := db.QueryRowContext(ctx, query, id).Scan(&weather.ID, &weather.VineyardID, &weather.BlockID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location,
+		&weather.Precipitation, &weather.LeafWetness, &weather.WindSpeed, &weather.DewPoint)
 	if err != nil {
 		return nil, fmt.Errorf("retrieving weather data by ID: %w", err)
 	}
@@ -738,11 +919,15 @@ func (db *DB) GetWeatherData(ctx context.Context, id int) (*model.WeatherData, e
 
 // UpdateWeatherData updates a given WeatherData's details.
 func (db *DB) UpdateWeatherData(ctx context.Context, weather *model.WeatherData) error {
-	const query = `
+	query := `
     UPDATE weather_data
-    SET temperature = $1, humidity = $2, observation_time = $3, location = ST_SetSRID(ST_MakePoint($4, $5), 4326)
-    WHERE id = $6`
-	_, err := db.ExecContext(ctx, query, weather.Temperature, weather.Humidity, weather.ObservationTime, weather.Location.X, weather.Location.Y, weather.ID)
+    SET temperature = $1, humidity = $2, observation_time = $3, location = ST_SetSRID(ST_GeomFromGeoJSON($4), 4326),
+        block_id = ` + blockAt("$5", "weather_data.vineyard_id", "ST_SetSRID(ST_GeomFromGeoJSON($4), 4326)") + `,
+        precipitation_mm = $7, leaf_wetness_minutes = $8, wind_speed_ms = $9, dew_point = $10
+    WHERE id = $6
+    RETURNING block_id`
+	err := db.QueryRowContext(ctx, query, weather.Temperature, weather.Humidity, weather.ObservationTime, weather.Location,
+		weather.BlockID, weather.ID, weather.Precipitation, weather.LeafWetness, weather.WindSpeed, weather.DewPoint).Scan(&weather.BlockID)
 	if err != nil {
 		return fmt.Errorf("updating weather data: %w", err)
 	}
@@ -762,7 +947,8 @@ func (db *DB) DeleteWeatherData(ctx context.Context, id int) error {
 // ListWeatherDataByVineyard retrieves all WeatherData for a specific vineyard.
 func (db *DB) ListWeatherDataByVineyard(ctx context.Context, vineyardID int) ([]model.WeatherData, error) {
 	const query = `
-    SELECT id, vineyard_id, temperature, humidity, observation_time, ST_X(location) AS longitude, ST_Y(location) AS latitude
+    SELECT id, vineyard_id, block_id, temperature, humidity, observation_time, ST_AsGeoJSON(location),
+           precipitation_mm, leaf_wetness_minutes, wind_speed_ms, dew_point
     FROM weather_data
     WHERE vineyard_id = $1`
 	rows, err := db.QueryContext(ctx, query, vineyardID)
@@ -774,7 +960,8 @@ func (db *DB) ListWeatherDataByVineyard(ctx context.Context, vineyardID int) ([]
 	var weathers []model.WeatherData
 	for rows.Next() {
 		var weather model.WeatherData
-		if err := rows.Scan(&weather.ID, &weather.VineyardID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location.X, &weather.Location.Y); err != nil {
+		if err := rows.Scan(&weather.ID, &weather.VineyardID, &weather.BlockID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location,
+			&weather.Precipitation, &weather.LeafWetness, &weather.WindSpeed, &weather.DewPoint); err != nil {
 			return nil, fmt.Errorf("scanning weather data: %w", err)
 		}
 		weathers = append(weathers, weather)
@@ -788,9 +975,11 @@ func (db *DB) ListWeatherDataByVineyard(ctx context.Context, vineyardID int) ([]
 // ListWeatherDataByDateRange retrieves WeatherData for a specific vineyard within a date range.
 func (db *DB) ListWeatherDataByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.WeatherData, error) {
 	const query = `
-    SELECT id, vineyard_id, temperature, humidity, observation_time, ST_X(location) AS longitude, ST_Y(location) AS latitude
+    SELECT id, vineyard_id, block_id, temperature, humidity, observation_time, ST_AsGeoJSON(location),
+           precipitation_mm, leaf_wetness_minutes, wind_speed_ms, dew_point
     FROM weather_data
-    WHERE vineyard_id = $1 AND observation_time BETWEEN $2 AND $3`
+    WHERE vineyard_id = $1 AND observation_time BETWEEN $2 AND $3
+    ORDER BY observation_time`
 	rows, err := db.QueryContext(ctx, query, vineyardID, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("querying weather data by date range: %w", err)
@@ -800,7 +989,8 @@ func (db *DB) ListWeatherDataByDateRange(ctx context.Context, vineyardID int, st
 	var weathers []model.WeatherData
 	for rows.Next() {
 		var weather model.WeatherData
-		if err := rows.Scan(&weather.ID, &weather.VineyardID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location.X, &weather.Location.Y); err != nil {
+		if err := rows.Scan(&weather.ID, &weather.VineyardID, &weather.BlockID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location,
+			&weather.Precipitation, &weather.LeafWetness, &weather.WindSpeed, &weather.DewPoint); err != nil {
 			return nil, fmt.Errorf("scanning weather data: %w", err)
 		}
 		weathers = append(weathers, weather)
@@ -810,3 +1000,2192 @@ func (db *DB) ListWeatherDataByDateRange(ctx context.Context, vineyardID int, st
 	}
 	return weathers, nil
 }
+
+// ListDailyTemperatures aggregates a vineyard's temperature observations into daily minimums and
+// maximums from one day to another, both included. Days are calendar days in loc; days without
+// observations are absent.
+func (db *DB) ListDailyTemperatures(ctx context.Context, vineyardID int, from, to time.Time, loc *time.Location) ([]model.DailyTemperature, error) {
+	const query = `
+    SELECT (observation_time AT TIME ZONE $4)::date AS day, MIN(temperature), MAX(temperature), COUNT(*)
+    FROM weather_data
+    WHERE vineyard_id = $1 AND observation_time >= $2 AND observation_time < $3
+    GROUP BY day
+    ORDER BY day`
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+	rows, err := db.QueryContext(ctx, query, vineyardID, start, end, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("aggregating daily temperatures: %w", err)
+	}
+	defer rows.Close()
+
+	var days []model.DailyTemperature
+	for rows.Next() {
+		var day model.DailyTemperature
+		if err := rows.Scan(&day.Date, &day.Min, &day.Max, &day.Observations); err != nil {
+			return nil, fmt.Errorf("scanning daily temperature: %w", err)
+		}
+		day.Date = time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, loc)
+		days = append(days, day)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading daily temperature rows: %w", err)
+	}
+	return days, nil
+}
+
+// Spatial methods
+// Search geometries are passed as GeoJSON and matched with the GiST indexes from migration 0005.
+
+// ListVineyardsIntersecting retrieves the vineyards whose bounding box intersects area.
+func (db *DB) ListVineyardsIntersecting(ctx context.Context, area geo.Geometry) ([]model.Vineyard, error) {
+	const query = `
+    SELECT id, name, COALESCE(location, ''), ST_AsGeoJSON(bbox), (` + lastSuccessfulSyncColumn + `)
+    FROM vineyards v
+    WHERE ST_Intersects(bbox, ST_SetSRID(ST_GeomFromGeoJSON($1), 4326))
+    ORDER BY id`
+	rows, err := db.QueryContext(ctx, query, area)
+	if err != nil {
+		return nil, fmt.Errorf("querying vineyards intersecting area: %w", err)
+	}
+	defer rows.Close()
+
+	var vineyards []model.Vineyard
+	for rows.Next() {
+		var vineyard model.Vineyard
+		var lastSync sql.NullTime
+		err := rows.Scan(&vineyard.ID, &vineyard.Name, &vineyard.Location, &vineyard.BoundingBox, &lastSync)
+		if err != nil {
+			return nil, fmt.Errorf("scanning vineyard: %w", err)
+		}
+		vineyard.LastSuccessfulSync = nullTimePtr(lastSync)
+		vineyards = append(vineyards, vineyard)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading vineyard rows: %w", err)
+	}
+	return vineyards, nil
+}
+
+// ListVineyardsNear retrieves the vineyards whose bounding box is within radiusKm of point, nearest first.
+// Distances are geodesic.
+func (db *DB) ListVineyardsNear(ctx context.Context, point geo.Point, radiusKm float64) ([]model.NearbyVineyard, error) {
+	const query = `
+    SELECT id, name, COALESCE(location, ''), ST_AsGeoJSON(bbox), (` + lastSuccessfulSyncColumn + `),
+           ST_Distance(bbox::geography, ST_SetSRID(ST_GeomFromGeoJSON($1), 4326)::geography) / 1000 AS distance_km
+    FROM vineyards v
+    WHERE ST_DWithin(bbox::geography, ST_SetSRID(ST_GeomFromGeoJSON($1), 4326)::geography, $2 * 1000)
+    ORDER BY distance_km, id`
+	rows, err := db.QueryContext(ctx, query, point, radiusKm)
+	if err != nil {
+		return nil, fmt.Errorf("querying vineyards near point: %w", err)
+	}
+	defer rows.Close()
+
+	var vineyards []model.NearbyVineyard
+	for rows.Next() {
+		var nearby model.NearbyVineyard
+		var lastSync sql.NullTime
+		err := rows.Scan(&nearby.ID, &nearby.Name, &nearby.Location, &nearby.BoundingBox, &lastSync, &nearby.DistanceKm)
+		if err != nil {
+			return nil, fmt.Errorf("scanning vineyard: %w", err)
+		}
+		nearby.LastSuccessfulSync = nullTimePtr(lastSync)
+		vineyards = append(vineyards, nearby)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading vineyard rows: %w", err)
+	}
+	return vineyards, nil
+}
+
+// ListPestDataWithin retrieves pest observations located inside area, or on its boundary, newest first.
+// A vineyardID of 0 searches every vineyard.
+func (db *DB) ListPestDataWithin(ctx context.Context, area geo.Geometry, vineyardID int) ([]model.PestData, error) {
+	const query = `
+    SELECT id, vineyard_id, block_id, description, observation_date, ST_AsGeoJSON(location), pest_type, severity, kind
+    FROM pest_data
+    WHERE ST_Intersects(location, ST_SetSRID(ST_GeomFromGeoJSON($1), 4326))
+      AND ($2 = 0 OR vineyard_id = $2)
+    ORDER BY observation_date DESC, id DESC`
+	rows, err := db.QueryContext(ctx, query, area, vineyardID)
+	if err != nil {
+		return nil, fmt.Errorf("querying pest data within area: %w", err)
+	}
+	defer rows.Close()
+
+	var pests []model.PestData
+	for rows.Next() {
+		var pest model.PestData
+		if err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.BlockID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity, &pest.Kind); err != nil {
+			return nil, fmt.Errorf("scanning pest data: %w", err)
+		}
+		pests = append(pests, pest)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading pest data rows: %w", err)
+	}
+	return pests, nil
+}
+
+// ListSatelliteCoverage retrieves the satellite scenes, stored for any vineyard, that cover at least
+// minPercent of the vineyard's bounding box, best coverage first. Coverage is the planar area ratio,
+// which at vineyard scale matches the geodesic one and is exactly 100 for a fully covered vineyard.
+func (db *DB) ListSatelliteCoverage(ctx context.Context, vineyardID int, minPercent float64) ([]model.SatelliteCoverage, error) {
+	const query = `
+    SELECT id, vineyard_id, image_url, resolution, captured_at, bbox_json, coverage
+    FROM (
+        SELECT s.id, s.vineyard_id, s.image_url, s.resolution, s.captured_at, ST_AsGeoJSON(s.bbox) AS bbox_json,
+               100 * ST_Area(ST_Intersection(s.bbox, v.bbox)) / ST_Area(v.bbox) AS coverage
+        FROM satellite_imagery s
+        JOIN vineyards v ON v.id = $1
+        WHERE s.bbox && v.bbox AND ST_Area(v.bbox) > 0
+    ) covered
+    WHERE coverage >= $2
+    ORDER BY coverage DESC, captured_at DESC`
+	rows, err := db.QueryContext(ctx, query, vineyardID, minPercent)
+	if err != nil {
+		return nil, fmt.Errorf("querying satellite coverage: %w", err)
+	}
+	defer rows.Close()
+
+	var scenes []model.SatelliteCoverage
+	for rows.Next() {
+		var scene model.SatelliteCoverage
+		err := rows.Scan(&scene.ID, &scene.VineyardID, &scene.ImageURL, &scene.Resolution, &scene.CapturedAt,
+			&scene.BoundingBox, &scene.CoveragePercent)
+		if err != nil {
+			return nil, fmt.Errorf("scanning satellite coverage: %w", err)
+		}
+		scenes = append(scenes, scene)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading satellite coverage rows: %w", err)
+	}
+	return scenes, nil
+}
+
+// Export methods
+// The Each methods stream rows to fn instead of collecting them, newest first, so exports of long
+// histories do not hold every record in memory. Iteration stops at the first error fn returns.
+
+// EachVineyard calls fn for every vineyard.
+func (db *DB) EachVineyard(ctx context.Context, fn func(*model.Vineyard) error) error {
+	const query = `
+    SELECT id, name, COALESCE(location, ''), ST_AsGeoJSON(bbox), (` + lastSuccessfulSyncColumn + `)
+    FROM vineyards v
+    ORDER BY id`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying vineyards: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var vineyard model.Vineyard
+		var lastSync sql.NullTime
+		if err := rows.Scan(&vineyard.ID, &vineyard.Name, &vineyard.Location, &vineyard.BoundingBox, &lastSync); err != nil {
+			return fmt.Errorf("scanning vineyard: %w", err)
+		}
+		vineyard.LastSuccessfulSync = nullTimePtr(lastSync)
+		if err := fn(&vineyard); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachPestData calls fn for a vineyard's pest observations matching the filter.
+func (db *DB) EachPestData(ctx context.Context, vineyardID int, filter model.RecordFilter, fn func(*model.PestData) error) error {
+	const query = `
+    SELECT id, vineyard_id, block_id, description, observation_date, ST_AsGeoJSON(location), pest_type, severity, kind
+    FROM pest_data
+    WHERE vineyard_id = $1
+      AND ($2::timestamptz IS NULL OR observation_date BETWEEN $2 AND $3)
+      AND ($4 = '' OR pest_type = $4)
+      AND ($5 = '' OR severity = $5)
+      AND ($6 = '' OR kind = $6)
+    ORDER BY observation_date DESC, id DESC
+    LIMIT $7`
+	rows, err := db.QueryContext(ctx, query, vineyardID, nullTime(filter.Start), nullTime(filter.End),
+		filter.PestType, filter.Severity, filter.Kind, nullLimit(filter.Limit))
+	if err != nil {
+		return fmt.Errorf("querying pest data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pest model.PestData
+		if err := rows.Scan(&pest.ID, &pest.VineyardID, &pest.BlockID, &pest.Description, &pest.ObservationDate, &pest.Location, &pest.Type, &pest.Severity, &pest.Kind); err != nil {
+			return fmt.Errorf("scanning pest data: %w", err)
+		}
+		if err := fn(&pest); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachSoilData calls fn for a vineyard's soil samples matching the filter.
+func (db *DB) EachSoilData(ctx context.Context, vineyardID int, filter model.RecordFilter, fn func(*model.SoilData) error) error {
+	const query = `
+    SELECT id, vineyard_id, block_id, data, ST_AsGeoJSON(location), sampled_at
+    FROM soil_data
+    WHERE vineyard_id = $1
+      AND ($2::timestamptz IS NULL OR sampled_at BETWEEN $2 AND $3)
+    ORDER BY sampled_at DESC, id DESC
+    LIMIT $4`
+	rows, err := db.QueryContext(ctx, query, vineyardID, nullTime(filter.Start), nullTime(filter.End), nullLimit(filter.Limit))
+	if err != nil {
+		return fmt.Errorf("querying soil data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var soil model.SoilData
+		var jsonData []byte
+		if err := rows.Scan(&soil.ID, &soil.VineyardID, &soil.BlockID, &jsonData, &soil.Location, &soil.SampledAt); err != nil {
+			return fmt.Errorf("scanning soil data: %w", err)
+		}
+		if err := json.Unmarshal(jsonData, &soil); err != nil {
+			return fmt.Errorf("unmarshaling soil data: %w", err)
+		}
+		if err := fn(&soil); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachWeatherData calls fn for a vineyard's weather observations matching the filter.
+func (db *DB) EachWeatherData(ctx context.Context, vineyardID int, filter model.RecordFilter, fn func(*model.WeatherData) error) error {
+	const query = `
+    SELECT id, vineyard_id, block_id, temperature, humidity, observation_time, ST_AsGeoJSON(location),
+           precipitation_mm, leaf_wetness_minutes, wind_speed_ms, dew_point
+    FROM weather_data
+    WHERE vineyard_id = $1
+      AND ($2::timestamptz IS NULL OR observation_time BETWEEN $2 AND $3)
+    ORDER BY observation_time DESC, id DESC
+    LIMIT $4`
+	rows, err := db.QueryContext(ctx, query, vineyardID, nullTime(filter.Start), nullTime(filter.End), nullLimit(filter.Limit))
+	if err != nil {
+		return fmt.Errorf("querying weather data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var weather model.WeatherData
+		if err := rows.Scan(&weather.ID, &weather.VineyardID, &weather.BlockID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location,
+			&weather.Precipitation, &weather.LeafWetness, &weather.WindSpeed, &weather.DewPoint); err != nil {
+			return fmt.Errorf("scanning weather data: %w", err)
+		}
+		if err := fn(&weather); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachImage calls fn for a vineyard's images matching the filter.
+func (db *DB) EachImage(ctx context.Context, vineyardID int, filter model.RecordFilter, fn func(*model.Image) error) error {
+	const query = `
+    SELECT id, vineyard_id, block_id, image_url, description, captured_at, ST_AsGeoJSON(bbox)
+    FROM images
+    WHERE vineyard_id = $1
+      AND ($2::timestamptz IS NULL OR captured_at BETWEEN $2 AND $3)
+    ORDER BY captured_at DESC, id DESC
+    LIMIT $4`
+	rows, err := db.QueryContext(ctx, query, vineyardID, nullTime(filter.Start), nullTime(filter.End), nullLimit(filter.Limit))
+	if err != nil {
+		return fmt.Errorf("querying images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var img model.Image
+		if err := rows.Scan(&img.ID, &img.VineyardID, &img.BlockID, &img.URL, &img.Description, &img.CapturedAt, &img.BoundingBox); err != nil {
+			return fmt.Errorf("scanning image: %w", err)
+		}
+		if err := fn(&img); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachSatelliteImagery calls fn for a vineyard's satellite imagery matching the filter.
+func (db *DB) EachSatelliteImagery(ctx context.Context, vineyardID int, filter model.RecordFilter, fn func(*model.SatelliteData) error) error {
+	const query = `
+    SELECT id, vineyard_id, image_url, resolution, captured_at, ST_AsGeoJSON(bbox)
+    FROM satellite_imagery
+    WHERE vineyard_id = $1
+      AND ($2::timestamptz IS NULL OR captured_at BETWEEN $2 AND $3)
+    ORDER BY captured_at DESC, id DESC
+    LIMIT $4`
+	rows, err := db.QueryContext(ctx, query, vineyardID, nullTime(filter.Start), nullTime(filter.End), nullLimit(filter.Limit))
+	if err != nil {
+		return fmt.Errorf("querying satellite imagery: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var img model.SatelliteData
+		if err := rows.Scan(&img.ID, &img.VineyardID, &img.ImageURL, &img.Resolution, &img.CapturedAt, &img.BoundingBox); err != nil {
+			return fmt.Errorf("scanning satellite imagery: %w", err)
+		}
+		if err := fn(&img); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Ingestion run methods
+// CreateIngestionRun inserts a run when a fetch starts.
+func (db *DB) CreateIngestionRun(ctx context.Context, run *model.IngestionRun) error {
+	const query = `
+    INSERT INTO ingestion_runs (source_key, vineyard_id, triggered_by, status, retry_of, window_start, window_end, started_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    RETURNING id`
+	err := db.QueryRowContext(ctx, query, run.SourceKey, run.VineyardID, run.Trigger, run.Status, run.RetryOf,
+		run.WindowStart, run.WindowEnd, run.StartedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("inserting ingestion run: %w", err)
+	}
+	return nil
+}
+
+// FinishIngestionRun records the outcome of a run.
+func (db *DB) FinishIngestionRun(ctx context.Context, run *model.IngestionRun) error {
+	const query = `
+    UPDATE ingestion_runs
+    SET status = $1, finished_at = $2, records = $3, http_status = $4, error = NULLIF($5, '')
+    WHERE id = $6`
+	_, err := db.ExecContext(ctx, query, run.Status, run.FinishedAt, run.Records, run.HTTPStatus, run.Error, run.ID)
+	if err != nil {
+		return fmt.Errorf("updating ingestion run: %w", err)
+	}
+	return nil
+}
+
+const ingestionRunColumns = `id, source_key, vineyard_id, triggered_by, status, retry_of, window_start, window_end,
+    started_at, finished_at, records, http_status, COALESCE(error, '')`
+
+// GetIngestionRun retrieves an ingestion run by ID.
+func (db *DB) GetIngestionRun(ctx context.Context, id int) (*model.IngestionRun, error) {
+	const query = `SELECT ` + ingestionRunColumns + ` FROM ingestion_runs WHERE id = $1`
+	run, err := scanIngestionRun(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving ingestion run by ID: %w", err)
+	}
+	return run, nil
+}
+
+// ListIngestionRuns retrieves runs matching the filter, newest first.
+func (db *DB) ListIngestionRuns(ctx context.Context, filter model.IngestionRunFilter) ([]model.IngestionRun, error) {
+	const query = `
+    SELECT ` + ingestionRunColumns + `
+    FROM ingestion_runs
+    WHERE ($1 = '' OR source_key = $1)
+      AND ($2 = 0 OR vineyard_id = $2)
+      AND ($3 = '' OR status = $3)
+      AND ($4 = '' OR triggered_by = $4)
+      AND ($5::timestamptz IS NULL OR started_at >= $5)
+      AND ($6::timestamptz IS NULL OR started_at < $6)
+    ORDER BY started_at DESC, id DESC
+    LIMIT $7`
+	rows, err := db.QueryContext(ctx, query, filter.SourceKey, filter.VineyardID, filter.Status, filter.Trigger,
+		nullTime(filter.Since), nullTime(filter.Until), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying ingestion runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []model.IngestionRun
+	for rows.Next() {
+		run, err := scanIngestionRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning ingestion run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading ingestion run rows: %w", err)
+	}
+	return runs, nil
+}
+
+// scanIngestionRun reads the columns listed in ingestionRunColumns from a row or rows.
+func scanIngestionRun(row interface{ Scan(...interface{}) error }) (*model.IngestionRun, error) {
+	run := &model.IngestionRun{}
+	var vineyardID, retryOf, httpStatus sql.NullInt64
+	var windowStart, windowEnd, finishedAt sql.NullTime
+	err := row.Scan(&run.ID, &run.SourceKey, &vineyardID, &run.Trigger, &run.Status, &retryOf, &windowStart, &windowEnd,
+		&run.StartedAt, &finishedAt, &run.Records, &httpStatus, &run.Error)
+	if err != nil {
+		return nil, err
+	}
+	run.VineyardID = nullIntPtr(vineyardID)
+	run.RetryOf = nullIntPtr(retryOf)
+	run.HTTPStatus = nullIntPtr(httpStatus)
+	run.WindowStart = nullTimePtr(windowStart)
+	run.WindowEnd = nullTimePtr(windowEnd)
+	run.FinishedAt = nullTimePtr(finishedAt)
+	return run, nil
+}
+
+// Backfill methods
+// CreateBackfillJob inserts a new backfill job.
+func (db *DB) CreateBackfillJob(ctx context.Context, job *model.BackfillJob) error {
+	const query = `
+    INSERT INTO backfill_jobs (source_key, vineyard_id, range_from, range_to, chunk_days, status, checkpoint, windows_total)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    RETURNING id, created_at, updated_at`
+	err := db.QueryRowContext(ctx, query, job.SourceKey, job.VineyardID, job.From, job.To, job.ChunkDays, job.Status,
+		job.Checkpoint, job.WindowsTotal).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting backfill job: %w", err)
+	}
+	return nil
+}
+
+// UpdateBackfillJob saves a job's status, checkpoint and progress counters.
+func (db *DB) UpdateBackfillJob(ctx context.Context, job *model.BackfillJob) error {
+	const query = `
+    UPDATE backfill_jobs
+    SET status = $1, checkpoint = $2, windows_total = $3, windows_fetched = $4, windows_skipped = $5, records = $6,
+        error = NULLIF($7, ''), updated_at = CURRENT_TIMESTAMP
+    WHERE id = $8
+    RETURNING updated_at`
+	err := db.QueryRowContext(ctx, query, job.Status, job.Checkpoint, job.WindowsTotal, job.WindowsFetched, job.WindowsSkipped,
+		job.Records, job.Error, job.ID).Scan(&job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("updating backfill job: %w", err)
+	}
+	return nil
+}
+
+const backfillJobColumns = `id, source_key, vineyard_id, range_from, range_to, chunk_days, status, checkpoint,
+    windows_total, windows_fetched, windows_skipped, records, COALESCE(error, ''), created_at, updated_at`
+
+// GetBackfillJob retrieves a backfill job by ID.
+func (db *DB) GetBackfillJob(ctx context.Context, id int) (*model.BackfillJob, error) {
+	const query = `SELECT ` + backfillJobColumns + ` FROM backfill_jobs WHERE id = $1`
+	job, err := scanBackfillJob(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving backfill job by ID: %w", err)
+	}
+	return job, nil
+}
+
+// FindResumableBackfillJob returns the latest unfinished job for the same source, vineyard and range, or nil.
+func (db *DB) FindResumableBackfillJob(ctx context.Context, sourceKey string, vineyardID int, from, to time.Time) (*model.BackfillJob, error) {
+	const query = `
+    SELECT ` + backfillJobColumns + `
+    FROM backfill_jobs
+    WHERE source_key = $1 AND vineyard_id = $2 AND range_from = $3 AND range_to = $4 AND status <> $5
+    ORDER BY id DESC
+    LIMIT 1`
+	job, err := scanBackfillJob(db.QueryRowContext(ctx, query, sourceKey, vineyardID, from, to, model.BackfillStatusCompleted))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding resumable backfill job: %w", err)
+	}
+	return job, nil
+}
+
+// ListBackfillJobs retrieves the most recent backfill jobs, newest first.
+func (db *DB) ListBackfillJobs(ctx context.Context, limit int) ([]model.BackfillJob, error) {
+	const query = `SELECT ` + backfillJobColumns + ` FROM backfill_jobs ORDER BY id DESC LIMIT $1`
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying backfill jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []model.BackfillJob
+	for rows.Next() {
+		job, err := scanBackfillJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning backfill job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading backfill job rows: %w", err)
+	}
+	return jobs, nil
+}
+
+func scanBackfillJob(row interface{ Scan(...interface{}) error }) (*model.BackfillJob, error) {
+	job := &model.BackfillJob{}
+	err := row.Scan(&job.ID, &job.SourceKey, &job.VineyardID, &job.From, &job.To, &job.ChunkDays, &job.Status, &job.Checkpoint,
+		&job.WindowsTotal, &job.WindowsFetched, &job.WindowsSkipped, &job.Records, &job.Error, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Disease risk methods
+
+const diseaseRiskColumns = `id, vineyard_id, model, day, risk_index, risk_level, details, pest_data_id, computed_at`
+
+// SaveDiseaseRisk inserts or replaces a model's result for a vineyard and day. A risk record already
+// linked to the day is kept when the result has none.
+func (db *DB) SaveDiseaseRisk(ctx context.Context, risk *model.DiseaseRisk) error {
+	const query = `
+    INSERT INTO disease_risk (vineyard_id, model, day, risk_index, risk_level, details, pest_data_id)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    ON CONFLICT (vineyard_id, model, day) DO UPDATE
+    SET risk_index = EXCLUDED.risk_index, risk_level = EXCLUDED.risk_level, details = EXCLUDED.details,
+        pest_data_id = COALESCE(EXCLUDED.pest_data_id, disease_risk.pest_data_id), computed_at = CURRENT_TIMESTAMP
+    RETURNING id, pest_data_id, computed_at`
+	details, err := json.Marshal(risk.Details)
+	if err != nil {
+		return fmt.Errorf("encoding disease risk details: %w", err)
+	}
+	var pestDataID sql.NullInt64
+	err = db.QueryRowContext(ctx, query, risk.VineyardID, risk.Model, risk.Day.Format("2006-01-02"), risk.Index, risk.Level,
+		details, risk.PestDataID).Scan(&risk.ID, &pestDataID, &risk.ComputedAt)
+	if err != nil {
+		return fmt.Errorf("saving disease risk: %w", err)
+	}
+	risk.PestDataID = nullIntPtr(pestDataID)
+	return nil
+}
+
+// ListDiseaseRisk retrieves a model's daily results for a vineyard from one day to another, both
+// included, in day order.
+func (db *DB) ListDiseaseRisk(ctx context.Context, vineyardID int, modelName string, from, to time.Time) ([]model.DiseaseRisk, error) {
+	const query = `
+    SELECT ` + diseaseRiskColumns + `
+    FROM disease_risk
+    WHERE vineyard_id = $1 AND model = $2 AND day BETWEEN $3 AND $4
+    ORDER BY day`
+	rows, err := db.QueryContext(ctx, query, vineyardID, modelName, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("querying disease risk: %w", err)
+	}
+	defer rows.Close()
+
+	var risks []model.DiseaseRisk
+	for rows.Next() {
+		risk, err := scanDiseaseRisk(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning disease risk: %w", err)
+		}
+		risks = append(risks, *risk)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading disease risk rows: %w", err)
+	}
+	return risks, nil
+}
+
+// GetLatestDiseaseRisk retrieves a model's last result for a vineyard from a day up to, but not
+// including, another.
+func (db *DB) GetLatestDiseaseRisk(ctx context.Context, vineyardID int, modelName string, from, before time.Time) (*model.DiseaseRisk, error) {
+	const query = `
+    SELECT ` + diseaseRiskColumns + `
+    FROM disease_risk
+    WHERE vineyard_id = $1 AND model = $2 AND day >= $3 AND day < $4
+    ORDER BY day DESC
+    LIMIT 1`
+	risk, err := scanDiseaseRisk(db.QueryRowContext(ctx, query, vineyardID, modelName,
+		from.Format("2006-01-02"), before.Format("2006-01-02")))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving latest disease risk: %w", err)
+	}
+	return risk, nil
+}
+
+func scanDiseaseRisk(row interface{ Scan(...interface{}) error }) (*model.DiseaseRisk, error) {
+	risk := &model.DiseaseRisk{}
+	var details []byte
+	var pestDataID sql.NullInt64
+	err := row.Scan(&risk.ID, &risk.VineyardID, &risk.Model, &risk.Day, &risk.Index, &risk.Level, &details, &pestDataID,
+		&risk.ComputedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(details, &risk.Details); err != nil {
+		return nil, fmt.Errorf("decoding disease risk details: %w", err)
+	}
+	risk.PestDataID = nullIntPtr(pestDataID)
+	return risk, nil
+}
+
+// Alert methods
+
+const alertRuleColumns = `id, vineyard_id, name, metric, operator, threshold, COALESCE(active_from, ''), COALESCE(active_to, ''),
+    min_el_stage, max_el_stage, cooldown_minutes, severity, recipients, disabled, created_at`
+
+// SaveAlertRule inserts a new alert rule.
+func (db *DB) SaveAlertRule(ctx context.Context, rule *model.AlertRule) error {
+	const query = `
+    INSERT INTO alert_rules (vineyard_id, name, metric, operator, threshold, active_from, active_to, min_el_stage,
+                             max_el_stage, cooldown_minutes, severity, recipients, disabled)
+    VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12, $13)
+    RETURNING id, created_at`
+	err := db.QueryRowContext(ctx, query, rule.VineyardID, rule.Name, rule.Metric, rule.Operator, rule.Threshold,
+		rule.ActiveFrom, rule.ActiveTo, rule.MinELStage, rule.MaxELStage, rule.CooldownMinutes, rule.Severity,
+		pq.Array(rule.Recipients), rule.Disabled).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting alert rule: %w", err)
+	}
+	return nil
+}
+
+// GetAlertRule retrieves an alert rule of a vineyard by ID.
+func (db *DB) GetAlertRule(ctx context.Context, vineyardID, id int) (*model.AlertRule, error) {
+	const query = `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE id = $1 AND vineyard_id = $2`
+	rule, err := scanAlertRule(db.QueryRowContext(ctx, query, id, vineyardID))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving alert rule by ID: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateAlertRule replaces an alert rule's settings. It returns sql.ErrNoRows when the vineyard has no
+// such rule. Alerts already raised keep the settings they were raised with.
+func (db *DB) UpdateAlertRule(ctx context.Context, rule *model.AlertRule) error {
+	const query = `
+    UPDATE alert_rules
+    SET name = $1, metric = $2, operator = $3, threshold = $4, active_from = NULLIF($5, ''), active_to = NULLIF($6, ''),
+        min_el_stage = $7, max_el_stage = $8, cooldown_minutes = $9, severity = $10, recipients = $11, disabled = $12
+    WHERE id = $13 AND vineyard_id = $14
+    RETURNING created_at`
+	err := db.QueryRowContext(ctx, query, rule.Name, rule.Metric, rule.Operator, rule.Threshold, rule.ActiveFrom,
+		rule.ActiveTo, rule.MinELStage, rule.MaxELStage, rule.CooldownMinutes, rule.Severity, pq.Array(rule.Recipients),
+		rule.Disabled, rule.ID, rule.VineyardID).Scan(&rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("updating alert rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlertRule removes an alert rule. Its alerts are kept without a rule.
+func (db *DB) DeleteAlertRule(ctx context.Context, vineyardID, id int) error {
+	const query = `DELETE FROM alert_rules WHERE id = $1 AND vineyard_id = $2`
+	res, err := db.ExecContext(ctx, query, id, vineyardID)
+	if err != nil {
+		return fmt.Errorf("deleting alert rule: %w", err)
+	}
+	return expectRow(res, "deleting alert rule")
+}
+
+// ListAlertRules retrieves a vineyard's alert rules by ID, leaving out disabled ones if enabledOnly is set.
+func (db *DB) ListAlertRules(ctx context.Context, vineyardID int, enabledOnly bool) ([]model.AlertRule, error) {
+	const query = `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE vineyard_id = $1 AND NOT (disabled AND $2) ORDER BY id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, enabledOnly)
+	if err != nil {
+		return nil, fmt.Errorf("querying alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []model.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning alert rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading alert rule rows: %w", err)
+	}
+	return rules, nil
+}
+
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (*model.AlertRule, error) {
+	rule := &model.AlertRule{}
+	var minStage, maxStage sql.NullInt64
+	err := row.Scan(&rule.ID, &rule.VineyardID, &rule.Name, &rule.Metric, &rule.Operator, &rule.Threshold, &rule.ActiveFrom,
+		&rule.ActiveTo, &minStage, &maxStage, &rule.CooldownMinutes, &rule.Severity, pq.Array(&rule.Recipients),
+		&rule.Disabled, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rule.MinELStage, rule.MaxELStage = nullIntPtr(minStage), nullIntPtr(maxStage)
+	return rule, nil
+}
+
+const alertColumns = `id, rule_id, vineyard_id, rule_name, metric, operator, threshold, severity, message, peak_value,
+    trigger_count, first_observed_at, last_observed_at, weather_data_id, status, acknowledged_at,
+    COALESCE(acknowledged_by, ''), resolved_at, notified_at, created_at`
+
+// RecordAlertTrigger counts a reading that fired a rule. The reading joins the rule's unresolved alert
+// whose observations it falls within the cooldown of; otherwise a new alert is inserted with message.
+// The rule's row is locked meanwhile, so concurrent readings cannot raise duplicate alerts. It reports
+// whether the alert is new.
+func (db *DB) RecordAlertTrigger(ctx context.Context, rule *model.AlertRule, weather *model.WeatherData, value float64,
+	message string) (*model.Alert, bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("starting alert transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM alert_rules WHERE id = $1 FOR UPDATE`, rule.ID); err != nil {
+		return nil, false, fmt.Errorf("locking alert rule: %w", err)
+	}
+	const update = `
+    UPDATE alerts
+    SET trigger_count = trigger_count + 1,
+        first_observed_at = LEAST(first_observed_at, $2), last_observed_at = GREATEST(last_observed_at, $2),
+        peak_value = CASE WHEN operator IN ('lt', 'lte') THEN LEAST(peak_value, $3) ELSE GREATEST(peak_value, $3) END
+    WHERE id = (
+        SELECT id FROM alerts
+        WHERE rule_id = $1 AND status <> 'resolved'
+          AND $2 BETWEEN first_observed_at - make_interval(mins => $4::integer)
+                     AND last_observed_at + make_interval(mins => $4::integer)
+        ORDER BY last_observed_at DESC
+        LIMIT 1)
+    RETURNING ` + alertColumns
+	alert, err := scanAlert(tx.QueryRowContext(ctx, update, rule.ID, weather.ObservationTime, value, rule.CooldownMinutes))
+	created := false
+	if errors.Is(err, sql.ErrNoRows) {
+		const insert = `
+        INSERT INTO alerts (rule_id, vineyard_id, rule_name, metric, operator, threshold, severity, message, peak_value,
+                            first_observed_at, last_observed_at, weather_data_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10, NULLIF($11, 0))
+        RETURNING ` + alertColumns
+		alert, err = scanAlert(tx.QueryRowContext(ctx, insert, rule.ID, rule.VineyardID, rule.Name, rule.Metric, rule.Operator,
+			rule.Threshold, rule.Severity, message, value, weather.ObservationTime, weather.ID))
+		created = true
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("recording alert: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("committing alert: %w", err)
+	}
+	return alert, created, nil
+}
+
+// GetAlert retrieves an alert by ID.
+func (db *DB) GetAlert(ctx context.Context, id int) (*model.Alert, error) {
+	const query = `SELECT ` + alertColumns + ` FROM alerts WHERE id = $1`
+	alert, err := scanAlert(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving alert by ID: %w", err)
+	}
+	return alert, nil
+}
+
+// ListAlerts retrieves a vineyard's alerts newest first, optionally of one status. A non-positive limit
+// returns all of them.
+func (db *DB) ListAlerts(ctx context.Context, vineyardID int, status string, limit int) ([]model.Alert, error) {
+	const query = `
+    SELECT ` + alertColumns + `
+    FROM alerts
+    WHERE vineyard_id = $1 AND ($2 = '' OR status = $2)
+    ORDER BY created_at DESC, id DESC
+    LIMIT $3`
+	rows, err := db.QueryContext(ctx, query, vineyardID, status, nullLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("querying alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []model.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning alert: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading alert rows: %w", err)
+	}
+	return alerts, nil
+}
+
+// ListAlertsRaised retrieves a vineyard's alerts first observed in [from, to), oldest first.
+func (db *DB) ListAlertsRaised(ctx context.Context, vineyardID int, from, to time.Time) ([]model.Alert, error) {
+	const query = `
+    SELECT ` + alertColumns + `
+    FROM alerts
+    WHERE vineyard_id = $1 AND first_observed_at >= $2 AND first_observed_at < $3
+    ORDER BY first_observed_at, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []model.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning alert: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading alert rows: %w", err)
+	}
+	return alerts, nil
+}
+
+// AcknowledgeAlert marks an unresolved alert acknowledged, keeping the first acknowledgement's time.
+// It returns sql.ErrNoRows when there is no such unresolved alert.
+func (db *DB) AcknowledgeAlert(ctx context.Context, id int, by string) (*model.Alert, error) {
+	const query = `
+    UPDATE alerts
+    SET status = 'acknowledged', acknowledged_at = COALESCE(acknowledged_at, CURRENT_TIMESTAMP),
+        acknowledged_by = COALESCE(NULLIF($2, ''), acknowledged_by)
+    WHERE id = $1 AND status <> 'resolved'
+    RETURNING ` + alertColumns
+	alert, err := scanAlert(db.QueryRowContext(ctx, query, id, by))
+	if err != nil {
+		return nil, fmt.Errorf("acknowledging alert: %w", err)
+	}
+	return alert, nil
+}
+
+// ResolveAlert closes an unresolved alert, so the rule's next trigger raises a new one. It returns
+// sql.ErrNoRows when there is no such unresolved alert.
+func (db *DB) ResolveAlert(ctx context.Context, id int) (*model.Alert, error) {
+	const query = `
+    UPDATE alerts
+    SET status = 'resolved', resolved_at = CURRENT_TIMESTAMP
+    WHERE id = $1 AND status <> 'resolved'
+    RETURNING ` + alertColumns
+	alert, err := scanAlert(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("resolving alert: %w", err)
+	}
+	return alert, nil
+}
+
+// MarkAlertNotified records that an alert's notification was sent.
+func (db *DB) MarkAlertNotified(ctx context.Context, id int) error {
+	const query = `UPDATE alerts SET notified_at = CURRENT_TIMESTAMP WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("marking alert notified: %w", err)
+	}
+	return expectRow(res, "marking alert notified")
+}
+
+func scanAlert(row interface{ Scan(...interface{}) error }) (*model.Alert, error) {
+	alert := &model.Alert{}
+	var ruleID, weatherDataID sql.NullInt64
+	var acknowledgedAt, resolvedAt, notifiedAt sql.NullTime
+	err := row.Scan(&alert.ID, &ruleID, &alert.VineyardID, &alert.RuleName, &alert.Metric, &alert.Operator, &alert.Threshold,
+		&alert.Severity, &alert.Message, &alert.PeakValue, &alert.TriggerCount, &alert.FirstObservedAt, &alert.LastObservedAt,
+		&weatherDataID, &alert.Status, &acknowledgedAt, &alert.AcknowledgedBy, &resolvedAt, &notifiedAt, &alert.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	alert.RuleID = nullIntPtr(ruleID)
+	alert.WeatherDataID = nullIntPtr(weatherDataID)
+	alert.AcknowledgedAt = nullTimePtr(acknowledgedAt)
+	alert.ResolvedAt = nullTimePtr(resolvedAt)
+	alert.NotifiedAt = nullTimePtr(notifiedAt)
+	return alert, nil
+}
+
+// Notification methods
+
+const subscriberColumns = `id, vineyard_id, email, COALESCE(name, ''), events, created_at`
+
+// SaveSubscriber inserts a new notification subscriber. An email already subscribed to the vineyard
+// returns ErrDuplicate.
+func (db *DB) SaveSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error {
+	const query = `
+    INSERT INTO notification_subscribers (vineyard_id, email, name, events)
+    VALUES ($1, $2, NULLIF($3, ''), $4)
+    RETURNING id, created_at`
+	err := db.QueryRowContext(ctx, query, sub.VineyardID, sub.Email, sub.Name, pq.Array(sub.Events)).Scan(&sub.ID, &sub.CreatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("inserting subscriber %s: %w", sub.Email, ErrDuplicate)
+	}
+	if err != nil {
+		return fmt.Errorf("inserting subscriber: %w", err)
+	}
+	return nil
+}
+
+// GetSubscriber retrieves a subscriber of a vineyard by ID.
+func (db *DB) GetSubscriber(ctx context.Context, vineyardID, id int) (*model.NotificationSubscriber, error) {
+	const query = `SELECT ` + subscriberColumns + ` FROM notification_subscribers WHERE id = $1 AND vineyard_id = $2`
+	sub, err := scanSubscriber(db.QueryRowContext(ctx, query, id, vineyardID))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving subscriber by ID: %w", err)
+	}
+	return sub, nil
+}
+
+// UpdateSubscriber replaces a subscriber's details. It returns sql.ErrNoRows when the vineyard has no
+// such subscriber.
+func (db *DB) UpdateSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error {
+	const query = `
+    UPDATE notification_subscribers
+    SET email = $1, name = NULLIF($2, ''), events = $3
+    WHERE id = $4 AND vineyard_id = $5
+    RETURNING created_at`
+	err := db.QueryRowContext(ctx, query, sub.Email, sub.Name, pq.Array(sub.Events), sub.ID, sub.VineyardID).Scan(&sub.CreatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("updating subscriber %s: %w", sub.Email, ErrDuplicate)
+	}
+	if err != nil {
+		return fmt.Errorf("updating subscriber: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscriber removes a subscriber from a vineyard.
+func (db *DB) DeleteSubscriber(ctx context.Context, vineyardID, id int) error {
+	const query = `DELETE FROM notification_subscribers WHERE id = $1 AND vineyard_id = $2`
+	res, err := db.ExecContext(ctx, query, id, vineyardID)
+	if err != nil {
+		return fmt.Errorf("deleting subscriber: %w", err)
+	}
+	return expectRow(res, "deleting subscriber")
+}
+
+// ListSubscribers retrieves a vineyard's subscribers ordered by email. A non-empty event keeps only
+// those subscribed to it.
+func (db *DB) ListSubscribers(ctx context.Context, vineyardID int, event string) ([]model.NotificationSubscriber, error) {
+	const query = `
+    SELECT ` + subscriberColumns + `
+    FROM notification_subscribers
+    WHERE vineyard_id = $1 AND ($2 = '' OR cardinality(events) = 0 OR $2 = ANY(events))
+    ORDER BY email`
+	rows, err := db.QueryContext(ctx, query, vineyardID, event)
+	if err != nil {
+		return nil, fmt.Errorf("querying subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []model.NotificationSubscriber
+	for rows.Next() {
+		sub, err := scanSubscriber(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning subscriber: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading subscriber rows: %w", err)
+	}
+	return subs, nil
+}
+
+func scanSubscriber(row interface{ Scan(...interface{}) error }) (*model.NotificationSubscriber, error) {
+	sub := &model.NotificationSubscriber{}
+	err := row.Scan(&sub.ID, &sub.VineyardID, &sub.Email, &sub.Name, pq.Array(&sub.Events), &sub.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+const outboxColumns = `id, kind, vineyard_id, recipients, subject, text_body, COALESCE(html_body, ''), status, attempts,
+    next_attempt_at, COALESCE(last_error, ''), created_at, sent_at`
+
+// EnqueueEmail stores a message for delivery as soon as possible.
+func (db *DB) EnqueueEmail(ctx context.Context, msg *model.OutboxMessage) error {
+	const query = `
+    INSERT INTO email_outbox (kind, vineyard_id, recipients, subject, text_body, html_body)
+    VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+    RETURNING ` + outboxColumns
+	row := db.QueryRowContext(ctx, query, msg.Kind, msg.VineyardID, pq.Array(msg.Recipients), msg.Subject, msg.TextBody, msg.HTMLBody)
+	queued, err := scanOutboxMessage(row)
+	if err != nil {
+		return fmt.Errorf("queuing email: %w", err)
+	}
+	*msg = *queued
+	return nil
+}
+
+// ClaimDueEmails returns up to limit pending messages that are due, oldest first, and pushes their next
+// attempt back by lease so that no other sender picks them up meanwhile.
+func (db *DB) ClaimDueEmails(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	const query = `
+    UPDATE email_outbox
+    SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+    WHERE id IN (
+        SELECT id FROM email_outbox
+        WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+        ORDER BY next_attempt_at, id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED)
+    RETURNING ` + outboxColumns
+	rows, err := db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claiming due emails: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []model.OutboxMessage
+	for rows.Next() {
+		msg, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning outbox message: %w", err)
+		}
+		msgs = append(msgs, *msg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading outbox rows: %w", err)
+	}
+	return msgs, nil
+}
+
+// MarkEmailSent records a successful delivery.
+func (db *DB) MarkEmailSent(ctx context.Context, id int) error {
+	const query = `
+    UPDATE email_outbox
+    SET status = 'sent', attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP, last_error = NULL
+    WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("marking email sent: %w", err)
+	}
+	return expectRow(res, "marking email sent")
+}
+
+// MarkEmailFailed records a failed attempt. The message is retried at next, or given up on when
+// final is set.
+func (db *DB) MarkEmailFailed(ctx context.Context, id int, sendErr string, next time.Time, final bool) error {
+	const query = `
+    UPDATE email_outbox
+    SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3,
+        status = CASE WHEN $4 THEN 'failed' ELSE 'pending' END
+    WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id, sendErr, next, final)
+	if err != nil {
+		return fmt.Errorf("marking email failed: %w", err)
+	}
+	return expectRow(res, "marking email failed")
+}
+
+// ListOutbox retrieves queued and delivered messages newest first, optionally of one status.
+func (db *DB) ListOutbox(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	const query = `
+    SELECT ` + outboxColumns + `
+    FROM email_outbox
+    WHERE $1 = '' OR status = $1
+    ORDER BY created_at DESC, id DESC
+    LIMIT $2`
+	rows, err := db.QueryContext(ctx, query, status, nullLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("querying outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []model.OutboxMessage
+	for rows.Next() {
+		msg, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning outbox message: %w", err)
+		}
+		msgs = append(msgs, *msg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading outbox rows: %w", err)
+	}
+	return msgs, nil
+}
+
+func scanOutboxMessage(row interface{ Scan(...interface{}) error }) (*model.OutboxMessage, error) {
+	msg := &model.OutboxMessage{}
+	var vineyardID sql.NullInt64
+	var sentAt sql.NullTime
+	err := row.Scan(&msg.ID, &msg.Kind, &vineyardID, pq.Array(&msg.Recipients), &msg.Subject, &msg.TextBody, &msg.HTMLBody,
+		&msg.Status, &msg.Attempts, &msg.NextAttemptAt, &msg.LastError, &msg.CreatedAt, &sentAt)
+	if err != nil {
+		return nil, err
+	}
+	msg.VineyardID = nullIntPtr(vineyardID)
+	msg.SentAt = nullTimePtr(sentAt)
+	return msg, nil
+}
+
+// Webhook methods
+
+const webhookColumns = `id, url, secret, events, vineyard_id, COALESCE(description, ''), disabled, created_at, updated_at`
+
+// SaveWebhook inserts a new webhook.
+func (db *DB) SaveWebhook(ctx context.Context, hook *model.Webhook) error {
+	const query = `
+    INSERT INTO webhooks (url, secret, events, vineyard_id, description, disabled)
+    VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+    RETURNING id, created_at, updated_at`
+	err := db.QueryRowContext(ctx, query, hook.URL, hook.Secret, pq.Array(hook.Events), hook.VineyardID,
+		hook.Description, hook.Disabled).Scan(&hook.ID, &hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting webhook: %w", err)
+	}
+	return nil
+}
+
+// GetWebhook retrieves a webhook by ID, including its secret.
+func (db *DB) GetWebhook(ctx context.Context, id int) (*model.Webhook, error) {
+	const query = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	hook, err := scanWebhook(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving webhook by ID: %w", err)
+	}
+	return hook, nil
+}
+
+// UpdateWebhook replaces a webhook's settings. An empty secret keeps the current one.
+func (db *DB) UpdateWebhook(ctx context.Context, hook *model.Webhook) error {
+	const query = `
+    UPDATE webhooks
+    SET url = $1, secret = COALESCE(NULLIF($2, ''), secret), events = $3, vineyard_id = $4, description = NULLIF($5, ''),
+        disabled = $6, updated_at = CURRENT_TIMESTAMP
+    WHERE id = $7
+    RETURNING created_at, updated_at`
+	err := db.QueryRowContext(ctx, query, hook.URL, hook.Secret, pq.Array(hook.Events), hook.VineyardID, hook.Description,
+		hook.Disabled, hook.ID).Scan(&hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("updating webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook along with its deliveries.
+func (db *DB) DeleteWebhook(ctx context.Context, id int) error {
+	const query = `DELETE FROM webhooks WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+	return expectRow(res, "deleting webhook")
+}
+
+// ListWebhooks retrieves every webhook by ID.
+func (db *DB) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	const query = `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("querying webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []model.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning webhook: %w", err)
+		}
+		hooks = append(hooks, *hook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading webhook rows: %w", err)
+	}
+	return hooks, nil
+}
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*model.Webhook, error) {
+	hook := &model.Webhook{}
+	var vineyardID sql.NullInt64
+	err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, pq.Array(&hook.Events), &vineyardID, &hook.Description,
+		&hook.Disabled, &hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	hook.VineyardID = nullIntPtr(vineyardID)
+	return hook, nil
+}
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code,
+    COALESCE(last_error, ''), replay_of, created_at, delivered_at`
+
+// EnqueueWebhookEvent queues payload for every enabled webhook subscribed to event, limited to the
+// vineyard's webhooks and those without a vineyard. It returns the number of deliveries queued.
+func (db *DB) EnqueueWebhookEvent(ctx context.Context, event string, vineyardID *int, payload []byte) (int, error) {
+	const query = `
+    INSERT INTO webhook_deliveries (webhook_id, event, payload)
+    SELECT id, $1, $3 FROM webhooks
+    WHERE NOT disabled AND $1 = ANY(events) AND (vineyard_id IS NULL OR vineyard_id = $2)`
+	res, err := db.ExecContext(ctx, query, event, vineyardID, string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("queuing webhook deliveries: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("queuing webhook deliveries: %w", err)
+	}
+	return int(n), nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries that are due, oldest first, and pushes their
+// next attempt back by lease so that no other sender picks them up meanwhile.
+func (db *DB) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	const query = `
+    UPDATE webhook_deliveries
+    SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+    WHERE id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+        ORDER BY next_attempt_at, id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED)
+    RETURNING ` + deliveryColumns
+	rows, err := db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claiming due webhook deliveries: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// RecordDeliveryAttempt logs an attempt and moves its delivery to status. A pending delivery is retried
+// at next.
+func (db *DB) RecordDeliveryAttempt(ctx context.Context, attempt *model.WebhookAttempt, status string, next time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const insertAttempt = `
+    INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, response_body, duration_ms)
+    VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+    RETURNING id, attempted_at`
+	err = tx.QueryRowContext(ctx, insertAttempt, attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error,
+		attempt.ResponseBody, attempt.DurationMs).Scan(&attempt.ID, &attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("inserting webhook attempt: %w", err)
+	}
+
+	const updateDelivery = `
+    UPDATE webhook_deliveries
+    SET attempts = $2, status = $3, next_attempt_at = $4, last_status_code = $5, last_error = NULLIF($6, ''),
+        delivered_at = CASE WHEN $3 = 'succeeded' THEN $7 ELSE delivered_at END
+    WHERE id = $1`
+	res, err := tx.ExecContext(ctx, updateDelivery, attempt.DeliveryID, attempt.Attempt, status, next, attempt.StatusCode,
+		attempt.Error, attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("updating webhook delivery: %w", err)
+	}
+	if err := expectRow(res, "updating webhook delivery"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetWebhookDelivery retrieves a delivery of a webhook with its attempt log.
+func (db *DB) GetWebhookDelivery(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, error) {
+	const query = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2`
+	delivery, err := scanDelivery(db.QueryRowContext(ctx, query, id, webhookID))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving webhook delivery by ID: %w", err)
+	}
+
+	const attemptsQuery = `
+    SELECT id, delivery_id, attempt, status_code, COALESCE(error, ''), COALESCE(response_body, ''), duration_ms, attempted_at
+    FROM webhook_attempts
+    WHERE delivery_id = $1
+    ORDER BY attempt, id`
+	rows, err := db.QueryContext(ctx, attemptsQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying webhook attempts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var attempt model.WebhookAttempt
+		var statusCode sql.NullInt64
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.Attempt, &statusCode, &attempt.Error,
+			&attempt.ResponseBody, &attempt.DurationMs, &attempt.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("scanning webhook attempt: %w", err)
+		}
+		attempt.StatusCode = nullIntPtr(statusCode)
+		delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading webhook attempt rows: %w", err)
+	}
+	return delivery, nil
+}
+
+// ListWebhookDeliveries retrieves a webhook's deliveries newest first, optionally of one status.
+func (db *DB) ListWebhookDeliveries(ctx context.Context, webhookID int, status string, limit int) ([]model.WebhookDelivery, error) {
+	const query = `
+    SELECT ` + deliveryColumns + `
+    FROM webhook_deliveries
+    WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+    ORDER BY created_at DESC, id DESC
+    LIMIT $3`
+	rows, err := db.QueryContext(ctx, query, webhookID, status, nullLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("querying webhook deliveries: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// ReplayWebhookDelivery queues a delivery's payload to its webhook again as a new delivery.
+func (db *DB) ReplayWebhookDelivery(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, error) {
+	const query = `
+    INSERT INTO webhook_deliveries (webhook_id, event, payload, replay_of)
+    SELECT webhook_id, event, payload, id FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2
+    RETURNING ` + deliveryColumns
+	delivery, err := scanDelivery(db.QueryRowContext(ctx, query, id, webhookID))
+	if err != nil {
+		return nil, fmt.Errorf("replaying webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// scanDeliveries reads and closes rows of deliveryColumns.
+func scanDeliveries(rows *sql.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading webhook delivery rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	var payload []byte
+	var statusCode, replayOf sql.NullInt64
+	var deliveredAt sql.NullTime
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &statusCode, &delivery.LastError, &replayOf, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	delivery.LastStatusCode = nullIntPtr(statusCode)
+	delivery.ReplayOf = nullIntPtr(replayOf)
+	delivery.DeliveredAt = nullTimePtr(deliveredAt)
+	return delivery, nil
+}
+
+// Maturity sample methods
+
+const maturitySampleColumns = `id, vineyard_id, block_id, sample_date, brix, ph, ta_g_l, yan_mg_l, berry_weight_g,
+    COALESCE(notes, ''), COALESCE(photo_url, ''), created_at`
+
+// SaveMaturitySample inserts a new maturity sample.
+func (db *DB) SaveMaturitySample(ctx context.Context, sample *model.MaturitySample) error {
+	const query = `
+    INSERT INTO maturity_samples (vineyard_id, block_id, sample_date, brix, ph, ta_g_l, yan_mg_l, berry_weight_g, notes, photo_url)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
+    RETURNING id, created_at`
+	err := db.QueryRowContext(ctx, query, sample.VineyardID, sample.BlockID, sample.SampleDate.Format("2006-01-02"),
+		sample.Brix, sample.PH, sample.TA, sample.YAN, sample.BerryWeight, sample.Notes, sample.PhotoURL).Scan(&sample.ID, &sample.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting maturity sample: %w", err)
+	}
+	return nil
+}
+
+// GetMaturitySample retrieves a maturity sample by ID.
+func (db *DB) GetMaturitySample(ctx context.Context, id int) (*model.MaturitySample, error) {
+	const query = `SELECT ` + maturitySampleColumns + ` FROM maturity_samples WHERE id = $1`
+	sample, err := scanMaturitySample(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving maturity sample by ID: %w", err)
+	}
+	return sample, nil
+}
+
+// UpdateMaturitySample replaces a sample's block, date and measurements. Its vineyard is kept.
+func (db *DB) UpdateMaturitySample(ctx context.Context, sample *model.MaturitySample) error {
+	const query = `
+    UPDATE maturity_samples
+    SET block_id = $1, sample_date = $2, brix = $3, ph = $4, ta_g_l = $5, yan_mg_l = $6, berry_weight_g = $7,
+        notes = NULLIF($8, ''), photo_url = NULLIF($9, '')
+    WHERE id = $10
+    RETURNING vineyard_id, created_at`
+	err := db.QueryRowContext(ctx, query, sample.BlockID, sample.SampleDate.Format("2006-01-02"), sample.Brix, sample.PH,
+		sample.TA, sample.YAN, sample.BerryWeight, sample.Notes, sample.PhotoURL, sample.ID).Scan(&sample.VineyardID, &sample.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("updating maturity sample: %w", err)
+	}
+	return nil
+}
+
+// DeleteMaturitySample removes a maturity sample by ID.
+func (db *DB) DeleteMaturitySample(ctx context.Context, id int) error {
+	const query = `DELETE FROM maturity_samples WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deleting maturity sample: %w", err)
+	}
+	return expectRow(res, "deleting maturity sample")
+}
+
+// ListMaturitySamples retrieves a vineyard's maturity samples by date, only those of one block when
+// blockID is not zero.
+func (db *DB) ListMaturitySamples(ctx context.Context, vineyardID, blockID int) ([]model.MaturitySample, error) {
+	const query = `
+    SELECT ` + maturitySampleColumns + `
+    FROM maturity_samples
+    WHERE vineyard_id = $1 AND ($2 = 0 OR block_id = $2)
+    ORDER BY sample_date, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("querying maturity samples for vineyard: %w", err)
+	}
+	return scanMaturitySamples(rows)
+}
+
+// ListMaturitySamplesByDateRange retrieves a vineyard's maturity samples taken from start to end,
+// both days included, by date.
+func (db *DB) ListMaturitySamplesByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.MaturitySample, error) {
+	const query = `
+    SELECT ` + maturitySampleColumns + `
+    FROM maturity_samples
+    WHERE vineyard_id = $1 AND sample_date BETWEEN $2 AND $3
+    ORDER BY sample_date, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("querying maturity samples by date range: %w", err)
+	}
+	return scanMaturitySamples(rows)
+}
+
+// scanMaturitySamples reads and closes rows of maturitySampleColumns.
+func scanMaturitySamples(rows *sql.Rows) ([]model.MaturitySample, error) {
+	defer rows.Close()
+	var samples []model.MaturitySample
+	for rows.Next() {
+		sample, err := scanMaturitySample(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning maturity sample: %w", err)
+		}
+		samples = append(samples, *sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading maturity sample rows: %w", err)
+	}
+	return samples, nil
+}
+
+func scanMaturitySample(row interface{ Scan(...interface{}) error }) (*model.MaturitySample, error) {
+	sample := &model.MaturitySample{}
+	var blockID sql.NullInt64
+	var brix, ph, ta, yan, berryWeight sql.NullFloat64
+	err := row.Scan(&sample.ID, &sample.VineyardID, &blockID, &sample.SampleDate, &brix, &ph, &ta, &yan, &berryWeight,
+		&sample.Notes, &sample.PhotoURL, &sample.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sample.BlockID = nullIntPtr(blockID)
+	sample.Brix = nullFloatPtr(brix)
+	sample.PH = nullFloatPtr(ph)
+	sample.TA = nullFloatPtr(ta)
+	sample.YAN = nullFloatPtr(yan)
+	sample.BerryWeight = nullFloatPtr(berryWeight)
+	return sample, nil
+}
+
+// Harvest methods
+
+const harvestColumns = `id, vineyard_id, block_id, harvest_date, tons, bins, COALESCE(crew, ''),
+    COALESCE(destination_winery, ''), brix, ph, COALESCE(notes, ''), created_at`
+
+// SaveHarvest inserts a new harvest.
+func (db *DB) SaveHarvest(ctx context.Context, harvest *model.Harvest) error {
+	const query = `
+    INSERT INTO harvests (vineyard_id, block_id, harvest_date, tons, bins, crew, destination_winery, brix, ph, notes)
+    VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, NULLIF($10, ''))
+    RETURNING id, created_at`
+	err := db.QueryRowContext(ctx, query, harvest.VineyardID, harvest.BlockID, harvest.HarvestDate.Format("2006-01-02"),
+		harvest.Tons, harvest.Bins, harvest.Crew, harvest.DestinationWinery, harvest.Brix, harvest.PH, harvest.Notes).
+		Scan(&harvest.ID, &harvest.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting harvest: %w", err)
+	}
+	return nil
+}
+
+// GetHarvest retrieves a harvest by ID.
+func (db *DB) GetHarvest(ctx context.Context, id int) (*model.Harvest, error) {
+	const query = `SELECT ` + harvestColumns + ` FROM harvests WHERE id = $1`
+	harvest, err := scanHarvest(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving harvest by ID: %w", err)
+	}
+	return harvest, nil
+}
+
+// UpdateHarvest replaces a harvest's block, date and delivery details. Its vineyard is kept.
+func (db *DB) UpdateHarvest(ctx context.Context, harvest *model.Harvest) error {
+	const query = `
+    UPDATE harvests
+    SET block_id = $1, harvest_date = $2, tons = $3, bins = $4, crew = NULLIF($5, ''), destination_winery = NULLIF($6, ''),
+        brix = $7, ph = $8, notes = NULLIF($9, '')
+    WHERE id = $10
+    RETURNING vineyard_id, created_at`
+	err := db.QueryRowContext(ctx, query, harvest.BlockID, harvest.HarvestDate.Format("2006-01-02"), harvest.Tons, harvest.Bins,
+		harvest.Crew, harvest.DestinationWinery, harvest.Brix, harvest.PH, harvest.Notes, harvest.ID).
+		Scan(&harvest.VineyardID, &harvest.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("updating harvest: %w", err)
+	}
+	return nil
+}
+
+// DeleteHarvest removes a harvest by ID.
+func (db *DB) DeleteHarvest(ctx context.Context, id int) error {
+	const query = `DELETE FROM harvests WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deleting harvest: %w", err)
+	}
+	return expectRow(res, "deleting harvest")
+}
+
+// ListHarvests retrieves a vineyard's harvests by date, only those of one block when blockID is not zero.
+func (db *DB) ListHarvests(ctx context.Context, vineyardID, blockID int) ([]model.Harvest, error) {
+	const query = `
+    SELECT ` + harvestColumns + `
+    FROM harvests
+    WHERE vineyard_id = $1 AND ($2 = 0 OR block_id = $2)
+    ORDER BY harvest_date, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("querying harvests for vineyard: %w", err)
+	}
+	return scanHarvests(rows)
+}
+
+// ListHarvestsByDateRange retrieves a vineyard's harvests from start to end, both days included, by date.
+func (db *DB) ListHarvestsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.Harvest, error) {
+	const query = `
+    SELECT ` + harvestColumns + `
+    FROM harvests
+    WHERE vineyard_id = $1 AND harvest_date BETWEEN $2 AND $3
+    ORDER BY harvest_date, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("querying harvests by date range: %w", err)
+	}
+	return scanHarvests(rows)
+}
+
+// scanHarvests reads and closes rows of harvestColumns.
+func scanHarvests(rows *sql.Rows) ([]model.Harvest, error) {
+	defer rows.Close()
+	var harvests []model.Harvest
+	for rows.Next() {
+		harvest, err := scanHarvest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning harvest: %w", err)
+		}
+		harvests = append(harvests, *harvest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading harvest rows: %w", err)
+	}
+	return harvests, nil
+}
+
+func scanHarvest(row interface{ Scan(...interface{}) error }) (*model.Harvest, error) {
+	harvest := &model.Harvest{}
+	var blockID, bins sql.NullInt64
+	var brix, ph sql.NullFloat64
+	err := row.Scan(&harvest.ID, &harvest.VineyardID, &blockID, &harvest.HarvestDate, &harvest.Tons, &bins, &harvest.Crew,
+		&harvest.DestinationWinery, &brix, &ph, &harvest.Notes, &harvest.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	harvest.BlockID = nullIntPtr(blockID)
+	harvest.Bins = nullIntPtr(bins)
+	harvest.Brix = nullFloatPtr(brix)
+	harvest.PH = nullFloatPtr(ph)
+	return harvest, nil
+}
+
+// BlockAreas returns the area in square metres of each of a vineyard's blocks that has a boundary.
+func (db *DB) BlockAreas(ctx context.Context, vineyardID int) (map[int]float64, error) {
+	const query = `SELECT id, ST_Area(geom::geography) FROM blocks WHERE vineyard_id = $1 AND geom IS NOT NULL`
+	rows, err := db.QueryContext(ctx, query, vineyardID)
+	if err != nil {
+		return nil, fmt.Errorf("querying block areas: %w", err)
+	}
+	defer rows.Close()
+
+	areas := make(map[int]float64)
+	for rows.Next() {
+		var id int
+		var area float64
+		if err := rows.Scan(&id, &area); err != nil {
+			return nil, fmt.Errorf("scanning block area: %w", err)
+		}
+		areas[id] = area
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading block area rows: %w", err)
+	}
+	return areas, nil
+}
+
+// PestPressureByBlock counts a vineyard's pest observations from start up to end by block and severity,
+// leaving out disease model warnings. Observations outside every block are keyed by 0.
+func (db *DB) PestPressureByBlock(ctx context.Context, vineyardID int, start, end time.Time) (map[int]*model.PestPressure, error) {
+	const query = `
+    SELECT COALESCE(block_id, 0), COALESCE(severity, ''), COUNT(*)
+    FROM pest_data
+    WHERE vineyard_id = $1 AND kind = $2 AND observation_date >= $3 AND observation_date < $4
+    GROUP BY 1, 2`
+	rows, err := db.QueryContext(ctx, query, vineyardID, model.PestKindObservation, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("counting pest observations: %w", err)
+	}
+	defer rows.Close()
+
+	pressure := make(map[int]*model.PestPressure)
+	for rows.Next() {
+		var blockID, count int
+		var severity string
+		if err := rows.Scan(&blockID, &severity, &count); err != nil {
+			return nil, fmt.Errorf("scanning pest observation count: %w", err)
+		}
+		p, ok := pressure[blockID]
+		if !ok {
+			p = &model.PestPressure{BySeverity: make(map[string]int)}
+			pressure[blockID] = p
+		}
+		p.Observations += count
+		p.BySeverity[severity] += count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading pest observation counts: %w", err)
+	}
+	return pressure, nil
+}
+
+// SoilMoistureByBlock summarises a vineyard's soil moisture readings from start up to end by block.
+// Readings outside every block are keyed by 0.
+func (db *DB) SoilMoistureByBlock(ctx context.Context, vineyardID int, start, end time.Time) (map[int]*model.SoilMoisture, error) {
+	const query = `
+    SELECT COALESCE(block_id, 0), COUNT(*), AVG(moisture), MIN(moisture), MAX(moisture)
+    FROM (
+        SELECT block_id, (data->>'moistureLevel')::double precision AS moisture
+        FROM soil_data
+        WHERE vineyard_id = $1 AND sampled_at >= $2 AND sampled_at < $3
+    ) readings
+    WHERE moisture IS NOT NULL
+    GROUP BY 1`
+	rows, err := db.QueryContext(ctx, query, vineyardID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("summarising soil moisture: %w", err)
+	}
+	defer rows.Close()
+
+	moisture := make(map[int]*model.SoilMoisture)
+	for rows.Next() {
+		var blockID int
+		m := &model.SoilMoisture{}
+		if err := rows.Scan(&blockID, &m.Samples, &m.Mean, &m.Min, &m.Max); err != nil {
+			return nil, fmt.Errorf("scanning soil moisture summary: %w", err)
+		}
+		moisture[blockID] = m
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading soil moisture summaries: %w", err)
+	}
+	return moisture, nil
+}
+
+// Harvest forecast methods
+
+const harvestForecastColumns = `id, vineyard_id, block_id, season, computed_at, trigger, estimate, window_start, window_end,
+    spread_days, confidence, signals`
+
+// SaveHarvestForecast stores a forecast with an estimate in the forecast history.
+func (db *DB) SaveHarvestForecast(ctx context.Context, f *model.HarvestForecast) error {
+	const query = `
+    INSERT INTO harvest_forecasts (vineyard_id, block_id, season, trigger, estimate, window_start, window_end,
+                                   spread_days, confidence, signals)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    RETURNING id, computed_at`
+	if f.Estimate == nil || f.WindowStart == nil || f.WindowEnd == nil || f.SpreadDays == nil {
+		return errors.New("inserting harvest forecast: a forecast without an estimate is not stored")
+	}
+	signals, err := json.Marshal(f.Signals)
+	if err != nil {
+		return fmt.Errorf("encoding harvest forecast signals: %w", err)
+	}
+	err = db.QueryRowContext(ctx, query, f.VineyardID, f.BlockID, f.Season, f.Trigger, f.Estimate.Format("2006-01-02"),
+		f.WindowStart.Format("2006-01-02"), f.WindowEnd.Format("2006-01-02"), *f.SpreadDays, f.Confidence, signals).
+		Scan(&f.ID, &f.ComputedAt)
+	if err != nil {
+		return fmt.Errorf("inserting harvest forecast: %w", err)
+	}
+	return nil
+}
+
+// LatestHarvestForecasts retrieves the last stored forecast of each block of a vineyard's season.
+func (db *DB) LatestHarvestForecasts(ctx context.Context, vineyardID, season int) ([]model.HarvestForecast, error) {
+	const query = `
+    SELECT DISTINCT ON (COALESCE(block_id, 0)) ` + harvestForecastColumns + `
+    FROM harvest_forecasts
+    WHERE vineyard_id = $1 AND season = $2
+    ORDER BY COALESCE(block_id, 0), computed_at DESC, id DESC`
+	rows, err := db.QueryContext(ctx, query, vineyardID, season)
+	if err != nil {
+		return nil, fmt.Errorf("querying latest harvest forecasts: %w", err)
+	}
+	return scanHarvestForecasts(rows)
+}
+
+// ListHarvestForecasts retrieves the stored forecasts of a vineyard's season oldest first, only those of
+// one block when blockID is not zero.
+func (db *DB) ListHarvestForecasts(ctx context.Context, vineyardID, season, blockID int) ([]model.HarvestForecast, error) {
+	const query = `
+    SELECT ` + harvestForecastColumns + `
+    FROM harvest_forecasts
+    WHERE vineyard_id = $1 AND season = $2 AND ($3 = 0 OR block_id = $3)
+    ORDER BY computed_at, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, season, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("querying harvest forecasts: %w", err)
+	}
+	return scanHarvestForecasts(rows)
+}
+
+// scanHarvestForecasts reads and closes rows of harvestForecastColumns.
+func scanHarvestForecasts(rows *sql.Rows) ([]model.HarvestForecast, error) {
+	defer rows.Close()
+	var forecasts []model.HarvestForecast
+	for rows.Next() {
+		f := model.HarvestForecast{}
+		var blockID sql.NullInt64
+		var estimate, windowStart, windowEnd time.Time
+		var spread float64
+		var signals []byte
+		err := rows.Scan(&f.ID, &f.VineyardID, &blockID, &f.Season, &f.ComputedAt, &f.Trigger, &estimate, &windowStart,
+			&windowEnd, &spread, &f.Confidence, &signals)
+		if err != nil {
+			return nil, fmt.Errorf("scanning harvest forecast: %w", err)
+		}
+		if err := json.Unmarshal(signals, &f.Signals); err != nil {
+			return nil, fmt.Errorf("decoding harvest forecast signals: %w", err)
+		}
+		f.BlockID = nullIntPtr(blockID)
+		f.Estimate, f.WindowStart, f.WindowEnd, f.SpreadDays = &estimate, &windowStart, &windowEnd, &spread
+		forecasts = append(forecasts, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading harvest forecast rows: %w", err)
+	}
+	return forecasts, nil
+}
+
+// Phenology observation methods
+
+const phenologyObservationColumns = `id, vineyard_id, block_id, observed_on, stage_system, stage, el_stage, image_id,
+    COALESCE(notes, ''), created_at`
+
+// SavePhenologyObservation inserts a new phenology observation.
+func (db *DB) SavePhenologyObservation(ctx context.Context, obs *model.PhenologyObservation) error {
+	const query = `
+    INSERT INTO phenology_observations (vineyard_id, block_id, observed_on, stage_system, stage, el_stage, image_id, notes)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+    RETURNING id, created_at`
+	err := db.QueryRowContext(ctx, query, obs.VineyardID, obs.BlockID, obs.ObservedOn.Format("2006-01-02"), obs.System,
+		obs.Stage, obs.ELStage, obs.ImageID, obs.Notes).Scan(&obs.ID, &obs.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting phenology observation: %w", err)
+	}
+	return nil
+}
+
+// GetPhenologyObservation retrieves a phenology observation by ID.
+func (db *DB) GetPhenologyObservation(ctx context.Context, id int) (*model.PhenologyObservation, error) {
+	const query = `SELECT ` + phenologyObservationColumns + ` FROM phenology_observations WHERE id = $1`
+	obs, err := scanPhenologyObservation(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving phenology observation by ID: %w", err)
+	}
+	return obs, nil
+}
+
+// UpdatePhenologyObservation replaces an observation's block, date, stage, image and notes. Its vineyard
+// is kept.
+func (db *DB) UpdatePhenologyObservation(ctx context.Context, obs *model.PhenologyObservation) error {
+	const query = `
+    UPDATE phenology_observations
+    SET block_id = $1, observed_on = $2, stage_system = $3, stage = $4, el_stage = $5, image_id = $6, notes = NULLIF($7, '')
+    WHERE id = $8
+    RETURNING vineyard_id, created_at`
+	err := db.QueryRowContext(ctx, query, obs.BlockID, obs.ObservedOn.Format("2006-01-02"), obs.System, obs.Stage,
+		obs.ELStage, obs.ImageID, obs.Notes, obs.ID).Scan(&obs.VineyardID, &obs.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("updating phenology observation: %w", err)
+	}
+	return nil
+}
+
+// DeletePhenologyObservation removes a phenology observation by ID.
+func (db *DB) DeletePhenologyObservation(ctx context.Context, id int) error {
+	const query = `DELETE FROM phenology_observations WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deleting phenology observation: %w", err)
+	}
+	return expectRow(res, "deleting phenology observation")
+}
+
+// ListPhenologyObservations retrieves a vineyard's phenology observations by date, only those of one
+// block when blockID is not zero.
+func (db *DB) ListPhenologyObservations(ctx context.Context, vineyardID, blockID int) ([]model.PhenologyObservation, error) {
+	const query = `
+    SELECT ` + phenologyObservationColumns + `
+    FROM phenology_observations
+    WHERE vineyard_id = $1 AND ($2 = 0 OR block_id = $2)
+    ORDER BY observed_on, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("querying phenology observations for vineyard: %w", err)
+	}
+	return scanPhenologyObservations(rows)
+}
+
+// ListPhenologyObservationsByDateRange retrieves a vineyard's phenology observations made from start to
+// end, both days included, by date.
+func (db *DB) ListPhenologyObservationsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.PhenologyObservation, error) {
+	const query = `
+    SELECT ` + phenologyObservationColumns + `
+    FROM phenology_observations
+    WHERE vineyard_id = $1 AND observed_on BETWEEN $2 AND $3
+    ORDER BY observed_on, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("querying phenology observations by date range: %w", err)
+	}
+	return scanPhenologyObservations(rows)
+}
+
+// scanPhenologyObservations reads and closes rows of phenologyObservationColumns.
+func scanPhenologyObservations(rows *sql.Rows) ([]model.PhenologyObservation, error) {
+	defer rows.Close()
+	var observations []model.PhenologyObservation
+	for rows.Next() {
+		obs, err := scanPhenologyObservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning phenology observation: %w", err)
+		}
+		observations = append(observations, *obs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading phenology observation rows: %w", err)
+	}
+	return observations, nil
+}
+
+func scanPhenologyObservation(row interface{ Scan(...interface{}) error }) (*model.PhenologyObservation, error) {
+	obs := &model.PhenologyObservation{}
+	var blockID, imageID sql.NullInt64
+	err := row.Scan(&obs.ID, &obs.VineyardID, &blockID, &obs.ObservedOn, &obs.System, &obs.Stage, &obs.ELStage, &imageID,
+		&obs.Notes, &obs.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	obs.BlockID = nullIntPtr(blockID)
+	obs.ImageID = nullIntPtr(imageID)
+	return obs, nil
+}
+
+// Spray application methods
+
+const sprayApplicationColumns = `id, vineyard_id, block_id, ST_AsGeoJSON(area), applied_at, product,
+    COALESCE(registration_number, ''), COALESCE(active_ingredient, ''), resistance_groups, rate, rate_unit, acres,
+    COALESCE(target, ''), applicator, rei_hours, phi_days, weather, COALESCE(notes, ''), created_at`
+
+// SaveSprayApplication inserts a new spray application. Without a block, it is assigned the block
+// covering a point of its area.
+func (db *DB) SaveSprayApplication(ctx context.Context, app *model.SprayApplication) error {
+	weather, err := sprayWeatherJSON(app.Weather)
+	if err != nil {
+		return err
+	}
+	query := `
+    INSERT INTO spray_applications (vineyard_id, block_id, area, applied_at, product, registration_number,
+                                    active_ingredient, resistance_groups, rate, rate_unit, acres, target, applicator,
+                                    rei_hours, phi_days, weather, notes)
+    VALUES ($1, ` + blockAt("$2", "$1", "ST_PointOnSurface(ST_SetSRID(ST_GeomFromGeoJSON($3), 4326))") + `,
+            ST_SetSRID(ST_GeomFromGeoJSON($3), 4326), $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11,
+            NULLIF($12, ''), $13, $14, $15, $16, NULLIF($17, ''))
+    RETURNING id, block_id, created_at`
+	err = db.QueryRowContext(ctx, query, app.VineyardID, app.BlockID, app.Area, app.AppliedAt, app.Product,
+		app.RegistrationNumber, app.ActiveIngredient, pq.Array(app.ResistanceGroups), app.Rate, app.RateUnit, app.Acres,
+		app.Target, app.Applicator, app.REIHours, app.PHIDays, weather, app.Notes).Scan(&app.ID, &app.BlockID, &app.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting spray application: %w", err)
+	}
+	return nil
+}
+
+// GetSprayApplication retrieves a spray application by ID.
+func (db *DB) GetSprayApplication(ctx context.Context, id int) (*model.SprayApplication, error) {
+	const query = `SELECT ` + sprayApplicationColumns + ` FROM spray_applications WHERE id = $1`
+	app, err := scanSprayApplication(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("retrieving spray application by ID: %w", err)
+	}
+	return app, nil
+}
+
+// UpdateSprayApplication replaces everything about an application but its vineyard. Its block is
+// assigned as on insert.
+func (db *DB) UpdateSprayApplication(ctx context.Context, app *model.SprayApplication) error {
+	weather, err := sprayWeatherJSON(app.Weather)
+	if err != nil {
+		return err
+	}
+	query := `
+    UPDATE spray_applications
+    SET block_id = ` + blockAt("$1", "spray_applications.vineyard_id", "ST_PointOnSurface(ST_SetSRID(ST_GeomFromGeoJSON($2), 4326))") + `,
+        area = ST_SetSRID(ST_GeomFromGeoJSON($2), 4326), applied_at = $3, product = $4,
+        registration_number = NULLIF($5, ''), active_ingredient = NULLIF($6, ''), resistance_groups = $7, rate = $8,
+        rate_unit = $9, acres = $10, target = NULLIF($11, ''), applicator = $12, rei_hours = $13, phi_days = $14,
+        weather = $15, notes = NULLIF($16, '')
+    WHERE id = $17
+    RETURNING vineyard_id, block_id, created_at`
+	err = db.QueryRowContext(ctx, query, app.BlockID, app.Area, app.AppliedAt, app.Product, app.RegistrationNumber,
+		app.ActiveIngredient, pq.Array(app.ResistanceGroups), app.Rate, app.RateUnit, app.Acres, app.Target, app.Applicator,
+		app.REIHours, app.PHIDays, weather, app.Notes, app.ID).Scan(&app.VineyardID, &app.BlockID, &app.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("updating spray application: %w", err)
+	}
+	return nil
+}
+
+// DeleteSprayApplication removes a spray application by ID.
+func (db *DB) DeleteSprayApplication(ctx context.Context, id int) error {
+	const query = `DELETE FROM spray_applications WHERE id = $1`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deleting spray application: %w", err)
+	}
+	return expectRow(res, "deleting spray application")
+}
+
+// ListSprayApplications retrieves all of a vineyard's spray applications, oldest first.
+func (db *DB) ListSprayApplications(ctx context.Context, vineyardID int) ([]model.SprayApplication, error) {
+	const query = `
+    SELECT ` + sprayApplicationColumns + `
+    FROM spray_applications
+    WHERE vineyard_id = $1
+    ORDER BY applied_at, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID)
+	if err != nil {
+		return nil, fmt.Errorf("querying spray applications for vineyard: %w", err)
+	}
+	return scanSprayApplications(rows)
+}
+
+// ListSprayApplicationsByDateRange retrieves a vineyard's spray applications applied in [start, end),
+// oldest first.
+func (db *DB) ListSprayApplicationsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.SprayApplication, error) {
+	const query = `
+    SELECT ` + sprayApplicationColumns + `
+    FROM spray_applications
+    WHERE vineyard_id = $1 AND applied_at >= $2 AND applied_at < $3
+    ORDER BY applied_at, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("querying spray applications by date range: %w", err)
+	}
+	return scanSprayApplications(rows)
+}
+
+// ListSprayApplicationsOnBlock retrieves the spray applications applied in [start, end) that may have
+// reached a block, oldest first: those assigned to it, those whose area intersects its boundary and
+// those with neither a block nor an area. A nil block matches every application of the vineyard.
+func (db *DB) ListSprayApplicationsOnBlock(ctx context.Context, vineyardID int, blockID *int, start, end time.Time) ([]model.SprayApplication, error) {
+	const query = `
+    SELECT ` + sprayApplicationColumns + `
+    FROM spray_applications
+    WHERE vineyard_id = $1 AND applied_at >= $3 AND applied_at < $4
+      AND ($2::integer IS NULL OR block_id = $2 OR (area IS NULL AND block_id IS NULL)
+           OR EXISTS (SELECT 1 FROM blocks b WHERE b.id = $2 AND ST_Intersects(spray_applications.area, b.geom)))
+    ORDER BY applied_at, id`
+	rows, err := db.QueryContext(ctx, query, vineyardID, blockID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("querying spray applications on block: %w", err)
+	}
+	return scanSprayApplications(rows)
+}
+
+// NearestWeatherData retrieves the vineyard's weather reading observed nearest at, no more than within
+// before or after it. It returns sql.ErrNoRows when there is none.
+func (db *DB) NearestWeatherData(ctx context.Context, vineyardID int, at time.Time, within time.Duration) (*model.WeatherData, error) {
+	const query = `
+    SELECT id, vineyard_id, block_id, temperature, humidity, observation_time, ST_AsGeoJSON(location),
+           precipitation_mm, leaf_wetness_minutes, wind_speed_ms, dew_point
+    FROM weather_data
+    WHERE vineyard_id = $1 AND observation_time BETWEEN $2 AND $3
+    ORDER BY ABS(EXTRACT(EPOCH FROM observation_time - $4::timestamptz)), id
+    LIMIT 1`
+	weather := &model.WeatherData{}
+	err := db.QueryRowContext(ctx, query, vineyardID, at.Add(-within), at.Add(within), at).Scan(&weather.ID, &weather.VineyardID,
+		&weather.BlockID, &weather.Temperature, &weather.Humidity, &weather.ObservationTime, &weather.Location,
+		&weather.Precipitation, &weather.LeafWetness, &weather.WindSpeed, &weather.DewPoint)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving nearest weather data: %w", err)
+	}
+	return weather, nil
+}
+
+// PolygonArea returns the area of a polygon in square metres.
+func (db *DB) PolygonArea(ctx context.Context, polygon geo.Polygon) (float64, error) {
+	const query = `SELECT ST_Area(ST_SetSRID(ST_GeomFromGeoJSON($1), 4326)::geography)`
+	var area float64
+	if err := db.QueryRowContext(ctx, query, polygon).Scan(&area); err != nil {
+		return 0, fmt.Errorf("computing polygon area: %w", err)
+	}
+	return area, nil
+}
+
+// sprayWeatherJSON encodes the weather column, NULL when no reading was found.
+func sprayWeatherJSON(weather *model.SprayWeather) (interface{}, error) {
+	if weather == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(weather)
+	if err != nil {
+		return nil, fmt.Errorf("encoding spray application weather: %w", err)
+	}
+	return b, nil
+}
+
+// scanSprayApplications reads and closes rows of sprayApplicationColumns.
+func scanSprayApplications(rows *sql.Rows) ([]model.SprayApplication, error) {
+	defer rows.Close()
+	var apps []model.SprayApplication
+	for rows.Next() {
+		app, err := scanSprayApplication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning spray application: %w", err)
+		}
+		apps = append(apps, *app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading spray application rows: %w", err)
+	}
+	return apps, nil
+}
+
+func scanSprayApplication(row interface{ Scan(...interface{}) error }) (*model.SprayApplication, error) {
+	app := &model.SprayApplication{}
+	var blockID, phiDays sql.NullInt64
+	var acres, reiHours sql.NullFloat64
+	var weather []byte
+	err := row.Scan(&app.ID, &app.VineyardID, &blockID, &app.Area, &app.AppliedAt, &app.Product, &app.RegistrationNumber,
+		&app.ActiveIngredient, pq.Array(&app.ResistanceGroups), &app.Rate, &app.RateUnit, &acres, &app.Target, &app.Applicator,
+		&reiHours, &phiDays, &weather, &app.Notes, &app.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if weather != nil {
+		if err := json.Unmarshal(weather, &app.Weather); err != nil {
+			return nil, fmt.Errorf("decoding spray application weather: %w", err)
+		}
+	}
+	app.BlockID = nullIntPtr(blockID)
+	app.PHIDays = nullIntPtr(phiDays)
+	app.Acres = nullFloatPtr(acres)
+	app.REIHours = nullFloatPtr(reiHours)
+	return app, nil
+}
+
+// recordTimeColumns maps a record model name to its table and timestamp column.
+var recordTimeColumns = map[string][2]string{
+	"weather":   {"weather_data", "observation_time"},
+	"soil":      {"soil_data", "sampled_at"},
+	"pest":      {"pest_data", "observation_date"},
+	"satellite": {"satellite_imagery", "captured_at"},
+}
+
+// CountRecordsInRange counts a vineyard's records of the given model (weather, soil, pest or satellite)
+// with a timestamp in [start, end).
+func (db *DB) CountRecordsInRange(ctx context.Context, modelName string, vineyardID int, start, end time.Time) (int, error) {
+	table, ok := recordTimeColumns[modelName]
+	if !ok {
+		return 0, fmt.Errorf("unsupported record model %s", modelName)
+	}
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE vineyard_id = $1 AND %s >= $2 AND %s < $3`, table[0], table[1], table[1])
+	var n int
+	if err := db.QueryRowContext(ctx, query, vineyardID, start, end).Scan(&n); err != nil {
+		return 0, fmt.Errorf("counting %s records: %w", modelName, err)
+	}
+	return n, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// expectRow turns an update or delete that matched nothing into sql.ErrNoRows.
+func expectRow(res sql.Result, action string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", action, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// blockAt is the SQL for a record's block: the given block parameter, or else the lowest-numbered block
+// of the vineyard whose boundary covers the point. Records outside every block get NULL.
+func blockAt(blockParam, vineyardExpr, pointExpr string) string {
+	return "COALESCE(" + blockParam + "::integer, (SELECT b.id FROM blocks b WHERE b.vineyard_id = " + vineyardExpr +
+		" AND ST_Covers(b.geom, " + pointExpr + ") ORDER BY b.id LIMIT 1))"
+}
+
+// marshalSoilData encodes the data column. The block lives in its own column, so it is left out of
+// the JSON, which is decoded over the scanned columns on read.
+func marshalSoilData(soilData *model.SoilData) ([]byte, error) {
+	record := *soilData
+	record.BlockID = nil
+	return json.Marshal(record)
+}
+
+// nullLimit turns a non-positive limit into NULL, which LIMIT treats as no limit.
+func nullLimit(limit int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullFloatPtr(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Float64
+	return &v
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
@@ -0,0 +1,247 @@
+/*
+ * migrate.go: Versioned schema migrations for the app database.
+ * Applies the numbered SQL files embedded from the migrations directory and records them in schema_migrations.
+ * Usage: Run at startup when database.autoMigrate is set, or through `harvester migrate up|down|status`.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_lock key that serializes concurrent migration runs.
+const migrationLockID = 7_314_202_401
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a single numbered schema change. Migrations without a down script cannot be rolled back.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// LoadMigrations parses the embedded migration files, ordered by version.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in order and returns the versions applied.
+func (db *DB) MigrateUp(ctx context.Context) ([]int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int
+	err = db.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, m, m.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+				return err
+			}
+			applied = append(applied, m.Version)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown rolls back the most recently applied migrations, up to steps of them.
+// It refuses to roll back a migration that has no down script.
+func (db *DB) MigrateDown(ctx context.Context, steps int) ([]int, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be a positive number")
+	}
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []int
+	err = db.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %04d_%s is irreversible", m.Version, m.Name)
+			}
+			if err := runMigration(ctx, conn, m, m.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+				return err
+			}
+			reverted = append(reverted, m.Version)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// MigrationStatus lists every known migration and whether it has been applied.
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = db.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			status := MigrationStatus{Version: m.Version, Name: m.Name}
+			if at, ok := done[m.Version]; ok {
+				appliedAt := at
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withMigrationLock runs fn on a dedicated connection holding the migration advisory lock.
+func (db *DB) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	const createTable = `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INTEGER PRIMARY KEY,
+        name VARCHAR(255) NOT NULL,
+        applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+    )`
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("scanning schema_migrations: %w", err)
+		}
+		done[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading schema_migrations rows: %w", err)
+	}
+	return done, nil
+}
+
+// runMigration executes a migration script and its bookkeeping statement in one transaction.
+func runMigration(ctx context.Context, conn *sql.Conn, m Migration, script, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("running migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("recording migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
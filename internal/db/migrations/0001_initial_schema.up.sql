@@ -1,16 +1,8 @@
--- Ensure the PostGIS extension is installed
+-- Baseline schema. Uses IF NOT EXISTS so databases created by the old
+-- initdb.sql script can adopt migrations without losing any data.
 CREATE EXTENSION IF NOT EXISTS postgis;
 
--- Drop existing tables if they exist to prevent errors in table creation
-DROP TABLE IF EXISTS weather_data CASCADE;
-DROP TABLE IF EXISTS pest_data CASCADE;
-DROP TABLE IF EXISTS soil_data CASCADE;
-DROP TABLE IF EXISTS satellite_imagery CASCADE;
-DROP TABLE IF EXISTS images CASCADE;
-DROP TABLE IF EXISTS vineyards CASCADE;
-
--- Create vineyards table with a bounding box column
-CREATE TABLE vineyards (
+CREATE TABLE IF NOT EXISTS vineyards (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     location VARCHAR(255),
@@ -19,8 +11,7 @@ CREATE TABLE vineyards (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Create images table storing references to images stored in cloud storage
-CREATE TABLE images (
+CREATE TABLE IF NOT EXISTS images (
     id SERIAL PRIMARY KEY,
     vineyard_id INTEGER NOT NULL,
     image_url TEXT NOT NULL,
@@ -31,8 +22,7 @@ CREATE TABLE images (
     FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
 );
 
--- Create satellite imagery table storing references to satellite images stored in cloud storage
-CREATE TABLE satellite_imagery (
+CREATE TABLE IF NOT EXISTS satellite_imagery (
     id SERIAL PRIMARY KEY,
     vineyard_id INTEGER NOT NULL,
     image_url TEXT NOT NULL,
@@ -43,8 +33,7 @@ CREATE TABLE satellite_imagery (
     FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
 );
 
--- Create soil data table
-CREATE TABLE soil_data (
+CREATE TABLE IF NOT EXISTS soil_data (
     id SERIAL PRIMARY KEY,
     vineyard_id INTEGER NOT NULL,
     data JSONB NOT NULL,
@@ -54,8 +43,7 @@ CREATE TABLE soil_data (
     FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
 );
 
--- Create Pest Data Table
-CREATE TABLE pest_data (
+CREATE TABLE IF NOT EXISTS pest_data (
     id SERIAL PRIMARY KEY,
     vineyard_id INTEGER NOT NULL,
     description TEXT,
@@ -66,8 +54,7 @@ CREATE TABLE pest_data (
     FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
 );
 
--- Create Weather Data Table
-CREATE TABLE weather_data (
+CREATE TABLE IF NOT EXISTS weather_data (
     id SERIAL PRIMARY KEY,
     vineyard_id INTEGER NOT NULL,
     temperature DECIMAL(4, 2) NOT NULL,
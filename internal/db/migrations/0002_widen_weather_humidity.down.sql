@@ -0,0 +1 @@
+ALTER TABLE weather_data ALTER COLUMN humidity TYPE DECIMAL(4, 2);
@@ -0,0 +1,2 @@
+-- DECIMAL(4, 2) tops out at 99.99, so saturated (100%) humidity readings failed to insert.
+ALTER TABLE weather_data ALTER COLUMN humidity TYPE DECIMAL(5, 2);
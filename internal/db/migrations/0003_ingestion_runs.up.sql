@@ -0,0 +1,25 @@
+-- One row per data source fetch for a vineyard. Sources called without a vineyard
+-- (local scheduler HTTP targets) leave vineyard_id NULL.
+CREATE TABLE IF NOT EXISTS ingestion_runs (
+    id SERIAL PRIMARY KEY,
+    source_key VARCHAR(255) NOT NULL,
+    vineyard_id INTEGER,
+    triggered_by VARCHAR(32) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    retry_of INTEGER,
+    window_start TIMESTAMP WITH TIME ZONE,
+    window_end TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    records INTEGER NOT NULL DEFAULT 0,
+    http_status INTEGER,
+    error TEXT,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE,
+    FOREIGN KEY (retry_of) REFERENCES ingestion_runs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS ingestion_runs_started_at_idx ON ingestion_runs (started_at DESC);
+CREATE INDEX IF NOT EXISTS ingestion_runs_source_idx ON ingestion_runs (source_key, started_at DESC);
+-- Serves the last successful sync lookup on vineyards
+CREATE INDEX IF NOT EXISTS ingestion_runs_vineyard_success_idx ON ingestion_runs (vineyard_id, finished_at DESC)
+    WHERE status = 'succeeded';
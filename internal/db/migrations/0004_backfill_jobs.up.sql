@@ -0,0 +1,22 @@
+-- Historical backfills. checkpoint is the first day not yet processed, so an
+-- interrupted job resumes from there.
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id SERIAL PRIMARY KEY,
+    source_key VARCHAR(255) NOT NULL,
+    vineyard_id INTEGER NOT NULL,
+    range_from DATE NOT NULL,
+    range_to DATE NOT NULL,
+    chunk_days INTEGER NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    checkpoint DATE NOT NULL,
+    windows_total INTEGER NOT NULL DEFAULT 0,
+    windows_fetched INTEGER NOT NULL DEFAULT 0,
+    windows_skipped INTEGER NOT NULL DEFAULT 0,
+    records INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS backfill_jobs_lookup_idx ON backfill_jobs (source_key, vineyard_id, range_from, range_to);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS weather_data_location_gist_idx;
+DROP INDEX IF EXISTS pest_data_location_gist_idx;
+DROP INDEX IF EXISTS soil_data_location_gist_idx;
+DROP INDEX IF EXISTS satellite_imagery_bbox_gist_idx;
+DROP INDEX IF EXISTS images_bbox_gist_idx;
+DROP INDEX IF EXISTS vineyards_bbox_geog_gist_idx;
+DROP INDEX IF EXISTS vineyards_bbox_gist_idx;
@@ -0,0 +1,9 @@
+-- GiST indexes for the spatial queries. Distance searches measure in metres on the
+-- geography type, so vineyard bounding boxes are also indexed as geography.
+CREATE INDEX IF NOT EXISTS vineyards_bbox_gist_idx ON vineyards USING GIST (bbox);
+CREATE INDEX IF NOT EXISTS vineyards_bbox_geog_gist_idx ON vineyards USING GIST ((bbox::geography));
+CREATE INDEX IF NOT EXISTS images_bbox_gist_idx ON images USING GIST (bbox);
+CREATE INDEX IF NOT EXISTS satellite_imagery_bbox_gist_idx ON satellite_imagery USING GIST (bbox);
+CREATE INDEX IF NOT EXISTS soil_data_location_gist_idx ON soil_data USING GIST (location);
+CREATE INDEX IF NOT EXISTS pest_data_location_gist_idx ON pest_data USING GIST (location);
+CREATE INDEX IF NOT EXISTS weather_data_location_gist_idx ON weather_data USING GIST (location);
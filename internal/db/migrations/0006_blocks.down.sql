@@ -0,0 +1,5 @@
+ALTER TABLE images DROP COLUMN IF EXISTS block_id;
+ALTER TABLE weather_data DROP COLUMN IF EXISTS block_id;
+ALTER TABLE soil_data DROP COLUMN IF EXISTS block_id;
+ALTER TABLE pest_data DROP COLUMN IF EXISTS block_id;
+DROP TABLE IF EXISTS blocks;
@@ -0,0 +1,30 @@
+-- Vineyard blocks: the management units a vineyard is planted and farmed in.
+CREATE TABLE IF NOT EXISTS blocks (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    varietal VARCHAR(255),
+    clone VARCHAR(255),
+    rootstock VARCHAR(255),
+    planting_year INTEGER,
+    row_spacing_m DECIMAL(6, 2),
+    vine_spacing_m DECIMAL(6, 2),
+    row_orientation VARCHAR(64),
+    trellis_system VARCHAR(255),
+    geom GEOMETRY(POLYGON, 4326),
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE,
+    UNIQUE (vineyard_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS blocks_geom_gist_idx ON blocks USING GIST (geom);
+
+-- Records may belong to a block; deleting the block keeps the records.
+ALTER TABLE pest_data ADD COLUMN IF NOT EXISTS block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL;
+ALTER TABLE soil_data ADD COLUMN IF NOT EXISTS block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL;
+ALTER TABLE weather_data ADD COLUMN IF NOT EXISTS block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS pest_data_block_idx ON pest_data (block_id);
+CREATE INDEX IF NOT EXISTS soil_data_block_idx ON soil_data (block_id);
+CREATE INDEX IF NOT EXISTS weather_data_block_idx ON weather_data (block_id);
+CREATE INDEX IF NOT EXISTS images_block_idx ON images (block_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS disease_risk;
+ALTER TABLE pest_data DROP COLUMN IF EXISTS kind;
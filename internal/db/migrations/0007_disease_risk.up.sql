@@ -0,0 +1,19 @@
+-- Daily output of the disease risk models. details holds the model's per-day inputs and the state
+-- carried into the next day; pest_data_id is the risk record created when the index crossed the
+-- model's spray threshold on that day.
+CREATE TABLE IF NOT EXISTS disease_risk (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    model VARCHAR(64) NOT NULL,
+    day DATE NOT NULL,
+    risk_index DOUBLE PRECISION NOT NULL,
+    risk_level VARCHAR(32) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    pest_data_id INTEGER REFERENCES pest_data(id) ON DELETE SET NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE,
+    UNIQUE (vineyard_id, model, day)
+);
+
+-- Pest records are either field observations or risk warnings raised by a disease model.
+ALTER TABLE pest_data ADD COLUMN IF NOT EXISTS kind VARCHAR(32) NOT NULL DEFAULT 'observation';
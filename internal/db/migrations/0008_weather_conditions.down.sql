@@ -0,0 +1,4 @@
+ALTER TABLE weather_data DROP COLUMN IF EXISTS dew_point;
+ALTER TABLE weather_data DROP COLUMN IF EXISTS wind_speed_ms;
+ALTER TABLE weather_data DROP COLUMN IF EXISTS leaf_wetness_minutes;
+ALTER TABLE weather_data DROP COLUMN IF EXISTS precipitation_mm;
@@ -0,0 +1,6 @@
+-- Conditions needed by the downy mildew and botrytis models. All are optional, since not every
+-- provider reports them. Precipitation and leaf wetness cover the time since the previous reading.
+ALTER TABLE weather_data ADD COLUMN IF NOT EXISTS precipitation_mm DOUBLE PRECISION;
+ALTER TABLE weather_data ADD COLUMN IF NOT EXISTS leaf_wetness_minutes DOUBLE PRECISION;
+ALTER TABLE weather_data ADD COLUMN IF NOT EXISTS wind_speed_ms DOUBLE PRECISION;
+ALTER TABLE weather_data ADD COLUMN IF NOT EXISTS dew_point DOUBLE PRECISION;
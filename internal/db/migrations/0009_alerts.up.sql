@@ -0,0 +1,49 @@
+-- Per-vineyard thresholds on weather readings. active_from and active_to (MM-DD) limit a rule to part
+-- of the year and may wrap past the new year.
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    metric VARCHAR(32) NOT NULL,
+    operator VARCHAR(8) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    active_from CHAR(5),
+    active_to CHAR(5),
+    cooldown_minutes INTEGER NOT NULL DEFAULT 360,
+    severity VARCHAR(32) NOT NULL DEFAULT 'warning',
+    recipients TEXT[] NOT NULL DEFAULT '{}',
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS alert_rules_vineyard_idx ON alert_rules (vineyard_id);
+
+-- Alerts keep a copy of their rule's settings so they still read correctly after the rule changes
+-- or is deleted.
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER REFERENCES alert_rules(id) ON DELETE SET NULL,
+    vineyard_id INTEGER NOT NULL,
+    rule_name VARCHAR(255) NOT NULL,
+    metric VARCHAR(32) NOT NULL,
+    operator VARCHAR(8) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    severity VARCHAR(32) NOT NULL,
+    message TEXT NOT NULL,
+    peak_value DOUBLE PRECISION NOT NULL,
+    trigger_count INTEGER NOT NULL DEFAULT 1,
+    first_observed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_observed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    weather_data_id INTEGER REFERENCES weather_data(id) ON DELETE SET NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'open',
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by VARCHAR(255),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS alerts_rule_idx ON alerts (rule_id, last_observed_at);
+CREATE INDEX IF NOT EXISTS alerts_vineyard_idx ON alerts (vineyard_id, created_at DESC);
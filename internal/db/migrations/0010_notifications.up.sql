@@ -0,0 +1,32 @@
+-- People who receive a vineyard's notification emails. An empty events array subscribes to every kind.
+CREATE TABLE IF NOT EXISTS notification_subscribers (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    email VARCHAR(320) NOT NULL,
+    name VARCHAR(255),
+    events TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE,
+    UNIQUE (vineyard_id, email)
+);
+
+-- Rendered email waiting for delivery, kept after sending as a delivery log. Pending rows are sent
+-- once next_attempt_at has passed; claiming a row pushes next_attempt_at forward so that another
+-- instance does not send it at the same time.
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    vineyard_id INTEGER REFERENCES vineyards(id) ON DELETE SET NULL,
+    recipients TEXT[] NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS email_outbox_due_idx ON email_outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,20 @@
+-- Fruit chemistry of grape samples, used to time the harvest. A sample may belong to a block;
+-- deleting the block keeps its samples.
+CREATE TABLE IF NOT EXISTS maturity_samples (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    sample_date DATE NOT NULL,
+    brix DOUBLE PRECISION,
+    ph DOUBLE PRECISION,
+    ta_g_l DOUBLE PRECISION,
+    yan_mg_l DOUBLE PRECISION,
+    berry_weight_g DOUBLE PRECISION,
+    notes TEXT,
+    photo_url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS maturity_samples_vineyard_date_idx ON maturity_samples (vineyard_id, sample_date);
+CREATE INDEX IF NOT EXISTS maturity_samples_block_idx ON maturity_samples (block_id);
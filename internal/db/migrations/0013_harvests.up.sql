@@ -0,0 +1,19 @@
+-- Picks of a vineyard's blocks and their delivery to a winery. Deleting the block keeps its harvests.
+CREATE TABLE IF NOT EXISTS harvests (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    harvest_date DATE NOT NULL,
+    tons DOUBLE PRECISION NOT NULL CHECK (tons > 0),
+    bins INTEGER CHECK (bins >= 0),
+    crew TEXT,
+    destination_winery TEXT,
+    brix DOUBLE PRECISION,
+    ph DOUBLE PRECISION,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS harvests_vineyard_date_idx ON harvests (vineyard_id, harvest_date);
+CREATE INDEX IF NOT EXISTS harvests_block_idx ON harvests (block_id);
@@ -0,0 +1,18 @@
+-- Every harvest forecast that differed from the one before it, to judge forecast accuracy after the season.
+CREATE TABLE IF NOT EXISTS harvest_forecasts (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE CASCADE,
+    season INTEGER NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    trigger VARCHAR(32) NOT NULL,
+    estimate DATE NOT NULL,
+    window_start DATE NOT NULL,
+    window_end DATE NOT NULL,
+    spread_days DOUBLE PRECISION NOT NULL,
+    confidence VARCHAR(16) NOT NULL,
+    signals JSONB NOT NULL,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS harvest_forecasts_vineyard_season_idx ON harvest_forecasts (vineyard_id, season, computed_at);
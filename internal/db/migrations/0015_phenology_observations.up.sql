@@ -0,0 +1,18 @@
+-- Growth stages observed in the field, recorded in the modified E-L or the BBCH system and normalized to
+-- E-L. An observation without a block covers the whole vineyard; deleting its block or image keeps it.
+CREATE TABLE IF NOT EXISTS phenology_observations (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    observed_on DATE NOT NULL,
+    stage_system VARCHAR(8) NOT NULL,
+    stage INTEGER NOT NULL,
+    el_stage INTEGER NOT NULL,
+    image_id INTEGER REFERENCES images(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS phenology_observations_vineyard_date_idx ON phenology_observations (vineyard_id, observed_on);
+CREATE INDEX IF NOT EXISTS phenology_observations_block_idx ON phenology_observations (block_id);
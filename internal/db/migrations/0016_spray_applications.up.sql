@@ -0,0 +1,29 @@
+-- Pesticide and other chemical applications. An application covers a block, a drawn area, or the whole
+-- vineyard when it has neither; deleting its block keeps it. weather is a copy of the weather_data
+-- reading nearest the application, kept even if the reading is deleted.
+CREATE TABLE IF NOT EXISTS spray_applications (
+    id SERIAL PRIMARY KEY,
+    vineyard_id INTEGER NOT NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    area GEOMETRY(POLYGON, 4326),
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    product VARCHAR(255) NOT NULL,
+    registration_number VARCHAR(64),
+    active_ingredient VARCHAR(255),
+    resistance_groups TEXT[] NOT NULL DEFAULT '{}',
+    rate DOUBLE PRECISION NOT NULL,
+    rate_unit VARCHAR(64) NOT NULL,
+    acres DOUBLE PRECISION,
+    target VARCHAR(255),
+    applicator VARCHAR(255) NOT NULL,
+    rei_hours DOUBLE PRECISION,
+    phi_days INTEGER,
+    weather JSONB,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (vineyard_id) REFERENCES vineyards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS spray_applications_vineyard_time_idx ON spray_applications (vineyard_id, applied_at);
+CREATE INDEX IF NOT EXISTS spray_applications_block_idx ON spray_applications (block_id);
+CREATE INDEX IF NOT EXISTS spray_applications_area_gist_idx ON spray_applications USING GIST (area);
@@ -0,0 +1,2 @@
+ALTER TABLE alert_rules DROP COLUMN IF EXISTS max_el_stage;
+ALTER TABLE alert_rules DROP COLUMN IF EXISTS min_el_stage;
@@ -0,0 +1,4 @@
+-- Limits an alert rule to part of the season by the current E-L growth stage of the reading's block,
+-- e.g. frost alerts from budburst (E-L 4) to flowering (E-L 23). Either bound may be left open.
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS min_el_stage SMALLINT;
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS max_el_stage SMALLINT;
@@ -0,0 +1,85 @@
+/*
+ * botrytis.go: Broome's botrytis bunch rot infection model.
+ * Usage: Broome et al. (1995) fitted the logit of infection severity to the length W of a wetness
+ *        period in hours and its mean temperature T in °C:
+ *            -4.268 + 0.0294·W·T - 0.0901·W - 0.0000235·W·T³
+ *        The index is that value for the day's longest wet period. Below 0.5 the risk is low, up to
+ *        1.0 moderate and from 1.0 high. A wet period still running at midnight carries into the next
+ *        day, so overnight dew is scored as one period on the day it ends.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package disease
+
+import (
+	"math"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// Detail keys of botrytis results.
+const (
+	DetailWetPeriodHours       = "wetPeriodHours"       // Length of the longest wet period, including hours carried from the day before
+	DetailWetPeriodTemperature = "wetPeriodTemperature" // Mean temperature during that period
+	DetailOpenWetHours         = "openWetHours"         // Length of the wet period still running at midnight
+	DetailOpenWetTemperature   = "openWetTemperature"   // Mean temperature during that period
+)
+
+// Botrytis is Broome's botrytis bunch rot infection model.
+type Botrytis struct{}
+
+func (Botrytis) Name() string       { return "botrytis" }
+func (Botrytis) Title() string      { return "Botrytis bunch rot (Broome)" }
+func (Botrytis) PestType() string   { return "botrytis bunch rot" }
+func (Botrytis) Threshold() float64 { return 0.5 }
+
+// Day scores a day.
+func (Botrytis) Day(day Day, prev *model.DiseaseRisk) model.DiseaseRisk {
+	var longestHours, longestTemp, openHours, openTemp float64
+	for i, p := range WetPeriods(day) {
+		hours, temp := p.Duration().Hours(), p.MeanTemperature()
+		if i == 0 && p.Start.Equal(day.Date) && day.Follows(prev) {
+			if carried := prev.Details[DetailOpenWetHours]; carried > 0 {
+				temp = (temp*hours + prev.Details[DetailOpenWetTemperature]*carried) / (hours + carried)
+				hours += carried
+			}
+		}
+		if hours > longestHours {
+			longestHours, longestTemp = hours, temp
+		}
+		if p.End.Equal(day.End()) {
+			openHours, openTemp = hours, temp
+		}
+	}
+
+	index := broome(longestHours, longestTemp)
+	return model.DiseaseRisk{
+		Day:   day.Date,
+		Index: index,
+		Level: botrytisLevel(index),
+		Details: map[string]float64{
+			DetailWetPeriodHours:       math.Round(longestHours*10) / 10,
+			DetailWetPeriodTemperature: math.Round(longestTemp*10) / 10,
+			DetailOpenWetHours:         math.Round(openHours*100) / 100,
+			DetailOpenWetTemperature:   math.Round(openTemp*100) / 100,
+		},
+	}
+}
+
+// broome returns the infection index for a wet period, rounded to two decimals.
+func broome(hours, temp float64) float64 {
+	v := -4.268 + 0.0294*hours*temp - 0.0901*hours - 0.0000235*hours*math.Pow(temp, 3)
+	return math.Round(v*100) / 100
+}
+
+func botrytisLevel(index float64) string {
+	switch {
+	case index >= 1:
+		return model.RiskHigh
+	case index >= 0.5:
+		return model.RiskModerate
+	default:
+		return model.RiskLow
+	}
+}
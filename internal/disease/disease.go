@@ -0,0 +1,216 @@
+/*
+ * disease.go: Engine for the weather-driven disease risk models.
+ * Usage: A Model scores one calendar day of weather observations, given the previous day's result, so
+ *        its state carries from day to day. GroupDays splits a vineyard's observations into days. Each
+ *        reading is taken to hold until the next one, for at most MaxReadingGap, so Periods, LongestRun
+ *        and TotalTime can measure durations from hourly or finer series.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package disease
+
+import (
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// MaxReadingGap is the longest a reading is assumed to hold; a longer gap breaks continuous periods.
+const MaxReadingGap = 2 * time.Hour
+
+const (
+	wetHumidity        = 90.0 // %, above which leaves are taken to be wet without a sensor
+	wetDewPointSpread  = 1.0  // °C, the spread between temperature and dew point at which dew forms
+	wetSensorThreshold = 0.5  // Fraction of a reading's span a leaf wetness sensor must report wet
+)
+
+// Model is a disease risk model evaluated one day at a time.
+type Model interface {
+	Name() string       // Identifier used in URLs and stored with each result, e.g. powdery-mildew
+	Title() string      // Human readable name, used in risk records
+	PestType() string   // pest_type of the risk records raised by the model
+	Threshold() float64 // Index at which spraying is advised
+	Day(day Day, prev *model.DiseaseRisk) model.DiseaseRisk
+}
+
+// Day is one calendar day of a vineyard's weather observations, in time order.
+type Day struct {
+	Date         time.Time
+	Observations []model.WeatherData
+}
+
+// End returns the start of the next day.
+func (d Day) End() time.Time {
+	return d.Date.AddDate(0, 0, 1)
+}
+
+// Follows reports whether the day comes directly after prev's day.
+func (d Day) Follows(prev *model.DiseaseRisk) bool {
+	return prev != nil && prev.Day.AddDate(0, 0, 1).Equal(d.Date)
+}
+
+var models = []Model{GublerThomas{}, DownyMildew{}, Botrytis{}}
+
+// Models returns every available model.
+func Models() []Model {
+	return models
+}
+
+// Lookup returns the model with the given name.
+func Lookup(name string) (Model, bool) {
+	for _, m := range models {
+		if m.Name() == name {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// GroupDays splits time-ordered observations into calendar days in loc. Days without observations
+// are omitted.
+func GroupDays(observations []model.WeatherData, loc *time.Location) []Day {
+	var days []Day
+	for _, obs := range observations {
+		t := obs.ObservationTime.In(loc)
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, Day{Date: date})
+		}
+		last := &days[len(days)-1]
+		last.Observations = append(last.Observations, obs)
+	}
+	return days
+}
+
+// Crossed reports whether a result reached the model's threshold from below it.
+func Crossed(m Model, prev *model.DiseaseRisk, cur model.DiseaseRisk) bool {
+	return cur.Index >= m.Threshold() && (prev == nil || prev.Index < m.Threshold())
+}
+
+// Period is a continuous stretch of a day during which readings satisfied a condition.
+type Period struct {
+	Start, End  time.Time
+	degreeHours float64 // Temperature integrated over the period
+}
+
+// Duration returns the length of the period.
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// MeanTemperature returns the time-weighted mean temperature during the period.
+func (p Period) MeanTemperature() float64 {
+	if p.End.After(p.Start) {
+		return p.degreeHours / p.Duration().Hours()
+	}
+	return 0
+}
+
+// Periods returns the continuous periods of the day during which readings satisfied ok, in time order.
+func Periods(day Day, ok func(model.WeatherData) bool) []Period {
+	return periods(day, func(obs model.WeatherData, _ time.Duration) bool { return ok(obs) })
+}
+
+// periods is Periods for conditions that depend on how long a reading holds.
+func periods(day Day, ok func(obs model.WeatherData, span time.Duration) bool) []Period {
+	var found []Period
+	eachSpan(day, func(obs model.WeatherData, start, end time.Time) {
+		if !ok(obs, end.Sub(start)) {
+			return
+		}
+		if n := len(found); n == 0 || !found[n-1].End.Equal(start) {
+			found = append(found, Period{Start: start})
+		}
+		last := &found[len(found)-1]
+		last.End = end
+		last.degreeHours += obs.Temperature * end.Sub(start).Hours()
+	})
+	return found
+}
+
+// LongestRun returns the longest continuous period of the day during which readings satisfied ok.
+func LongestRun(day Day, ok func(model.WeatherData) bool) time.Duration {
+	var longest time.Duration
+	for _, p := range Periods(day, ok) {
+		if p.Duration() > longest {
+			longest = p.Duration()
+		}
+	}
+	return longest
+}
+
+// TotalTime returns how long readings satisfied ok during the day.
+func TotalTime(day Day, ok func(model.WeatherData) bool) time.Duration {
+	var total time.Duration
+	eachSpan(day, func(obs model.WeatherData, start, end time.Time) {
+		if ok(obs) {
+			total += end.Sub(start)
+		}
+	})
+	return total
+}
+
+// Wet reports whether leaves were wet during a reading. A leaf wetness sensor decides when there is
+// one; otherwise leaves are taken to be wet in rain, at dew point or in humidity of 90% or more.
+func Wet(obs model.WeatherData, span time.Duration) bool {
+	if obs.LeafWetness != nil {
+		return *obs.LeafWetness >= wetSensorThreshold*span.Minutes()
+	}
+	switch {
+	case obs.Precipitation != nil && *obs.Precipitation > 0:
+		return true
+	case obs.DewPoint != nil && obs.Temperature-*obs.DewPoint <= wetDewPointSpread:
+		return true
+	default:
+		return obs.Humidity >= wetHumidity
+	}
+}
+
+// WetPeriods returns the periods of the day during which leaves were wet.
+func WetPeriods(day Day) []Period {
+	return periods(day, Wet)
+}
+
+// Precipitation returns the day's total rainfall in mm. Readings without precipitation count as dry.
+func Precipitation(day Day) float64 {
+	var total float64
+	for _, obs := range day.Observations {
+		if obs.Precipitation != nil {
+			total += *obs.Precipitation
+		}
+	}
+	return total
+}
+
+// MeanTemperature returns the time-weighted mean temperature of the day.
+func MeanTemperature(day Day) float64 {
+	var degreeHours, hours float64
+	for _, p := range Periods(day, func(model.WeatherData) bool { return true }) {
+		degreeHours += p.degreeHours
+		hours += p.Duration().Hours()
+	}
+	if hours == 0 {
+		return 0
+	}
+	return degreeHours / hours
+}
+
+// eachSpan calls fn with each reading and the period it holds for: until the next reading, the end of
+// the day or MaxReadingGap, whichever comes first.
+func eachSpan(day Day, fn func(obs model.WeatherData, start, end time.Time)) {
+	dayEnd := day.End()
+	for i, obs := range day.Observations {
+		start := obs.ObservationTime
+		end := start.Add(MaxReadingGap)
+		if i+1 < len(day.Observations) && day.Observations[i+1].ObservationTime.Before(end) {
+			end = day.Observations[i+1].ObservationTime
+		}
+		if dayEnd.Before(end) {
+			end = dayEnd
+		}
+		if end.After(start) {
+			fn(obs, start, end)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * downymildew.go: The "3-10" rule for primary downy mildew infection.
+ * Usage: Oospores in the soil can infect the vine once the mean temperature is at least 10°C and at
+ *        least 10 mm of rain has fallen within 24 to 48 hours, with shoots 10 cm long. The index is the
+ *        rain of the day and the day before when the day is warm enough, and an infection event when
+ *        it reaches 10 mm. Shoot length is not recorded, so it is taken to be met within the season.
+ *        Events at the 18-25°C optimum for infection are high risk, other events moderate.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package disease
+
+import (
+	"math"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+const (
+	dmMinTemp        = 10.0 // °C
+	dmMinRain        = 10.0 // mm over two days
+	dmMinOptimumTemp = 18.0 // °C
+	dmMaxOptimumTemp = 25.0 // °C
+)
+
+// Detail keys of downy mildew results.
+const (
+	DetailRain            = "rainMm"          // Rainfall of the day
+	DetailMeanTemperature = "meanTemperature" // Time-weighted mean temperature of the day
+	DetailWetHours        = "wetHours"        // Total time leaves were wet
+)
+
+// DownyMildew is the "3-10" rule for primary downy mildew infection.
+type DownyMildew struct{}
+
+func (DownyMildew) Name() string       { return "downy-mildew" }
+func (DownyMildew) Title() string      { return "Downy mildew (3-10 rule)" }
+func (DownyMildew) PestType() string   { return "downy mildew" }
+func (DownyMildew) Threshold() float64 { return dmMinRain }
+
+// Day scores a day. The previous day's rain counts only when prev is the day before.
+func (DownyMildew) Day(day Day, prev *model.DiseaseRisk) model.DiseaseRisk {
+	rain := Precipitation(day)
+	mean := MeanTemperature(day)
+	var wet float64
+	for _, p := range WetPeriods(day) {
+		wet += p.Duration().Hours()
+	}
+
+	index := 0.0
+	if mean >= dmMinTemp {
+		index = rain
+		if day.Follows(prev) {
+			index += prev.Details[DetailRain]
+		}
+	}
+	index = math.Round(index*10) / 10
+
+	level := model.RiskLow
+	switch {
+	case index < dmMinRain:
+	case mean >= dmMinOptimumTemp && mean <= dmMaxOptimumTemp:
+		level = model.RiskHigh
+	default:
+		level = model.RiskModerate
+	}
+
+	return model.DiseaseRisk{
+		Day:   day.Date,
+		Index: index,
+		Level: level,
+		Details: map[string]float64{
+			DetailRain:            math.Round(rain*10) / 10,
+			DetailMeanTemperature: math.Round(mean*10) / 10,
+			DetailWetHours:        math.Round(wet*10) / 10,
+		},
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * gublerthomas.go: The UC Davis Gubler-Thomas powdery mildew risk index.
+ * Usage: The index starts after three consecutive days with at least six continuous hours between
+ *        21 and 29.4°C (70-85°F), reaching 60. From then on each such day adds 20 points and every
+ *        other day subtracts 10, and a further 10 are subtracted when it is 35°C (95°F) or hotter for
+ *        15 minutes or more. The index stays between 0 and 100. Up to 30 is low risk, up to 50
+ *        moderate and from 60 high, when spray intervals should be shortened.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package disease
+
+import (
+	"math"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+const (
+	gtMinFavorable   = 21.0 // °C
+	gtMaxFavorable   = 29.4 // °C
+	gtLethal         = 35.0 // °C
+	gtFavorableHours = 6 * time.Hour
+	gtLethalMinutes  = 15 * time.Minute
+	gtInitiationDays = 3
+)
+
+// Detail keys of Gubler-Thomas results.
+const (
+	DetailFavorableHours = "favorableHours" // Longest continuous period between 21 and 29.4°C
+	DetailLethalMinutes  = "lethalMinutes"  // Time at 35°C or above
+	DetailInitiationDays = "initiationDays" // Consecutive favorable days towards starting the index, 3 once started
+)
+
+// GublerThomas is the Gubler-Thomas powdery mildew risk index.
+type GublerThomas struct{}
+
+func (GublerThomas) Name() string       { return "powdery-mildew" }
+func (GublerThomas) Title() string      { return "Gubler-Thomas powdery mildew" }
+func (GublerThomas) PestType() string   { return "powdery mildew" }
+func (GublerThomas) Threshold() float64 { return 60 }
+
+// Day scores a day. Before the index has started, a day that does not follow prev restarts the count
+// of consecutive favorable days.
+func (GublerThomas) Day(day Day, prev *model.DiseaseRisk) model.DiseaseRisk {
+	favorable := LongestRun(day, func(w model.WeatherData) bool {
+		return w.Temperature >= gtMinFavorable && w.Temperature <= gtMaxFavorable
+	})
+	lethal := TotalTime(day, func(w model.WeatherData) bool {
+		return w.Temperature >= gtLethal
+	})
+	isFavorable := favorable >= gtFavorableHours
+
+	index, initiation := 0.0, 0.0
+	if prev != nil {
+		index, initiation = prev.Index, prev.Details[DetailInitiationDays]
+	}
+	if initiation < gtInitiationDays {
+		// Counting towards initiation needs an unbroken series of favorable days
+		if !day.Follows(prev) {
+			initiation = 0
+		}
+		index = 0
+		if isFavorable {
+			initiation++
+		} else {
+			initiation = 0
+		}
+		if initiation == gtInitiationDays {
+			index = 20 * gtInitiationDays
+		}
+	} else {
+		if isFavorable {
+			index += 20
+		} else {
+			index -= 10
+		}
+		if lethal >= gtLethalMinutes {
+			index -= 10
+		}
+		index = math.Min(100, math.Max(0, index))
+	}
+
+	return model.DiseaseRisk{
+		Day:   day.Date,
+		Index: index,
+		Level: gublerThomasLevel(index),
+		Details: map[string]float64{
+			DetailFavorableHours: favorable.Hours(),
+			DetailLethalMinutes:  lethal.Minutes(),
+			DetailInitiationDays: initiation,
+		},
+	}
+}
+
+func gublerThomasLevel(index float64) string {
+	switch {
+	case index >= 60:
+		return model.RiskHigh
+	case index > 30:
+		return model.RiskModerate
+	default:
+		return model.RiskLow
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * feature.go: Streams GeoJSON FeatureCollections.
+ * Usage: Create a FeatureWriter over the response, call Write once per record and Close at the end.
+ *        Nothing is written until the first feature or Close, so a caller can still report an error
+ *        as long as Started is false. Properties builds a feature's properties from a model, dropping
+ *        the field that became its geometry.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package geo
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ContentType is the media type of GeoJSON documents (RFC 7946).
+const ContentType = "application/geo+json"
+
+// Feature is one GeoJSON Feature. A nil or empty Geometry is written as null.
+type Feature struct {
+	ID         int
+	Geometry   Geometry
+	Properties map[string]json.RawMessage
+}
+
+// FeatureWriter writes a FeatureCollection one feature at a time.
+type FeatureWriter struct {
+	w       io.Writer
+	started bool
+	count   int
+}
+
+func NewFeatureWriter(w io.Writer) *FeatureWriter {
+	return &FeatureWriter{w: w}
+}
+
+// Started reports whether any output has been written.
+func (fw *FeatureWriter) Started() bool { return fw.started }
+
+// Count returns the number of features written.
+func (fw *FeatureWriter) Count() int { return fw.count }
+
+// Write appends a feature to the collection.
+func (fw *FeatureWriter) Write(f Feature) error {
+	var geometry interface{}
+	if f.Geometry != nil && !isEmpty(f.Geometry) {
+		geometry = f.Geometry
+	}
+	b, err := json.Marshal(struct {
+		Type       string                     `json:"type"`
+		ID         int                        `json:"id"`
+		Geometry   interface{}                `json:"geometry"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}{"Feature", f.ID, geometry, f.Properties})
+	if err != nil {
+		return err
+	}
+
+	prefix := ","
+	if !fw.started {
+		prefix = `{"type":"FeatureCollection","features":[`
+		fw.started = true
+	}
+	if _, err := io.WriteString(fw.w, prefix); err != nil {
+		return err
+	}
+	if _, err := fw.w.Write(b); err != nil {
+		return err
+	}
+	fw.count++
+	return nil
+}
+
+// Close ends the collection. An empty collection is still a valid document.
+func (fw *FeatureWriter) Close() error {
+	if !fw.started {
+		fw.started = true
+		_, err := io.WriteString(fw.w, `{"type":"FeatureCollection","features":[]}`+"\n")
+		return err
+	}
+	_, err := io.WriteString(fw.w, "]}\n")
+	return err
+}
+
+// Properties returns the JSON fields of v, without the fields named in omit.
+func Properties(v interface{}, omit ...string) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(b, &props); err != nil {
+		return nil, err
+	}
+	for _, name := range omit {
+		delete(props, name)
+	}
+	return props, nil
+}
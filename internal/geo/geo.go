@@ -0,0 +1,277 @@
+/*
+ * geo.go: Geometry types shared by the models, the database layer and the API.
+ * Usage:
+ *   - Point, Polygon and MultiPolygon marshal as RFC 7946 GeoJSON geometries and implement
+ *     driver.Valuer and sql.Scanner, so they are written with ST_GeomFromGeoJSON and read back
+ *     with ST_AsGeoJSON.
+ *   - Coordinates are WGS 84 longitude/latitude. Altitudes are accepted on input and dropped.
+ *   - Parse decodes any supported geometry and ParseArea only polygons; Validate reports a *ValidationError.
+ *   - The zero value of each type is "no geometry": it marshals as null and is stored as NULL.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package geo
+
+import (
+	"encoding/json"
+)
+
+// GeoJSON geometry type names.
+const (
+	TypePoint        = "Point"
+	TypePolygon      = "Polygon"
+	TypeMultiPolygon = "MultiPolygon"
+)
+
+// Geometry is implemented by every geometry type in this package.
+type Geometry interface {
+	GeometryType() string
+	Validate() error
+}
+
+// Position is a longitude/latitude pair.
+type Position [2]float64
+
+// Lon returns the position's longitude.
+func (p Position) Lon() float64 { return p[0] }
+
+// Lat returns the position's latitude.
+func (p Position) Lat() float64 { return p[1] }
+
+// Point is a single location.
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// Polygon is a list of linear rings: the exterior ring first, then any holes.
+// Each ring is closed, i.e. its first and last positions are equal.
+type Polygon [][]Position
+
+// MultiPolygon is a list of polygons.
+type MultiPolygon []Polygon
+
+func (Point) GeometryType() string        { return TypePoint }
+func (Polygon) GeometryType() string      { return TypePolygon }
+func (MultiPolygon) GeometryType() string { return TypeMultiPolygon }
+
+// IsZero reports whether the point is unset.
+func (p Point) IsZero() bool { return p == Point{} }
+
+// Position returns the point's coordinates.
+func (p Point) Position() Position { return Position{p.Lon, p.Lat} }
+
+// geoJSON is the wire form of a geometry.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// MarshalJSON writes the point as a GeoJSON Point, or null when unset.
+func (p Point) MarshalJSON() ([]byte, error) {
+	if p.IsZero() {
+		return []byte("null"), nil
+	}
+	return marshalGeometry(TypePoint, []float64{p.Lon, p.Lat})
+}
+
+// UnmarshalJSON reads a GeoJSON Point. The object form {"longitude": .., "latitude": ..} used before
+// GeoJSON is also accepted, so response mappings can keep targeting location.latitude.
+func (p *Point) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		*p = Point{}
+		return nil
+	}
+	var legacy struct {
+		Type      string   `json:"type"`
+		Longitude *float64 `json:"longitude"`
+		Latitude  *float64 `json:"latitude"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return invalid("point must be a GeoJSON object")
+	}
+	if legacy.Type == "" && (legacy.Longitude != nil || legacy.Latitude != nil) {
+		*p = Point{}
+		if legacy.Longitude != nil {
+			p.Lon = *legacy.Longitude
+		}
+		if legacy.Latitude != nil {
+			p.Lat = *legacy.Latitude
+		}
+		return nil
+	}
+
+	var coords []float64
+	if err := unmarshalGeometry(data, TypePoint, &coords); err != nil {
+		return err
+	}
+	pos, err := toPosition(coords)
+	if err != nil {
+		return err
+	}
+	*p = Point{Lon: pos.Lon(), Lat: pos.Lat()}
+	return nil
+}
+
+// MarshalJSON writes the polygon as a GeoJSON Polygon, or null when empty.
+func (p Polygon) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return marshalGeometry(TypePolygon, [][]Position(p))
+}
+
+// UnmarshalJSON reads a GeoJSON Polygon.
+func (p *Polygon) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		*p = nil
+		return nil
+	}
+	var coords [][][]float64
+	if err := unmarshalGeometry(data, TypePolygon, &coords); err != nil {
+		return err
+	}
+	poly, err := toPolygon(coords)
+	if err != nil {
+		return err
+	}
+	*p = poly
+	return nil
+}
+
+// MarshalJSON writes the geometry as a GeoJSON MultiPolygon, or null when empty.
+func (m MultiPolygon) MarshalJSON() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte("null"), nil
+	}
+	coords := make([][][]Position, len(m))
+	for i, poly := range m {
+		coords[i] = poly
+	}
+	return marshalGeometry(TypeMultiPolygon, coords)
+}
+
+// UnmarshalJSON reads a GeoJSON MultiPolygon.
+func (m *MultiPolygon) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		*m = nil
+		return nil
+	}
+	var coords [][][][]float64
+	if err := unmarshalGeometry(data, TypeMultiPolygon, &coords); err != nil {
+		return err
+	}
+	multi := make(MultiPolygon, len(coords))
+	for i, polyCoords := range coords {
+		poly, err := toPolygon(polyCoords)
+		if err != nil {
+			return invalid("polygon %d: %s", i, err.(*ValidationError).Reason)
+		}
+		multi[i] = poly
+	}
+	*m = multi
+	return nil
+}
+
+// Parse decodes a GeoJSON Point, Polygon or MultiPolygon and validates it.
+func Parse(data []byte) (Geometry, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, invalid("geometry must be a GeoJSON object")
+	}
+
+	var g Geometry
+	switch header.Type {
+	case TypePoint:
+		var p Point
+		if err := p.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		g = p
+	case TypePolygon:
+		var p Polygon
+		if err := p.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		g = p
+	case TypeMultiPolygon:
+		var m MultiPolygon
+		if err := m.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		g = m
+	default:
+		return nil, invalid("unsupported geometry type %q", header.Type)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ParseArea decodes a Polygon or MultiPolygon, e.g. an area drawn on a map, and validates it.
+func ParseArea(data []byte) (Geometry, error) {
+	g, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := g.(Point); ok {
+		return nil, invalid("expected a GeoJSON Polygon or MultiPolygon, got a Point")
+	}
+	return g, nil
+}
+
+func marshalGeometry(geometryType string, coordinates interface{}) ([]byte, error) {
+	coords, err := json.Marshal(coordinates)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geoJSON{Type: geometryType, Coordinates: coords})
+}
+
+func unmarshalGeometry(data []byte, geometryType string, coordinates interface{}) error {
+	var g geoJSON
+	if err := json.Unmarshal(data, &g); err != nil {
+		return invalid("%s must be a GeoJSON object", geometryType)
+	}
+	if g.Type != geometryType {
+		return invalid("expected a GeoJSON %s, got %q", geometryType, g.Type)
+	}
+	if len(g.Coordinates) == 0 {
+		return invalid("%s has no coordinates", geometryType)
+	}
+	if err := json.Unmarshal(g.Coordinates, coordinates); err != nil {
+		return invalid("malformed %s coordinates", geometryType)
+	}
+	return nil
+}
+
+func toPosition(coords []float64) (Position, error) {
+	if len(coords) < 2 || len(coords) > 3 {
+		return Position{}, invalid("a position needs 2 or 3 numbers, got %d", len(coords))
+	}
+	return Position{coords[0], coords[1]}, nil
+}
+
+func toPolygon(coords [][][]float64) (Polygon, error) {
+	poly := make(Polygon, len(coords))
+	for i, ringCoords := range coords {
+		ring := make([]Position, len(ringCoords))
+		for j, c := range ringCoords {
+			pos, err := toPosition(c)
+			if err != nil {
+				return nil, invalid("ring %d, position %d: %s", i, j, err.(*ValidationError).Reason)
+			}
+			ring[j] = pos
+		}
+		poly[i] = ring
+	}
+	return poly, nil
+}
+
+func isNull(data []byte) bool {
+	return string(data) == "null"
+}
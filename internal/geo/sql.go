@@ -0,0 +1,76 @@
+/*
+ * sql.go: database/sql support for the geometry types.
+ * Usage: Pass geometries as query arguments wrapped in ST_SetSRID(ST_GeomFromGeoJSON($n), 4326) and
+ *        scan ST_AsGeoJSON(column) into them. Empty geometries are written as NULL and NULL reads
+ *        back as an empty geometry.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package geo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Value implements driver.Valuer.
+func (p Point) Value() (driver.Value, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+	return jsonValue(p)
+}
+
+// Value implements driver.Valuer.
+func (p Polygon) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	return jsonValue(p)
+}
+
+// Value implements driver.Valuer.
+func (m MultiPolygon) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return jsonValue(m)
+}
+
+// Scan implements sql.Scanner for ST_AsGeoJSON output.
+func (p *Point) Scan(src interface{}) error {
+	return scanJSON(src, p)
+}
+
+// Scan implements sql.Scanner for ST_AsGeoJSON output.
+func (p *Polygon) Scan(src interface{}) error {
+	return scanJSON(src, p)
+}
+
+// Scan implements sql.Scanner for ST_AsGeoJSON output.
+func (m *MultiPolygon) Scan(src interface{}) error {
+	return scanJSON(src, m)
+}
+
+func jsonValue(g interface{}) (driver.Value, error) {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func scanJSON(src interface{}, dst json.Unmarshaler) error {
+	switch v := src.(type) {
+	case nil:
+		return dst.UnmarshalJSON([]byte("null"))
+	case []byte:
+		return dst.UnmarshalJSON(v)
+	case string:
+		return dst.UnmarshalJSON([]byte(v))
+	default:
+		return fmt.Errorf("cannot scan %T into a geometry; select ST_AsGeoJSON(column)", src)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * validate.go: Validation of geometries before they are stored.
+ * Usage: Services call Validate on incoming geometries; errors are *ValidationError so the API can
+ *        report them as bad requests. Checks cover coordinate ranges, ring closure and size,
+ *        degenerate (zero-area) rings and self-intersecting rings.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ValidationError describes why a geometry was rejected.
+type ValidationError struct {
+	Field  string // Model field the geometry came from, when known
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("invalid geometry in %s: %s", e.Field, e.Reason)
+	}
+	return "invalid geometry: " + e.Reason
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &ValidationError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// ValidateField validates g and names field in the error. Empty geometries are accepted,
+// since every geometry column is optional.
+func ValidateField(field string, g Geometry) error {
+	if isEmpty(g) {
+		return nil
+	}
+	err := g.Validate()
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return &ValidationError{Field: field, Reason: verr.Reason}
+	}
+	return err
+}
+
+func isEmpty(g Geometry) bool {
+	switch v := g.(type) {
+	case Point:
+		return v.IsZero()
+	case Polygon:
+		return len(v) == 0
+	case MultiPolygon:
+		return len(v) == 0
+	default:
+		return g == nil
+	}
+}
+
+// Validate checks the coordinate ranges.
+func (p Point) Validate() error {
+	return validatePosition(p.Position())
+}
+
+// Validate checks each ring: at least four positions, closed, not degenerate and not self-intersecting.
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return invalid("polygon has no rings")
+	}
+	for i, ring := range p {
+		if err := validateRing(ring); err != nil {
+			return invalid("ring %d: %s", i, err.(*ValidationError).Reason)
+		}
+	}
+	return nil
+}
+
+// Validate checks every polygon.
+func (m MultiPolygon) Validate() error {
+	if len(m) == 0 {
+		return invalid("multipolygon has no polygons")
+	}
+	for i, poly := range m {
+		if err := poly.Validate(); err != nil {
+			return invalid("polygon %d: %s", i, err.(*ValidationError).Reason)
+		}
+	}
+	return nil
+}
+
+func validatePosition(pos Position) error {
+	lon, lat := pos.Lon(), pos.Lat()
+	if math.IsNaN(lon) || math.IsNaN(lat) || math.IsInf(lon, 0) || math.IsInf(lat, 0) {
+		return invalid("coordinates must be finite numbers")
+	}
+	if lon < -180 || lon > 180 {
+		return invalid("longitude %g is outside [-180, 180]", lon)
+	}
+	if lat < -90 || lat > 90 {
+		return invalid("latitude %g is outside [-90, 90]", lat)
+	}
+	return nil
+}
+
+func validateRing(ring []Position) error {
+	if len(ring) < 4 {
+		return invalid("a linear ring needs at least 4 positions, got %d", len(ring))
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return invalid("linear ring is not closed")
+	}
+	for _, pos := range ring {
+		if err := validatePosition(pos); err != nil {
+			return err
+		}
+	}
+	if i, j, ok := selfIntersection(ring); ok {
+		return invalid("linear ring intersects itself between edges %d and %d", i, j)
+	}
+	if ringArea(ring) == 0 {
+		return invalid("linear ring has no area")
+	}
+	return nil
+}
+
+// ringArea is the shoelace formula; the sign gives the winding order.
+func ringArea(ring []Position) float64 {
+	var sum float64
+	for i := 0; i < len(ring)-1; i++ {
+		sum += ring[i].Lon()*ring[i+1].Lat() - ring[i+1].Lon()*ring[i].Lat()
+	}
+	return sum / 2
+}
+
+// selfIntersection finds two non-adjacent edges of a closed ring that touch or cross.
+func selfIntersection(ring []Position) (int, int, bool) {
+	n := len(ring) - 1 // Number of edges
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			// Neighbouring edges share an endpoint, including the last and first edges
+			if j == i+1 || (i == 0 && j == n-1) {
+				continue
+			}
+			if segmentsIntersect(ring[i], ring[i+1], ring[j], ring[j+1]) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func segmentsIntersect(a, b, c, d Position) bool {
+	d1 := orientation(c, d, a)
+	d2 := orientation(c, d, b)
+	d3 := orientation(a, b, c)
+	d4 := orientation(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
+
+func orientation(a, b, c Position) float64 {
+	return (b.Lon()-a.Lon())*(c.Lat()-a.Lat()) - (b.Lat()-a.Lat())*(c.Lon()-a.Lon())
+}
+
+// onSegment reports whether c, known to be collinear with a and b, lies between them.
+func onSegment(a, b, c Position) bool {
+	return math.Min(a.Lon(), b.Lon()) <= c.Lon() && c.Lon() <= math.Max(a.Lon(), b.Lon()) &&
+		math.Min(a.Lat(), b.Lat()) <= c.Lat() && c.Lat() <= math.Max(a.Lat(), b.Lat())
+}
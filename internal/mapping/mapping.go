@@ -0,0 +1,225 @@
+/*
+ * mapping.go: Converts provider JSON responses into model records using a declarative MappingConfig.
+ * Usage:
+ *   - Decode(cfg, body) returns *model.WeatherData, *model.SoilData, *model.PestData or
+ *     *model.SatelliteData values, one per record selected by cfg.Root.
+ *   - Each configured field is selected, converted, and placed under the model's JSON field name,
+ *     so a new provider only needs a mapping block in config.yaml.
+ * Example:
+ *   mapping:
+ *     model: weather
+ *     fields:
+ *       temperature: { path: main.temp, convert: kelvinToCelsius }
+ *       humidity: main.humidity
+ *       observation_time: { path: dt, convert: unixTime }
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package mapping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// Target model names accepted in MappingConfig.Model.
+const (
+	ModelWeather   = "weather"
+	ModelSoil      = "soil"
+	ModelPest      = "pest"
+	ModelSatellite = "satellite"
+)
+
+// ErrUnknownModel is returned for a MappingConfig.Model that has no model type.
+var ErrUnknownModel = errors.New("unknown mapping model")
+
+// converters implement the unit and type conversions available to FieldMapping.Convert.
+var converters = map[string]func(interface{}) (interface{}, error){
+	"kelvinToCelsius": func(v interface{}) (interface{}, error) {
+		f, err := toFloat(v)
+		return f - 273.15, err
+	},
+	"fahrenheitToCelsius": func(v interface{}) (interface{}, error) {
+		f, err := toFloat(v)
+		return (f - 32) * 5 / 9, err
+	},
+	"fractionToPercent": func(v interface{}) (interface{}, error) {
+		f, err := toFloat(v)
+		return f * 100, err
+	},
+	"unixTime": func(v interface{}) (interface{}, error) {
+		f, err := toFloat(v)
+		return time.Unix(int64(f), 0).UTC(), err
+	},
+	"unixMillis": func(v interface{}) (interface{}, error) {
+		f, err := toFloat(v)
+		return time.UnixMilli(int64(f)).UTC(), err
+	},
+	"number": func(v interface{}) (interface{}, error) {
+		return toFloat(v)
+	},
+	"string": func(v interface{}) (interface{}, error) {
+		switch t := v.(type) {
+		case string:
+			return t, nil
+		case float64:
+			return strconv.FormatFloat(t, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(t), nil
+		default:
+			b, err := json.Marshal(t)
+			return string(b), err
+		}
+	},
+}
+
+// Validate checks the model name, selectors and conversions without decoding a response.
+func Validate(cfg config.MappingConfig) error {
+	if _, err := newRecord(cfg.Model); err != nil {
+		return err
+	}
+	if cfg.Root != "" {
+		if _, err := parseSelector(cfg.Root); err != nil {
+			return err
+		}
+	}
+	if len(cfg.Fields) == 0 {
+		return errors.New("mapping has no fields")
+	}
+	for target, field := range cfg.Fields {
+		if _, err := parseSelector(field.Path); err != nil {
+			return fmt.Errorf("field %s: %w", target, err)
+		}
+		if field.Convert != "" {
+			if _, ok := converters[field.Convert]; !ok {
+				return fmt.Errorf("field %s: unknown conversion %q", target, field.Convert)
+			}
+		}
+	}
+	return nil
+}
+
+// Decode maps a JSON response body onto records of the configured model type.
+func Decode(cfg config.MappingConfig, body []byte) ([]interface{}, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	sources := []interface{}{doc}
+	if cfg.Root != "" {
+		root, ok, err := selectValue(doc, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("root %q not found in response", cfg.Root)
+		}
+		if arr, isArr := root.([]interface{}); isArr {
+			sources = arr
+		} else {
+			sources = []interface{}{root}
+		}
+	}
+
+	// Apply fields in a stable order so errors are deterministic
+	targets := make([]string, 0, len(cfg.Fields))
+	for target := range cfg.Fields {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	records := make([]interface{}, 0, len(sources))
+	for i, src := range sources {
+		fields := make(map[string]interface{})
+		for _, target := range targets {
+			field := cfg.Fields[target]
+			value, ok, err := selectValue(src, field.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				if field.Optional {
+					continue
+				}
+				return nil, fmt.Errorf("record %d: field %s: %q not found", i, target, field.Path)
+			}
+			if field.Convert != "" {
+				if value, err = converters[field.Convert](value); err != nil {
+					return nil, fmt.Errorf("record %d: field %s: %w", i, target, err)
+				}
+			}
+			setNested(fields, target, value)
+		}
+
+		// Round-trip through JSON so the model's own tags and types decide the result
+		encoded, err := json.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		record, _ := newRecord(cfg.Model)
+		if err := json.Unmarshal(encoded, record); err != nil {
+			return nil, fmt.Errorf("record %d: mapping onto %s: %w", i, cfg.Model, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func newRecord(modelName string) (interface{}, error) {
+	switch modelName {
+	case ModelWeather:
+		return &model.WeatherData{}, nil
+	case ModelSoil:
+		return &model.SoilData{}, nil
+	case ModelPest:
+		return &model.PestData{}, nil
+	case ModelSatellite:
+		return &model.SatelliteData{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownModel, modelName)
+	}
+}
+
+// setNested stores value under a dotted target name, creating intermediate objects.
+func setNested(fields map[string]interface{}, target string, value interface{}) {
+	parts := strings.Split(target, ".")
+	current := fields
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", t)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+}
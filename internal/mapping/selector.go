@@ -0,0 +1,97 @@
+/*
+ * selector.go: Evaluates JSONPath-style selectors against decoded JSON.
+ * Usage:
+ *   - Selectors are dot-separated object keys with optional [n] array indexes and an optional
+ *     leading "$", e.g. main.temp, $.weather[0].description or list[2].main.humidity.
+ *   - Negative indexes count from the end of the array, so hourly[-1] is the last element.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package mapping
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one object key or array index of a parsed selector.
+type step struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseSelector splits a selector into object key and array index steps.
+func parseSelector(selector string) ([]step, error) {
+	s := strings.TrimSpace(selector)
+	s = strings.TrimPrefix(s, "$")
+	s = strings.TrimPrefix(s, ".")
+
+	var steps []step
+	for s != "" {
+		switch {
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("selector %q: unclosed '['", selector)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(s[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("selector %q: invalid index %q", selector, s[1:end])
+			}
+			steps = append(steps, step{index: n, isIndex: true})
+			s = strings.TrimPrefix(s[end+1:], ".")
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("selector %q: empty key", selector)
+			}
+			steps = append(steps, step{key: s[:end]})
+			s = strings.TrimPrefix(s[end:], ".")
+		}
+	}
+	return steps, nil
+}
+
+// selectValue walks doc along the selector. The boolean is false when any step does not match.
+func selectValue(doc interface{}, selector string) (interface{}, bool, error) {
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := doc
+	for _, st := range steps {
+		if st.isIndex {
+			arr, ok := current.([]interface{})
+			if !ok {
+				return nil, false, nil
+			}
+			i := st.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, false, nil
+			}
+			current = arr[i]
+			continue
+		}
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+		if current, ok = obj[st.key]; !ok {
+			return nil, false, nil
+		}
+	}
+	if current == nil {
+		return nil, false, nil
+	}
+	return current, true, nil
+}
@@ -0,0 +1,173 @@
+/*
+ * curve.go: Fits a sugar accumulation curve to Brix readings and projects when it reaches a target.
+ * Usage: Brix rises roughly linearly through ripening and slows as it approaches its plateau. Fit tries
+ *        a quadratic that is still rising at the last reading but bending over, which captures that
+ *        slowdown, and otherwise falls back to a straight line. Days are counted from the first reading.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package maturity
+
+import (
+	"math"
+)
+
+// Curve methods.
+const (
+	MethodLinear    = "linear"
+	MethodQuadratic = "quadratic"
+)
+
+// MaxProjectionDays bounds how far past the last reading a curve is projected.
+const MaxProjectionDays = 90
+
+// minQuadraticDays is the number of distinct sample days needed before a quadratic is tried; with
+// fewer it fits the noise of the readings rather than their trend.
+const minQuadraticDays = 4
+
+// Point is a Brix reading taken Day days after the first one.
+type Point struct {
+	Day  float64
+	Brix float64
+}
+
+// Curve is c[0] + c[1]·d + c[2]·d², with c[2] zero for a straight line.
+type Curve struct {
+	Method       string
+	Coefficients []float64
+	RSquared     float64
+}
+
+// At returns the curve's Brix on day d.
+func (c Curve) At(d float64) float64 {
+	v := 0.0
+	for i := len(c.Coefficients) - 1; i >= 0; i-- {
+		v = v*d + c.Coefficients[i]
+	}
+	return v
+}
+
+// Rate returns the curve's slope in °Brix per day on day d.
+func (c Curve) Rate(d float64) float64 {
+	rate := c.Coefficients[1]
+	if len(c.Coefficients) > 2 {
+		rate += 2 * c.Coefficients[2] * d
+	}
+	return rate
+}
+
+// Fit fits a curve to the readings. It needs readings on at least two different days.
+func Fit(points []Point) (Curve, bool) {
+	days := make(map[float64]bool)
+	last := math.Inf(-1)
+	for _, p := range points {
+		days[p.Day] = true
+		last = math.Max(last, p.Day)
+	}
+	if len(days) < 2 {
+		return Curve{}, false
+	}
+	if len(days) >= minQuadraticDays {
+		if c, ok := leastSquares(points, 3); ok && c[2] < 0 && c[1]+2*c[2]*last > 0 {
+			return Curve{Method: MethodQuadratic, Coefficients: c, RSquared: rSquared(points, c)}, true
+		}
+	}
+	c, ok := leastSquares(points, 2)
+	if !ok {
+		return Curve{}, false
+	}
+	return Curve{Method: MethodLinear, Coefficients: c, RSquared: rSquared(points, c)}, true
+}
+
+// Project returns the first day from day from onwards on which the curve reaches target. It reports
+// false when the curve is not rising towards the target or would take more than MaxProjectionDays.
+func (c Curve) Project(target, from float64) (float64, bool) {
+	if c.At(from) >= target {
+		return from, true
+	}
+	if c.Rate(from) <= 0 {
+		return 0, false
+	}
+	var day float64
+	if len(c.Coefficients) < 3 || c.Coefficients[2] == 0 {
+		day = (target - c.Coefficients[0]) / c.Coefficients[1]
+	} else {
+		// The rising side of a downward parabola is its smaller root
+		a, b, k := c.Coefficients[2], c.Coefficients[1], c.Coefficients[0]-target
+		disc := b*b - 4*a*k
+		if disc < 0 {
+			return 0, false // The curve levels off below the target
+		}
+		day = (-b + math.Sqrt(disc)) / (2 * a)
+	}
+	if day < from || day-from > MaxProjectionDays {
+		return 0, false
+	}
+	return day, true
+}
+
+// leastSquares fits a polynomial with n coefficients by solving the normal equations.
+func leastSquares(points []Point, n int) ([]float64, bool) {
+	// m is the augmented matrix of the normal equations: sums of d^(i+j) and of Brix·d^i
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n+1)
+	}
+	for _, p := range points {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				m[i][j] += math.Pow(p.Day, float64(i+j))
+			}
+			m[i][n] += p.Brix * math.Pow(p.Day, float64(i))
+		}
+	}
+
+	// Gaussian elimination with partial pivoting
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := col + 1; row < n; row++ {
+			f := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= f * m[col][k]
+			}
+		}
+	}
+	c := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		v := m[row][n]
+		for k := row + 1; k < n; k++ {
+			v -= m[row][k] * c[k]
+		}
+		c[row] = v / m[row][row]
+	}
+	return c, true
+}
+
+// rSquared returns the share of the readings' variance the curve explains, 1 when they are all equal.
+func rSquared(points []Point, c []float64) float64 {
+	curve := Curve{Coefficients: c}
+	mean := 0.0
+	for _, p := range points {
+		mean += p.Brix
+	}
+	mean /= float64(len(points))
+	var residual, total float64
+	for _, p := range points {
+		residual += math.Pow(p.Brix-curve.At(p.Day), 2)
+		total += math.Pow(p.Brix-mean, 2)
+	}
+	if total == 0 {
+		return 1
+	}
+	return 1 - residual/total
+}
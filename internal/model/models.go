@@ -9,46 +9,80 @@
 package model
 
 import (
+	"encoding/json"
 	"io"
 	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 )
 
 // Vineyard represents the data model for a vineyard, including its location and soil health.
 type Vineyard struct {
-	ID               int             `json:"id"`
-	Name             string          `json:"name"`
-	Location         string          `json:"location"`    // Consider using a more complex type for geolocation data
-	BoundingBox      string          `json:"boundingBox"` // GeoJSON format for more accurate geospatial representation
-	SoilHealth       []SoilData      `json:"soilHealth"`
-	SatelliteImagery []SatelliteData `json:"satelliteImagery"`
+	ID                 int              `json:"id"`
+	Name               string           `json:"name"`
+	Location           string           `json:"location"`              // Place name, e.g. "Crozet, Virginia"
+	BoundingBox        geo.Polygon      `json:"boundingBox,omitempty"` // GeoJSON Polygon
+	SoilHealth         []SoilData       `json:"soilHealth"`
+	SatelliteImagery   []SatelliteData  `json:"satelliteImagery"`
+	LastSuccessfulSync *time.Time       `json:"lastSuccessfulSync,omitempty"` // Latest successful ingestion run, from ingestion_runs
+	Phenology          []PhenologyStage `json:"phenology,omitempty"`          // Current stage of each block, with environmental data only
+}
+
+// VineyardGeometry holds the values derived from a vineyard's bounding box polygon.
+type VineyardGeometry struct {
+	VineyardID int         `json:"vineyard_id"`
+	Centroid   geo.Point   `json:"centroid"`
+	MinLon     float64     `json:"minLon"`
+	MinLat     float64     `json:"minLat"`
+	MaxLon     float64     `json:"maxLon"`
+	MaxLat     float64     `json:"maxLat"`
+	Polygon    geo.Polygon `json:"polygon"` // The bounding box
+}
+
+// Block is a management unit of a vineyard, planted with one varietal on one trellis system.
+type Block struct {
+	ID             int         `json:"id"`
+	VineyardID     int         `json:"vineyard_id"`
+	Name           string      `json:"name"`
+	Varietal       string      `json:"varietal,omitempty"`
+	Clone          string      `json:"clone,omitempty"`
+	Rootstock      string      `json:"rootstock,omitempty"`
+	PlantingYear   *int        `json:"plantingYear,omitempty"`
+	RowSpacing     *float64    `json:"rowSpacing,omitempty"`     // in metres
+	VineSpacing    *float64    `json:"vineSpacing,omitempty"`    // in metres
+	RowOrientation string      `json:"rowOrientation,omitempty"` // e.g. "N-S" or a bearing such as "15°"
+	TrellisSystem  string      `json:"trellisSystem,omitempty"`  // e.g. "VSP", "Geneva Double Curtain"
+	Boundary       geo.Polygon `json:"boundary,omitempty"`       // GeoJSON Polygon
 }
 
 // Image represents metadata about an image related to a vineyard.
 type Image struct {
-	ID          int       `json:"id"`
-	VineyardID  int       `json:"vineyard_id"`
-	URL         string    `json:"url"`
-	Description string    `json:"description"`
-	CapturedAt  time.Time `json:"capturedAt"`
-	BoundingBox string    `json:"boundingBox"` // GeoJSON format to specify the precise area the image covers
+	ID          int         `json:"id"`
+	VineyardID  int         `json:"vineyard_id"`
+	BlockID     *int        `json:"block_id,omitempty"` // Assigned from the image's centre when not given
+	URL         string      `json:"url"`
+	Description string      `json:"description"`
+	CapturedAt  time.Time   `json:"capturedAt"`
+	BoundingBox geo.Polygon `json:"boundingBox,omitempty"` // GeoJSON Polygon of the area the image covers
 }
 
 // SatelliteData represents the structure of data fetched from the satellite imagery API.
 type SatelliteData struct {
-	ID          int       `json:"id"`
-	VineyardID  int       `json:"vineyard_id"`
-	ImageURL    string    `json:"imageUrl"`
-	CapturedAt  time.Time `json:"capturedAt"`
-	Resolution  float64   `json:"resolution"`  // Resolution of the satellite image in meters
-	BoundingBox string    `json:"boundingBox"` // GeoJSON format to specify the precise area the satellite image covers
-	FilePath    string    `json:"filePath"`    // Local or remote file path of the image for uploading
-	ImageFile   io.Reader `json:"-"`           // The image file data, excluded from JSON operations
+	ID          int         `json:"id"`
+	VineyardID  int         `json:"vineyard_id"`
+	ImageURL    string      `json:"imageUrl"`
+	CapturedAt  time.Time   `json:"capturedAt"`
+	Resolution  float64     `json:"resolution"`            // Resolution of the satellite image in meters
+	BoundingBox geo.Polygon `json:"boundingBox,omitempty"` // GeoJSON Polygon of the area the satellite image covers
+	FilePath    string      `json:"filePath"`              // Local or remote file path of the image for uploading
+	ImageFile   io.Reader   `json:"-"`                     // The image file data, excluded from JSON operations
 }
 
 // SoilData encapsulates soil characteristics fetched from the soil data API.
 type SoilData struct {
 	ID               int     `json:"id"`
 	VineyardID       int     `json:"vineyard_id"`
+	BlockID          *int    `json:"block_id,omitempty"` // Assigned from the location when not given
 	MoistureLevel    float64 `json:"moistureLevel"`
 	NutrientContents struct {
 		Nitrogen   float64 `json:"nitrogen"`
@@ -57,32 +91,660 @@ type SoilData struct {
 	} `json:"nutrientContents"`
 	SoilType  string    `json:"soilType"`
 	SampledAt time.Time `json:"sampledAt"`
-	Location  Location  `json:"location"` // Modified to use a structured type
+	Location  geo.Point `json:"location"` // GeoJSON Point
+}
+
+// MaturitySample records the fruit chemistry of a grape sample taken on one day.
+type MaturitySample struct {
+	ID          int       `json:"id"`
+	VineyardID  int       `json:"vineyard_id"`
+	BlockID     *int      `json:"block_id,omitempty"`
+	SampleDate  time.Time `json:"sampleDate"`
+	Brix        *float64  `json:"brix,omitempty"`        // °Brix
+	PH          *float64  `json:"ph,omitempty"`          // Juice pH
+	TA          *float64  `json:"ta,omitempty"`          // Titratable acidity, g/L as tartaric acid
+	YAN         *float64  `json:"yan,omitempty"`         // Yeast assimilable nitrogen, mg N/L
+	BerryWeight *float64  `json:"berryWeight,omitempty"` // Mean weight of one berry, g
+	Notes       string    `json:"notes,omitempty"`
+	PhotoURL    string    `json:"photoUrl,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// MaturityTrendQuery selects the samples a maturity trend is fitted to. Zero values take defaults.
+type MaturityTrendQuery struct {
+	TargetBrix float64
+	From       time.Time
+	To         time.Time
+}
+
+// MaturityTrend is the Brix trend of each block of a vineyard over a range of days.
+type MaturityTrend struct {
+	VineyardID int                  `json:"vineyard_id"`
+	TargetBrix float64              `json:"targetBrix"`
+	From       time.Time            `json:"from"`
+	To         time.Time            `json:"to"`
+	Blocks     []BlockMaturityTrend `json:"blocks"`
+}
+
+// BlockMaturityTrend fits a curve to a block's Brix readings and projects when it reaches the target.
+// Samples without a block are reported together without a block_id. Coefficients are those of
+// c0 + c1·d + c2·d², where d counts days from FirstSampleDate.
+type BlockMaturityTrend struct {
+	BlockID         *int                 `json:"block_id,omitempty"`
+	BlockName       string               `json:"blockName,omitempty"`
+	Varietal        string               `json:"varietal,omitempty"`
+	Samples         int                  `json:"samples"`
+	FirstSampleDate time.Time            `json:"firstSampleDate"`
+	LatestBrix      float64              `json:"latestBrix"`
+	LatestDate      time.Time            `json:"latestDate"`
+	Method          string               `json:"method,omitempty"` // linear or quadratic; empty when no curve was fitted
+	Coefficients    []float64            `json:"coefficients,omitempty"`
+	RSquared        *float64             `json:"rSquared,omitempty"`
+	BrixPerDay      *float64             `json:"brixPerDay,omitempty"`    // Rate of the curve on LatestDate
+	ReachedOn       *time.Time           `json:"reachedOn,omitempty"`     // First sample at or above the target
+	ProjectedDate   *time.Time           `json:"projectedDate,omitempty"` // When the curve reaches the target
+	Note            string               `json:"note,omitempty"`          // Why there is no projection
+	Points          []MaturityTrendPoint `json:"points"`
+}
+
+// MaturityTrendPoint is one Brix reading with the fitted curve's value on its day.
+type MaturityTrendPoint struct {
+	Date   time.Time `json:"date"`
+	Brix   float64   `json:"brix"`
+	Fitted *float64  `json:"fitted,omitempty"`
+}
+
+// Harvest is one pick of a block, or of a vineyard when no block is named, and its delivery to a winery.
+type Harvest struct {
+	ID                int       `json:"id"`
+	VineyardID        int       `json:"vineyard_id"`
+	BlockID           *int      `json:"block_id,omitempty"`
+	HarvestDate       time.Time `json:"harvestDate"`
+	Tons              float64   `json:"tons"` // Short tons (2,000 lb) delivered
+	Bins              *int      `json:"bins,omitempty"`
+	Crew              string    `json:"crew,omitempty"`
+	DestinationWinery string    `json:"destinationWinery,omitempty"`
+	Brix              *float64  `json:"brix,omitempty"` // °Brix at delivery
+	PH                *float64  `json:"ph,omitempty"`   // Juice pH at delivery
+	Notes             string    `json:"notes,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// PestPressure counts a season's pest observations, without disease model warnings.
+type PestPressure struct {
+	Observations int            `json:"observations"`
+	BySeverity   map[string]int `json:"bySeverity"`
+}
+
+// SoilMoisture summarises a season's soil moisture readings.
+type SoilMoisture struct {
+	Samples int     `json:"samples"`
+	Mean    float64 `json:"mean"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// BlockYield is a block's harvest over a season. Harvests without a block are reported together without
+// a block_id. Acres and Vines come from the block's boundary and row and vine spacing, and are left
+// out with the yields derived from them when the block lacks those.
+type BlockYield struct {
+	BlockID      *int          `json:"block_id,omitempty"`
+	BlockName    string        `json:"blockName,omitempty"`
+	Varietal     string        `json:"varietal,omitempty"`
+	Harvests     int           `json:"harvests"`
+	Tons         float64       `json:"tons"`
+	Bins         int           `json:"bins"`
+	Acres        *float64      `json:"acres,omitempty"`
+	Vines        *int          `json:"vines,omitempty"`
+	TonsPerAcre  *float64      `json:"tonsPerAcre,omitempty"`
+	KgPerVine    *float64      `json:"kgPerVine,omitempty"`
+	Brix         *float64      `json:"brix,omitempty"` // Mean at delivery, weighted by tons
+	PH           *float64      `json:"ph,omitempty"`   // Mean at delivery, weighted by tons
+	PestPressure PestPressure  `json:"pestPressure"`
+	SoilMoisture *SoilMoisture `json:"soilMoisture,omitempty"`
+}
+
+// SeasonYield is a vineyard's harvest over a growing season, with the season's heat, pest pressure and
+// soil moisture. Season is the year the fruit was picked in.
+type SeasonYield struct {
+	VineyardID   int           `json:"vineyard_id"`
+	Season       int           `json:"season"`
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Tons         float64       `json:"tons"`
+	Acres        *float64      `json:"acres,omitempty"`       // Harvested blocks with a known area
+	TonsPerAcre  *float64      `json:"tonsPerAcre,omitempty"` // Over Acres
+	GDD          *float64      `json:"gdd,omitempty"`         // Growing degree days of the season, to today for the current one
+	PestPressure PestPressure  `json:"pestPressure"`
+	SoilMoisture *SoilMoisture `json:"soilMoisture,omitempty"`
+	Blocks       []BlockYield  `json:"blocks"`
+}
+
+// YieldComparison compares a vineyard's seasons. Each correlation is the Pearson coefficient of the
+// seasons' yield, in the unit named by Yield, against one season value; it is left out when fewer than
+// three harvested seasons have that value.
+type YieldComparison struct {
+	VineyardID   int               `json:"vineyard_id"`
+	From         int               `json:"from"`
+	To           int               `json:"to"`
+	Yield        string            `json:"yield"` // tonsPerAcre, or tons when a harvested season has no known area
+	Correlations YieldCorrelations `json:"correlations"`
+	Seasons      []SeasonYield     `json:"seasons"`
+}
+
+// YieldCorrelations relates yield to the season values that may explain it.
+type YieldCorrelations struct {
+	GDD              *float64 `json:"gdd,omitempty"`
+	PestObservations *float64 `json:"pestObservations,omitempty"`
+	SoilMoisture     *float64 `json:"soilMoisture,omitempty"` // Against the season's mean moisture
+}
+
+// Harvest forecast signals, each an independent estimate of the pick date.
+const (
+	ForecastSignalMaturity = "maturity" // The block's Brix trend reaching the target
+	ForecastSignalHeat     = "heat"     // Season-to-date GDD reaching the GDD of past picks
+	ForecastSignalHistory  = "history"  // The day of the season of past picks
+)
+
+// Harvest forecast confidence levels.
+const (
+	ForecastConfidenceHigh   = "high"
+	ForecastConfidenceMedium = "medium"
+	ForecastConfidenceLow    = "low"
+)
+
+// What caused a harvest forecast to be recomputed.
+const (
+	ForecastTriggerWeather  = "weather"
+	ForecastTriggerMaturity = "maturity"
+	ForecastTriggerManual   = "manual"
+)
+
+// ForecastSignal is one estimate of a pick date. SpreadDays is its standard deviation.
+type ForecastSignal struct {
+	Method     string    `json:"method"`
+	Date       time.Time `json:"date"`
+	SpreadDays float64   `json:"spreadDays"`
+	Detail     string    `json:"detail"`
+}
+
+// HarvestForecast estimates when a block, or the vineyard's fruit outside any block, will be picked in a
+// season. The window is the estimate give or take 1.28 combined standard deviations, so roughly four
+// picks in five should fall inside it. A block already picked this season reports HarvestedOn instead.
+// ActualDate, ErrorDays and InWindow are filled in for stored forecasts once the block has been picked.
+type HarvestForecast struct {
+	ID          int              `json:"id,omitempty"`
+	VineyardID  int              `json:"vineyard_id"`
+	BlockID     *int             `json:"block_id,omitempty"`
+	BlockName   string           `json:"blockName,omitempty"`
+	Season      int              `json:"season"`
+	ComputedAt  time.Time        `json:"computedAt"`
+	Trigger     string           `json:"trigger,omitempty"`
+	Estimate    *time.Time       `json:"estimate,omitempty"`
+	WindowStart *time.Time       `json:"windowStart,omitempty"`
+	WindowEnd   *time.Time       `json:"windowEnd,omitempty"`
+	SpreadDays  *float64         `json:"spreadDays,omitempty"` // Combined standard deviation
+	Confidence  string           `json:"confidence,omitempty"`
+	Signals     []ForecastSignal `json:"signals"`
+	HarvestedOn *time.Time       `json:"harvestedOn,omitempty"`
+	Note        string           `json:"note,omitempty"` // Why there is no estimate
+	ActualDate  *time.Time       `json:"actualDate,omitempty"`
+	ErrorDays   *int             `json:"errorDays,omitempty"` // Estimate minus ActualDate
+	InWindow    *bool            `json:"inWindow,omitempty"`
 }
 
-// Location struct to hold geospatial coordinates
-type Location struct {
-	X float64 `json:"longitude"` // longitude
-	Y float64 `json:"latitude"`  // latitude
+// VineyardHarvestForecast is the harvest forecast of each block of a vineyard.
+type VineyardHarvestForecast struct {
+	VineyardID int               `json:"vineyard_id"`
+	Season     int               `json:"season"`
+	ComputedAt time.Time         `json:"computedAt"`
+	Blocks     []HarvestForecast `json:"blocks"`
+}
+
+// ForecastAccuracy summarises how a picked block's stored forecasts compared with its first pick.
+type ForecastAccuracy struct {
+	BlockID          *int      `json:"block_id,omitempty"`
+	ActualDate       time.Time `json:"actualDate"`
+	Forecasts        int       `json:"forecasts"`
+	MeanAbsErrorDays float64   `json:"meanAbsErrorDays"`
+	InWindowShare    float64   `json:"inWindowShare"` // Share of forecasts whose window held ActualDate
+}
+
+// HarvestForecastHistory lists the forecasts stored for a vineyard's season, oldest first.
+type HarvestForecastHistory struct {
+	VineyardID int                `json:"vineyard_id"`
+	Season     int                `json:"season"`
+	Forecasts  []HarvestForecast  `json:"forecasts"`
+	Accuracy   []ForecastAccuracy `json:"accuracy"`
+}
+
+// PhenologyObservation is a growth stage seen in a block, or across the vineyard when no block is given.
+type PhenologyObservation struct {
+	ID          int       `json:"id"`
+	VineyardID  int       `json:"vineyard_id"`
+	BlockID     *int      `json:"block_id,omitempty"`
+	ObservedOn  time.Time `json:"observedOn"`
+	System      string    `json:"system"`             // "el" for modified Eichhorn-Lorenz, the default, or "bbch"
+	Stage       int       `json:"stage"`              // In System
+	ELStage     int       `json:"elStage"`            // Stage normalized to E-L
+	BBCH        int       `json:"bbch"`               // Stage as a BBCH code, the closest one when recorded in E-L
+	Description string    `json:"description"`        // Of the E-L stage
+	ImageID     *int      `json:"image_id,omitempty"` // Photo of the stage, an image of the same vineyard
+	Notes       string    `json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PhenologyStage is the growth stage of a block today: its latest observation this season, advanced by
+// the GDD accumulated since. Without an observation it is estimated from the season start.
+type PhenologyStage struct {
+	BlockID     *int                  `json:"block_id,omitempty"`
+	BlockName   string                `json:"blockName,omitempty"`
+	ELStage     *int                  `json:"elStage,omitempty"` // Unset when there is neither an observation nor heat to estimate from
+	BBCH        *int                  `json:"bbch,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Estimated   bool                  `json:"estimated"`          // Not the observed stage
+	GDDSince    *float64              `json:"gddSince,omitempty"` // Since the observation, or the season start
+	Observation *PhenologyObservation `json:"observation,omitempty"`
+	Note        string                `json:"note,omitempty"` // Why the stage could not be advanced or estimated
+}
+
+// SprayApplication is one pesticide, fungicide or other chemical application to a block, a drawn area,
+// or the whole vineyard when it names neither.
+type SprayApplication struct {
+	ID                 int           `json:"id"`
+	VineyardID         int           `json:"vineyard_id"`
+	BlockID            *int          `json:"block_id,omitempty"` // Assigned from the area when not given
+	Area               geo.Polygon   `json:"area,omitempty"`     // GeoJSON Polygon treated, when not a whole block
+	AppliedAt          time.Time     `json:"appliedAt"`
+	Product            string        `json:"product"`
+	RegistrationNumber string        `json:"registrationNumber,omitempty"` // EPA or other registration number
+	ActiveIngredient   string        `json:"activeIngredient,omitempty"`
+	ResistanceGroups   []string      `json:"resistanceGroups,omitempty"` // FRAC, IRAC or HRAC codes, e.g. "FRAC 3"
+	Rate               float64       `json:"rate"`
+	RateUnit           string        `json:"rateUnit"`         // e.g. "fl oz/acre"
+	Acres              *float64      `json:"acres,omitempty"`  // Area treated; from the area or block boundary when not given
+	Target             string        `json:"target,omitempty"` // Pest or disease treated
+	Applicator         string        `json:"applicator"`
+	REIHours           *float64      `json:"reiHours,omitempty"`         // Restricted-entry interval
+	PHIDays            *int          `json:"phiDays,omitempty"`          // Pre-harvest interval
+	ReentryAt          *time.Time    `json:"reentryAt,omitempty"`        // AppliedAt plus the REI
+	HarvestAllowedOn   *time.Time    `json:"harvestAllowedOn,omitempty"` // First day the PHI allows a harvest
+	Weather            *SprayWeather `json:"weather,omitempty"`
+	ResistanceRisk     bool          `json:"resistanceRisk"` // Shares a resistance group with the previous application
+	ResistanceNote     string        `json:"resistanceNote,omitempty"`
+	Notes              string        `json:"notes,omitempty"`
+	CreatedAt          time.Time     `json:"createdAt"`
+}
+
+// SprayWeather is the weather_data reading nearest a spray application, copied when it is recorded.
+type SprayWeather struct {
+	WeatherID     int       `json:"weather_id"`
+	ObservedAt    time.Time `json:"observedAt"`
+	Temperature   float64   `json:"temperature"`             // in Celsius
+	Humidity      float64   `json:"humidity"`                // percentage
+	WindSpeed     *float64  `json:"windSpeed,omitempty"`     // m/s
+	Precipitation *float64  `json:"precipitation,omitempty"` // mm since the previous reading
 }
 
 // PestData represents data about pest observations within a vineyard.
 type PestData struct {
 	ID              int       `json:"id"`
 	VineyardID      int       `json:"vineyard_id"`
+	BlockID         *int      `json:"block_id,omitempty"` // Assigned from the location when not given
 	Description     string    `json:"description"`
 	Type            string    `json:"type"`
 	Severity        string    `json:"severity"`
 	ObservationDate time.Time `json:"observation_date"`
-	Location        Location  `json:"location"` // Modified to use a structured type
+	Location        geo.Point `json:"location"` // GeoJSON Point
+	Kind            string    `json:"kind"`     // PestKindObservation, or PestKindRisk for disease model warnings
 }
 
+// Pest record kinds.
+const (
+	PestKindObservation = "observation"
+	PestKindRisk        = "risk"
+)
+
 // WeatherData represents weather conditions observed in a vineyard at a specific time.
 type WeatherData struct {
 	ID              int       `json:"id"`
 	VineyardID      int       `json:"vineyard_id"`
-	Temperature     float64   `json:"temperature"` // in Celsius
-	Humidity        float64   `json:"humidity"`    // percentage
+	BlockID         *int      `json:"block_id,omitempty"` // Assigned from the location when not given
+	Temperature     float64   `json:"temperature"`        // in Celsius
+	Humidity        float64   `json:"humidity"`           // percentage
 	ObservationTime time.Time `json:"observation_time"`
-	Location        Location  `json:"location"` // Modified to use a structured type
+	Location        geo.Point `json:"location"`                // GeoJSON Point
+	Precipitation   *float64  `json:"precipitation,omitempty"` // mm since the previous reading
+	LeafWetness     *float64  `json:"leafWetness,omitempty"`   // Minutes of leaf wetness since the previous reading
+	WindSpeed       *float64  `json:"windSpeed,omitempty"`     // m/s
+	DewPoint        *float64  `json:"dewPoint,omitempty"`      // in Celsius
+}
+
+// RecordFilter narrows a vineyard's records for export. Zero values match everything.
+type RecordFilter struct {
+	Start    time.Time // With End, keeps records timestamped BETWEEN Start AND End
+	End      time.Time
+	PestType string // Pest records only
+	Severity string // Pest records only
+	Kind     string // Pest records only: observation or risk
+	Limit    int    // Keeps only the newest records
+}
+
+// NearbyVineyard is a vineyard found by a distance search.
+type NearbyVineyard struct {
+	Vineyard
+	DistanceKm float64 `json:"distanceKm"` // From the search point to the nearest edge of the bounding box, 0 inside it
+}
+
+// SatelliteCoverage is a satellite scene found by a coverage search.
+type SatelliteCoverage struct {
+	SatelliteData
+	CoveragePercent float64 `json:"coveragePercent"` // Share of the vineyard's bounding box inside the scene
+}
+
+// DailyTemperature is a day's temperature range at a vineyard, aggregated from its weather observations.
+type DailyTemperature struct {
+	Date         time.Time `json:"date"`
+	Min          float64   `json:"min"` // in Celsius
+	Max          float64   `json:"max"` // in Celsius
+	Observations int       `json:"observations"`
+}
+
+// DegreeDay is one day of a growing degree day series.
+type DegreeDay struct {
+	DailyTemperature
+	GDD         float64 `json:"gdd"`
+	Accumulated float64 `json:"accumulated"` // Since the season start, 0 before it
+}
+
+// HeatIndex is a heat summation index over its fixed window of the growing season.
+type HeatIndex struct {
+	Value    float64   `json:"value"`
+	Class    string    `json:"class,omitempty"` // Set once the window has ended
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`       // The window end, or the requested end if earlier
+	Days     int       `json:"days"`     // Days with observations
+	Complete bool      `json:"complete"` // The window has ended and every day in it has observations
+}
+
+// GrowingDegreeDays is a vineyard's degree day series with its season's heat summation indices.
+type GrowingDegreeDays struct {
+	VineyardID   int         `json:"vineyard_id"`
+	Method       string      `json:"method"`
+	BaseTemp     float64     `json:"baseTemp"`
+	CapTemp      float64     `json:"capTemp,omitempty"`
+	SeasonStart  time.Time   `json:"seasonStart"`
+	From         time.Time   `json:"from"`
+	To           time.Time   `json:"to"`
+	SeasonToDate float64     `json:"seasonToDate"` // GDD accumulated from SeasonStart to To
+	MissingDays  int         `json:"missingDays"`  // Days from From to To without observations
+	Days         []DegreeDay `json:"days"`
+	Winkler      HeatIndex   `json:"winkler"`
+	Huglin       HeatIndex   `json:"huglin"`
+	BEDD         HeatIndex   `json:"bedd"` // Biologically effective degree days
+}
+
+// GDDQuery selects the days and method of a growing degree day report. Zero values use the defaults.
+type GDDQuery struct {
+	From     time.Time
+	To       time.Time
+	Budbreak time.Time // Season start, instead of 1 April or 1 October
+	Method   string
+	CapTemp  *float64 // 0 disables the cap
+}
+
+// Disease risk levels.
+const (
+	RiskLow      = "low"
+	RiskModerate = "moderate"
+	RiskHigh     = "high"
+)
+
+// DiseaseRisk is a disease model's risk index for a vineyard on one day.
+type DiseaseRisk struct {
+	ID         int                `json:"id"`
+	VineyardID int                `json:"vineyard_id"`
+	Model      string             `json:"model"`
+	Day        time.Time          `json:"day"`
+	Index      float64            `json:"index"`
+	Level      string             `json:"level"`
+	Details    map[string]float64 `json:"details,omitempty"`      // The model's daily inputs and carried state
+	PestDataID *int               `json:"pest_data_id,omitempty"` // Risk record raised when the index crossed the spray threshold
+	ComputedAt time.Time          `json:"computedAt"`
+}
+
+// Alert rule comparisons of a reading with the threshold.
+const (
+	AlertBelow     = "lt"
+	AlertAtOrBelow = "lte"
+	AlertAbove     = "gt"
+	AlertAtOrAbove = "gte"
+)
+
+// Alert severities.
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
+// Alert statuses. Open and acknowledged alerts absorb further triggers of their rule within its cooldown.
+const (
+	AlertStatusOpen         = "open"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertRule is a per-vineyard threshold on a weather reading, e.g. frost after budbreak.
+type AlertRule struct {
+	ID              int       `json:"id"`
+	VineyardID      int       `json:"vineyard_id"`
+	Name            string    `json:"name"`
+	Metric          string    `json:"metric"`               // WeatherData JSON field: temperature, humidity, precipitation, leafWetness, windSpeed or dewPoint
+	Operator        string    `json:"operator"`             // lt, lte, gt or gte
+	Threshold       float64   `json:"threshold"`            // In the metric's unit
+	ActiveFrom      string    `json:"activeFrom,omitempty"` // MM-DD, e.g. "04-01"; with ActiveTo limits the rule to part of the year
+	ActiveTo        string    `json:"activeTo,omitempty"`   // MM-DD, inclusive; may wrap past the new year
+	MinELStage      *int      `json:"minELStage,omitempty"` // Limits the rule to blocks currently at or past this E-L stage
+	MaxELStage      *int      `json:"maxELStage,omitempty"` // Limits the rule to blocks currently at or before this E-L stage
+	CooldownMinutes int       `json:"cooldownMinutes"`      // Quiet period after the last trigger before a new alert is raised
+	Severity        string    `json:"severity"`             // info, warning or critical
+	Recipients      []string  `json:"recipients"`           // Email addresses notified of new alerts
+	Disabled        bool      `json:"disabled,omitempty"`   // Disabled rules are kept but not evaluated
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Alert is raised when a rule fires. Readings that fire the rule again within its cooldown are counted
+// on the same alert instead of raising new ones.
+type Alert struct {
+	ID              int        `json:"id"`
+	RuleID          *int       `json:"ruleId,omitempty"` // Unset once the rule is deleted
+	VineyardID      int        `json:"vineyard_id"`
+	RuleName        string     `json:"ruleName"`
+	Metric          string     `json:"metric"`
+	Operator        string     `json:"operator"`
+	Threshold       float64    `json:"threshold"`
+	Severity        string     `json:"severity"`
+	Message         string     `json:"message"`
+	PeakValue       float64    `json:"peakValue"` // Most extreme reading, the lowest for lt and lte rules
+	TriggerCount    int        `json:"triggerCount"`
+	FirstObservedAt time.Time  `json:"firstObservedAt"`
+	LastObservedAt  time.Time  `json:"lastObservedAt"`
+	WeatherDataID   *int       `json:"weather_data_id,omitempty"` // Reading that raised the alert
+	Status          string     `json:"status"`
+	AcknowledgedAt  *time.Time `json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy  string     `json:"acknowledgedBy,omitempty"`
+	ResolvedAt      *time.Time `json:"resolvedAt,omitempty"`
+	NotifiedAt      *time.Time `json:"notifiedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// NotificationSubscriber receives a vineyard's notification emails of the chosen kinds.
+type NotificationSubscriber struct {
+	ID         int       `json:"id"`
+	VineyardID int       `json:"vineyard_id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name,omitempty"`
+	Events     []string  `json:"events"` // alert.raised, ingestion.failed and report.weekly; all of them when empty
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Outbox message statuses. Pending messages are retried until they are sent or run out of attempts.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxMessage is an email queued for delivery.
+type OutboxMessage struct {
+	ID            int        `json:"id"`
+	Kind          string     `json:"kind"`
+	VineyardID    *int       `json:"vineyard_id,omitempty"`
+	Recipients    []string   `json:"recipients"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"textBody"`
+	HTMLBody      string     `json:"htmlBody,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+}
+
+// Webhook subscribes a URL to data events. Deliveries are signed with Secret, which is never returned.
+type Webhook struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Events      []string  `json:"events"`
+	VineyardID  *int      `json:"vineyard_id,omitempty"` // Only events of this vineyard when set
+	Description string    `json:"description,omitempty"`
+	Disabled    bool      `json:"disabled,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Webhook delivery statuses. Pending deliveries are retried until they succeed or run out of attempts.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook.
+type WebhookDelivery struct {
+	ID             int              `json:"id"`
+	WebhookID      int              `json:"webhookId"`
+	Event          string           `json:"event"`
+	Payload        json.RawMessage  `json:"payload"`
+	Status         string           `json:"status"`
+	Attempts       int              `json:"attempts"`
+	NextAttemptAt  time.Time        `json:"nextAttemptAt"`
+	LastStatusCode *int             `json:"lastStatusCode,omitempty"`
+	LastError      string           `json:"lastError,omitempty"`
+	ReplayOf       *int             `json:"replayOf,omitempty"` // Delivery this one sends again
+	CreatedAt      time.Time        `json:"createdAt"`
+	DeliveredAt    *time.Time       `json:"deliveredAt,omitempty"`
+	AttemptLog     []WebhookAttempt `json:"attemptLog,omitempty"`
+}
+
+// WebhookAttempt records one HTTP request of a delivery.
+type WebhookAttempt struct {
+	ID           int       `json:"id"`
+	DeliveryID   int       `json:"deliveryId"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   *int      `json:"statusCode,omitempty"` // Absent when no response was received
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"responseBody,omitempty"` // Truncated
+	DurationMs   int64     `json:"durationMs"`
+	AttemptedAt  time.Time `json:"attemptedAt"`
+}
+
+// WeeklyReport summarizes a vineyard's records over a week. From and To are calendar days, both included.
+type WeeklyReport struct {
+	VineyardID       int            `json:"vineyard_id"`
+	VineyardName     string         `json:"vineyardName"`
+	From             time.Time      `json:"from"`
+	To               time.Time      `json:"to"`
+	WeatherReadings  int            `json:"weatherReadings"`
+	MinTemperature   *float64       `json:"minTemperature,omitempty"` // in Celsius
+	MaxTemperature   *float64       `json:"maxTemperature,omitempty"` // in Celsius
+	Precipitation    float64        `json:"precipitation"`            // mm
+	PestObservations []PestData     `json:"pestObservations"`
+	RiskWarnings     []PestData     `json:"riskWarnings"` // Pest records raised by the disease models
+	Alerts           []Alert        `json:"alerts"`       // Alerts raised in the week
+	FailedRuns       []IngestionRun `json:"failedRuns"`
+}
+
+// Ingestion run statuses.
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// Ingestion run triggers.
+const (
+	RunTriggerManual    = "manual"
+	RunTriggerScheduled = "scheduled"
+	RunTriggerRetry     = "retry"
+	RunTriggerBackfill  = "backfill"
+)
+
+// IngestionRun records one fetch of a data source, for a single vineyard when the source uses one.
+type IngestionRun struct {
+	ID          int        `json:"id"`
+	SourceKey   string     `json:"sourceKey"`
+	VineyardID  *int       `json:"vineyard_id,omitempty"`
+	Trigger     string     `json:"trigger"`
+	Status      string     `json:"status"`
+	RetryOf     *int       `json:"retryOf,omitempty"` // Run this one repeated
+	WindowStart *time.Time `json:"windowStart,omitempty"`
+	WindowEnd   *time.Time `json:"windowEnd,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Records     int        `json:"records"`
+	HTTPStatus  *int       `json:"httpStatus,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// IngestionRunFilter narrows a listing of ingestion runs. Zero values match everything.
+type IngestionRunFilter struct {
+	SourceKey  string
+	VineyardID int
+	Status     string
+	Trigger    string
+	Since      time.Time // Runs started at or after
+	Until      time.Time // Runs started before
+	Limit      int
+}
+
+// Backfill job statuses. Jobs that are not completed can be resumed from their checkpoint.
+const (
+	BackfillStatusRunning     = "running"
+	BackfillStatusCompleted   = "completed"
+	BackfillStatusFailed      = "failed"
+	BackfillStatusInterrupted = "interrupted"
+)
+
+// BackfillJob tracks the historical ingestion of a data source for one vineyard.
+// Dates are whole UTC days; To is inclusive.
+type BackfillJob struct {
+	ID             int       `json:"id"`
+	SourceKey      string    `json:"sourceKey"`
+	VineyardID     int       `json:"vineyard_id"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	ChunkDays      int       `json:"chunkDays"`
+	Status         string    `json:"status"`
+	Checkpoint     time.Time `json:"checkpoint"` // First day not yet processed
+	WindowsTotal   int       `json:"windowsTotal"`
+	WindowsFetched int       `json:"windowsFetched"`
+	WindowsSkipped int       `json:"windowsSkipped"` // Windows that already had data
+	Records        int       `json:"records"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,38 @@
+/*
+ * memory.go: In-memory email transport.
+ * Usage: Stands in for SMTP in tests and local development; Sent returns every message delivered so far.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport keeps delivered messages in memory. Err, when set, fails every send.
+type MemoryTransport struct {
+	Err error
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (t *MemoryTransport) Send(ctx context.Context, msg Message) error {
+	if t.Err != nil {
+		return t.Err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+// Sent returns a copy of the messages delivered so far.
+func (t *MemoryTransport) Sent() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.sent...)
+}
@@ -0,0 +1,61 @@
+/*
+ * notify.go: Outbound email for alerts, ingestion failures and weekly reports.
+ * Usage: Render fills a message from the templates in templates/. NewTransport builds the SMTP
+ *        transport from notifications.emailService, or returns nil when email is disabled, in which
+ *        case nothing is queued. Transport is an interface so a MemoryTransport or a local SMTP sink
+ *        such as MailHog can stand in for the real server. Queuing and retries live in the
+ *        notification service, which keeps messages in the email_outbox table.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+)
+
+// Message is an email with a plain-text body and an optional HTML alternative.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+	HTML    string
+}
+
+// Transport delivers messages.
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewTransport returns the SMTP transport configured in cfg, or nil when email is disabled.
+func NewTransport(cfg config.EmailServiceConfig) (Transport, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.SMTPHost == "" {
+		return nil, errors.New("SMTPHost is required")
+	}
+	if cfg.SMTPPort <= 0 || cfg.SMTPPort > 65535 {
+		return nil, fmt.Errorf("invalid SMTPPort %d", cfg.SMTPPort)
+	}
+	if _, err := mail.ParseAddress(cfg.FromEmail); err != nil {
+		return nil, fmt.Errorf("invalid FromEmail %q: %v", cfg.FromEmail, err)
+	}
+	return NewSMTPTransport(cfg), nil
+}
+
+// ValidateAddresses checks that every entry is a single email address.
+func ValidateAddresses(addresses []string) error {
+	for _, address := range addresses {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("invalid email address %q", address)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+ * smtp.go: Sends email through the configured SMTP server.
+ * Usage: Port 465 uses implicit TLS; other ports upgrade with STARTTLS when the server offers it.
+ *        Username and Password enable PLAIN authentication, which net/smtp only allows over TLS or
+ *        to localhost.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+)
+
+// smtpTimeout bounds one delivery, from connecting to the end of the message.
+const smtpTimeout = 30 * time.Second
+
+// SMTPTransport sends messages with one SMTP connection each.
+type SMTPTransport struct {
+	cfg config.EmailServiceConfig
+}
+
+func NewSMTPTransport(cfg config.EmailServiceConfig) *SMTPTransport {
+	return &SMTPTransport{cfg: cfg}
+}
+
+// Send delivers msg to all of its recipients.
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(t.cfg.SMTPHost, strconv.Itoa(t.cfg.SMTPPort))
+	tlsConfig := &tls.Config{ServerName: t.cfg.SMTPHost}
+	var conn net.Conn
+	var err error
+	if t.cfg.SMTPPort == 465 {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, t.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("starting SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && t.cfg.SMTPPort != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if t.cfg.Username != "" {
+		auth := smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authenticating with SMTP server: %w", err)
+		}
+	}
+	from, err := mail.ParseAddress(t.cfg.FromEmail)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("adding recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting message: %w", err)
+	}
+	if _, err := w.Write(t.compose(msg)); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose renders msg with its headers and CRLF line endings, as multipart/alternative when it has HTML.
+func (t *SMTPTransport) compose(msg Message) []byte {
+	var b bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+	}
+	header("From", t.cfg.FromEmail)
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	if msg.HTML == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "8bit")
+		b.WriteString("\r\n")
+		b.WriteString(crlf(msg.Body))
+		return b.Bytes()
+	}
+
+	parts := multipart.NewWriter(&b)
+	header("Content-Type", `multipart/alternative; boundary="`+parts.Boundary()+`"`)
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Body},
+		{"text/html", msg.HTML},
+	} {
+		w, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		io.WriteString(w, crlf(part.body))
+	}
+	parts.Close()
+	return b.Bytes()
+}
+
+// crlf normalizes line endings to CRLF.
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
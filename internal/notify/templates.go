@@ -0,0 +1,90 @@
+/*
+ * templates.go: Renders notification email from the embedded templates.
+ * Usage: Each kind of notification has a .txt file defining "<kind>.subject" and "<kind>.text" and an
+ *        .html file defining "<kind>.html". The HTML is rendered with html/template, so values are
+ *        escaped. Times are printed in the zone they carry, so callers convert them first.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// Notification kinds, which are also the events vineyard subscribers choose from.
+const (
+	KindAlertRaised     = "alert.raised"
+	KindIngestionFailed = "ingestion.failed"
+	KindWeeklyReport    = "report.weekly" // Rendered from a *model.WeeklyReport
+)
+
+// Kinds lists every notification kind.
+var Kinds = []string{KindAlertRaised, KindIngestionFailed, KindWeeklyReport}
+
+// AlertData is rendered for KindAlertRaised.
+type AlertData struct {
+	Vineyard        *model.Vineyard
+	Alert           *model.Alert
+	CooldownMinutes int
+}
+
+// IngestionFailureData is rendered for KindIngestionFailed.
+type IngestionFailureData struct {
+	Vineyard *model.Vineyard
+	Run      *model.IngestionRun
+}
+
+//go:embed templates
+var templateFiles embed.FS
+
+var funcs = map[string]interface{}{
+	"date":     func(t time.Time) string { return t.Format("2006-01-02") },
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"deref": func(v interface{}) interface{} {
+		switch p := v.(type) {
+		case *int:
+			return *p
+		case *float64:
+			return *p
+		}
+		return v
+	},
+	"operator": func(op string) string {
+		return map[string]string{"lt": "<", "lte": "<=", "gt": ">", "gte": ">="}[op]
+	},
+}
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.New("").Funcs(funcs).ParseFS(templateFiles, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(funcs).ParseFS(templateFiles, "templates/*.html"))
+)
+
+// Render fills the subject and bodies of a notification of the given kind. The recipients are left
+// for the caller.
+func Render(kind string, data interface{}) (Message, error) {
+	var msg Message
+	var subject, text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, kind+".subject", data); err != nil {
+		return msg, fmt.Errorf("rendering %s subject: %w", kind, err)
+	}
+	if err := textTemplates.ExecuteTemplate(&text, kind+".text", data); err != nil {
+		return msg, fmt.Errorf("rendering %s text: %w", kind, err)
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, kind+".html", data); err != nil {
+		return msg, fmt.Errorf("rendering %s HTML: %w", kind, err)
+	}
+	msg.Subject = strings.Join(strings.Fields(subject.String()), " ")
+	msg.Body = text.String()
+	msg.HTML = html.String()
+	return msg, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * stages.go: Grapevine growth stages in the modified Eichhorn-Lorenz (E-L) and BBCH systems.
+ * Usage: Lookup resolves a stage recorded in either system to its E-L stage, closest BBCH code and
+ *        description, following Coombe (1995). Advance moves a stage forward by growing degree days
+ *        (base 10 °C) using typical intervals from budburst to harvest ripeness; real intervals vary with
+ *        varietal and site, so advanced stages are estimates until observed.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package phenology
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Stage systems.
+const (
+	SystemEL   = "el"
+	SystemBBCH = "bbch"
+)
+
+// Stage is a growth stage. GDD is the typical degree days from budburst, E-L 4, to reach it; stages
+// after harvest ripeness are not reached by degree days and have none.
+type Stage struct {
+	EL          int      `json:"el"`
+	BBCH        int      `json:"bbch"` // The equivalent or closest BBCH code
+	Description string   `json:"description"`
+	GDD         *float64 `json:"gdd,omitempty"`
+}
+
+func gdd(v float64) *float64 { return &v }
+
+// Stages lists the E-L stages in order of development.
+var Stages = []Stage{
+	{1, 0, "Winter bud", gdd(-45)},
+	{2, 1, "Bud scales opening", gdd(-30)},
+	{3, 5, "Woolly bud, green showing", gdd(-15)},
+	{4, 7, "Budburst, leaf tips visible", gdd(0)},
+	{5, 9, "Rosette of leaf tips visible", gdd(15)},
+	{7, 11, "First leaf separated from shoot tip", gdd(40)},
+	{9, 12, "2 to 3 leaves separated, shoots 2-4 cm long", gdd(70)},
+	{10, 13, "3 leaves separated", gdd(90)},
+	{11, 14, "4 leaves separated", gdd(120)},
+	{12, 15, "5 leaves separated, shoots about 10 cm long, inflorescence clear", gdd(150)},
+	{13, 16, "6 leaves separated", gdd(175)},
+	{14, 17, "7 leaves separated", gdd(200)},
+	{15, 18, "8 leaves separated, shoot elongating rapidly, single flowers in compact groups", gdd(220)},
+	{16, 19, "10 leaves separated", gdd(245)},
+	{17, 57, "12 leaves separated, inflorescence well developed, single flowers separated", gdd(280)},
+	{18, 57, "14 leaves separated, flower caps still in place", gdd(315)},
+	{19, 60, "About 16 leaves separated, beginning of flowering", gdd(350)},
+	{20, 61, "10% caps off", gdd(365)},
+	{21, 63, "30% caps off", gdd(380)},
+	{23, 65, "17-20 leaves separated, 50% caps off (flowering)", gdd(400)},
+	{25, 68, "80% caps off", gdd(430)},
+	{26, 69, "Cap-fall complete", gdd(450)},
+	{27, 71, "Setting, young berries enlarging", gdd(480)},
+	{29, 73, "Berries pepper-corn size", gdd(560)},
+	{31, 75, "Berries pea-size", gdd(650)},
+	{32, 77, "Beginning of bunch closure", gdd(730)},
+	{33, 79, "Berries still hard and green", gdd(800)},
+	{34, 81, "Berries begin to soften, sugar starts increasing", gdd(1000)},
+	{35, 83, "Berries begin to colour and enlarge (veraison)", gdd(1050)},
+	{36, 85, "Berries with intermediate sugar values", gdd(1250)},
+	{37, 85, "Berries not quite ripe", gdd(1400)},
+	{38, 89, "Berries harvest-ripe", gdd(1500)},
+	{39, 89, "Berries over-ripe", nil},
+	{41, 91, "After harvest, cane maturation complete", nil},
+	{43, 93, "Beginning of leaf fall", nil},
+	{47, 97, "End of leaf fall", nil},
+}
+
+// bbchToEL maps the BBCH codes used for grapevine to their E-L stage.
+var bbchToEL = map[int]int{
+	0: 1, 1: 2, 3: 2, 5: 3, 7: 4, 8: 4, 9: 5,
+	11: 7, 12: 9, 13: 10, 14: 11, 15: 12, 16: 13, 17: 14, 18: 15, 19: 16,
+	53: 12, 55: 15, 57: 17,
+	60: 19, 61: 20, 62: 20, 63: 21, 64: 21, 65: 23, 66: 23, 67: 25, 68: 25, 69: 26,
+	71: 27, 73: 29, 75: 31, 77: 32, 79: 33,
+	81: 34, 83: 35, 85: 36, 89: 38,
+	91: 41, 92: 41, 93: 43, 95: 43, 97: 47,
+}
+
+// Lookup returns the stage recorded as code in system. A BBCH code keeps its own value rather than the
+// closest code of its E-L stage.
+func Lookup(system string, code int) (Stage, error) {
+	switch system {
+	case SystemEL:
+		if s, ok := ByEL(code); ok {
+			return s, nil
+		}
+		return Stage{}, fmt.Errorf("unknown E-L stage %d", code)
+	case SystemBBCH:
+		el, ok := bbchToEL[code]
+		if !ok {
+			return Stage{}, fmt.Errorf("unknown BBCH stage %02d", code)
+		}
+		s, _ := ByEL(el)
+		s.BBCH = code
+		return s, nil
+	}
+	return Stage{}, fmt.Errorf("unknown stage system %q, expected %s or %s", system, SystemEL, SystemBBCH)
+}
+
+// ByEL returns the E-L stage el.
+func ByEL(el int) (Stage, bool) {
+	i := sort.Search(len(Stages), func(i int) bool { return Stages[i].EL >= el })
+	if i < len(Stages) && Stages[i].EL == el {
+		return Stages[i], true
+	}
+	return Stage{}, false
+}
+
+// Advance returns the stage typically reached gdd degree days after from. Stages without degree days
+// do not advance, and no stage advances past harvest ripeness.
+func Advance(from Stage, gdd float64) Stage {
+	if from.GDD == nil || gdd <= 0 {
+		return from
+	}
+	target := *from.GDD + gdd
+	reached := from
+	for _, s := range Stages {
+		if s.GDD == nil || s.EL <= from.EL {
+			continue
+		}
+		if *s.GDD > target {
+			break
+		}
+		reached = s
+	}
+	return reached
+}
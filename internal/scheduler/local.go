@@ -0,0 +1,259 @@
+/*
+ * local.go: In-process cron scheduler, an alternative to Google Cloud Scheduler.
+ * Usage:
+ *   - Parses each enabled DataSourceConfig.Schedule in its TimeZone and runs the job inside the process.
+ *   - Ingestion sources run through the IngestionService; any other source is called over HTTP,
+ *     the same way a Cloud Scheduler HTTP target would be, with {apiKey} and the date placeholders expanded.
+ *   - A bounded worker pool sized by IngestionSettings.ParallelIngestions limits concurrent runs.
+ *   - HTTP targets use the source's client from internal/transport, so they share its retries and circuit breaker.
+ *   - HTTP target calls are recorded in ingestion_runs without a vineyard; ingestion runs record their own.
+ * Dependencies:
+ *   - github.com/robfig/cron/v3 for cron expression parsing and timing.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	client "github.com/sthompson732/viticulture-harvester-app/internal/clients"
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/service"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
+)
+
+// Ingester runs the in-process ingestion for a data source.
+type Ingester interface {
+	Supports(sourceKey string) bool
+	FetchDataFromSource(ctx context.Context, sourceKey string, opts service.FetchOptions) (*service.IngestionSummary, error)
+}
+
+// LocalScheduler triggers data source jobs from an in-process cron.
+type LocalScheduler struct {
+	Cfg      *config.Config
+	Ingester Ingester
+	Clients  *transport.Registry
+	Runs     service.IngestionRunService
+
+	cron    *cron.Cron
+	queue   chan string
+	workers int
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// cronParser accepts the standard five-field syntax used by Cloud Scheduler, plus descriptors like @hourly.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// NewLocalScheduler registers a cron entry for every enabled data source.
+func NewLocalScheduler(cfg *config.Config, ingester Ingester, clients *transport.Registry,
+	runs service.IngestionRunService) (*LocalScheduler, error) {
+	workers := cfg.IngestionSettings.ParallelIngestions
+	if workers <= 0 {
+		workers = 1
+	}
+
+	ls := &LocalScheduler{
+		Cfg:      cfg,
+		Ingester: ingester,
+		Clients:  clients,
+		Runs:     runs,
+		cron:     cron.New(cron.WithParser(cronParser)),
+		queue:    make(chan string, len(cfg.DataSources)),
+		workers:  workers,
+		inFlight: make(map[string]bool),
+	}
+
+	// Register in a stable order so log output and errors are deterministic
+	keys := make([]string, 0, len(cfg.DataSources))
+	for key := range cfg.DataSources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		source := cfg.DataSources[key]
+		if !source.Enabled {
+			continue
+		}
+		schedule, err := ParseSchedule(source.Schedule, source.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("data source %s: %w", key, err)
+		}
+		sourceKey := key
+		ls.cron.Schedule(schedule, cron.FuncJob(func() { ls.enqueue(sourceKey) }))
+		log.Printf("Scheduled %s locally: %q %s", key, source.Schedule, source.TimeZone)
+	}
+	return ls, nil
+}
+
+// ParseSchedule parses a five-field cron expression evaluated in the given IANA time zone.
+func ParseSchedule(spec, timeZone string) (cron.Schedule, error) {
+	loc := time.UTC
+	if timeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+		}
+	}
+	schedule, err := cronParser.Parse(strings.TrimSpace(spec))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	if s, ok := schedule.(*cron.SpecSchedule); ok {
+		s.Location = loc
+	}
+	return schedule, nil
+}
+
+// Start launches the worker pool and the cron loop. It returns immediately.
+func (ls *LocalScheduler) Start(ctx context.Context) {
+	ctx, ls.cancel = context.WithCancel(ctx)
+	for i := 0; i < ls.workers; i++ {
+		ls.wg.Add(1)
+		go ls.worker(ctx)
+	}
+	ls.cron.Start()
+	log.Printf("Local scheduler started with %d workers", ls.workers)
+}
+
+// Stop halts the cron loop and waits for running jobs to finish.
+func (ls *LocalScheduler) Stop() {
+	<-ls.cron.Stop().Done()
+	if ls.cancel != nil {
+		ls.cancel()
+	}
+	ls.wg.Wait()
+}
+
+// enqueue hands a due job to the worker pool, skipping it if a previous run has not finished.
+func (ls *LocalScheduler) enqueue(sourceKey string) {
+	ls.mu.Lock()
+	if ls.inFlight[sourceKey] {
+		ls.mu.Unlock()
+		log.Printf("Skipping %s: previous run still in progress", sourceKey)
+		return
+	}
+	ls.inFlight[sourceKey] = true
+	ls.mu.Unlock()
+
+	// The queue holds one slot per data source and each source is queued at most once
+	ls.queue <- sourceKey
+}
+
+func (ls *LocalScheduler) worker(ctx context.Context) {
+	defer ls.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sourceKey := <-ls.queue:
+			ls.RunNow(ctx, sourceKey)
+			ls.mu.Lock()
+			delete(ls.inFlight, sourceKey)
+			ls.mu.Unlock()
+		}
+	}
+}
+
+// RunNow executes the job for a data source synchronously.
+func (ls *LocalScheduler) RunNow(ctx context.Context, sourceKey string) error {
+	source, ok := ls.Cfg.DataSources[sourceKey]
+	if !ok {
+		return fmt.Errorf("data source %s not configured", sourceKey)
+	}
+
+	start := time.Now()
+	var err error
+	if ls.Ingester != nil && ls.Ingester.Supports(sourceKey) {
+		var summary *service.IngestionSummary
+		summary, err = ls.Ingester.FetchDataFromSource(ctx, sourceKey, service.FetchOptions{
+			Date:    start.UTC(),
+			Trigger: model.RunTriggerScheduled,
+		})
+		if err == nil {
+			log.Printf("Scheduled job %s: %d vineyards succeeded, %d failed, %d records",
+				sourceKey, summary.Succeeded, summary.Failed, summary.Records)
+			for _, result := range summary.Vineyards {
+				if result.Error != "" {
+					log.Printf("Scheduled job %s: vineyard %d: %s", sourceKey, result.VineyardID, result.Error)
+				}
+			}
+			if summary.Failed > 0 && summary.Succeeded == 0 {
+				err = fmt.Errorf("all %d vineyards failed", summary.Failed)
+			}
+		}
+	} else {
+		err = ls.runHTTPTarget(ctx, sourceKey, source, start.UTC())
+	}
+	if err != nil {
+		log.Printf("Scheduled job %s failed after %s: %v", sourceKey, time.Since(start).Round(time.Millisecond), err)
+		return err
+	}
+	log.Printf("Scheduled job %s completed in %s", sourceKey, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// runHTTPTarget calls an HTTP target inside a recorded ingestion run.
+func (ls *LocalScheduler) runHTTPTarget(ctx context.Context, sourceKey string, source config.DataSourceConfig,
+	runDate time.Time) error {
+	run := &model.IngestionRun{
+		SourceKey: sourceKey,
+		Trigger:   model.RunTriggerScheduled,
+		StartedAt: runDate,
+	}
+	if err := ls.Runs.StartRun(ctx, run); err != nil {
+		// Losing the history entry should not stop the job itself
+		log.Printf("Scheduled job %s: recording ingestion run: %v", sourceKey, err)
+		run = nil
+	}
+
+	httpStatus, err := ls.callHTTPTarget(ctx, sourceKey, source, runDate)
+	if run != nil {
+		if finishErr := ls.Runs.FinishRun(context.WithoutCancel(ctx), run, 0, httpStatus, err); finishErr != nil {
+			log.Printf("Scheduled job %s: recording end of ingestion run %d: %v", sourceKey, run.ID, finishErr)
+		}
+	}
+	return err
+}
+
+// callHTTPTarget sends the request a Cloud Scheduler HTTP target would send and returns the response status.
+func (ls *LocalScheduler) callHTTPTarget(ctx context.Context, sourceKey string, source config.DataSourceConfig,
+	runDate time.Time) (int, error) {
+	vars, err := client.NewEndpointVars(source, runDate)
+	if err != nil {
+		return 0, err
+	}
+	endpoint, err := client.ExpandEndpoint(source.Endpoint, vars)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := client.NewSourceRequest(ctx, source, endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := ls.Clients.Client(sourceKey).Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("calling %s: %w", source.Endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("calling %s: unexpected status %s", source.Endpoint, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
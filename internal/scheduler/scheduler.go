@@ -1,9 +1,12 @@
 /*
  * scheduler.go: Orchestrates timed data fetching tasks using Google Cloud Scheduler.
  * Usage:
- *   - Configures and initiates scheduled jobs that trigger data retrieval and processing tasks.
+ *   - Reconciles the Cloud Scheduler jobs under the project/location with Config.DataSources,
+ *     creating, updating and deleting jobs so repeated deploys are idempotent.
  *   - Ensures tasks are executed at specified intervals, handling retries and logging as necessary.
  *   - Utilizes cron syntax to define job schedules.
+ *   - Endpoints whose templates only use {apiKey} are called directly; templates with per-run placeholders
+ *     ({date}, {lat}, ...) are routed through the harvester's /fetch-data/{source} at App.PublicURL.
 * Dependencies:
  *   - Requires external scheduling APIs or local cron services.
  *   - Interacts with client modules (e.g., satellite.go, weather.go, soil.go) to set up data fetch operations.
@@ -17,12 +20,32 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 
 	scheduler "cloud.google.com/go/scheduler/apiv1"
 	"cloud.google.com/go/scheduler/apiv1/schedulerpb"
+	client "github.com/sthompson732/viticulture-harvester-app/internal/clients"
 	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// JobNamePrefix marks the Cloud Scheduler jobs owned by the harvester. Jobs without it are never touched.
+const JobNamePrefix = "harvester-"
+
+// Reconciliation actions reported in a ReconcileReport.
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+	ActionSkip      = "skip" // The job could not be built from its data source config
 )
 
 type SchedulerClient struct {
@@ -30,8 +53,28 @@ type SchedulerClient struct {
 	Cfg    *config.Config
 }
 
+// JobChange describes what reconciliation does, or would do, to a single job.
+type JobChange struct {
+	Action    string   `json:"action"`
+	JobName   string   `json:"jobName"`
+	SourceKey string   `json:"sourceKey,omitempty"`
+	Changes   []string `json:"changes,omitempty"` // Fields that differ, for updates
+	Error     string   `json:"error,omitempty"`
+}
+
+// ReconcileReport lists the outcome for every job considered during reconciliation.
+type ReconcileReport struct {
+	DryRun bool        `json:"dryRun"`
+	Jobs   []JobChange `json:"jobs"`
+}
+
 func NewSchedulerClient(ctx context.Context, cfg *config.Config) (*SchedulerClient, error) {
-	client, err := scheduler.NewCloudSchedulerClient(ctx, option.WithCredentialsFile(cfg.CloudStorage.CredentialsPath))
+	return NewSchedulerClientWithOptions(ctx, cfg, option.WithCredentialsFile(cfg.CloudStorage.CredentialsPath))
+}
+
+// NewSchedulerClientWithOptions creates a client with explicit connection options, e.g. to reach a fake server.
+func NewSchedulerClientWithOptions(ctx context.Context, cfg *config.Config, opts ...option.ClientOption) (*SchedulerClient, error) {
+	client, err := scheduler.NewCloudSchedulerClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create scheduler client: %v", err)
 	}
@@ -41,27 +84,214 @@ func NewSchedulerClient(ctx context.Context, cfg *config.Config) (*SchedulerClie
 	}, nil
 }
 
+// SetupJobs brings the Cloud Scheduler jobs in line with the configured data sources.
 func (sc *SchedulerClient) SetupJobs(ctx context.Context) error {
-	for _, jobCfg := range sc.Cfg.DataSources {
-		if jobCfg.Enabled {
-			err := sc.createJob(ctx, jobCfg)
-			if err != nil {
-				log.Printf("Failed to create job for %s: %v", jobCfg.Description, err)
-				continue
-			}
-			log.Printf("Successfully scheduled job: %s", jobCfg.Description)
+	report, err := sc.Reconcile(ctx, false)
+	if err != nil {
+		return err
+	}
+	for _, job := range report.Jobs {
+		if job.Error != "" {
+			log.Printf("Scheduler job %s (%s) failed: %s", job.JobName, job.Action, job.Error)
+			continue
+		}
+		if job.Action != ActionUnchanged {
+			log.Printf("Scheduler job %s: %s", job.JobName, job.Action)
 		}
 	}
 	return nil
 }
 
-func (sc *SchedulerClient) createJob(ctx context.Context, jobCfg config.DataSourceConfig) error {
-	parent := fmt.Sprintf("projects/%s/locations/%s", sc.Cfg.ProjectID, sc.Cfg.LocationID)
+// Reconcile diffs the desired jobs from Config.DataSources against the jobs under the
+// project/location and creates, updates or deletes jobs to match. With dryRun set it only
+// reports the changes it would make.
+func (sc *SchedulerClient) Reconcile(ctx context.Context, dryRun bool) (*ReconcileReport, error) {
+	desired, invalid := sc.desiredJobs()
+	existing, err := sc.listJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sources whose job cannot be built are reported and their existing jobs are left alone
+	report := &ReconcileReport{DryRun: dryRun, Jobs: invalid}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		want := desired[name]
+		change := JobChange{JobName: name, SourceKey: want.sourceKey}
+		var opErr error
+		if have, ok := existing[name]; !ok {
+			change.Action = ActionCreate
+			if !dryRun {
+				_, opErr = sc.Client.CreateJob(ctx, &schedulerpb.CreateJobRequest{Parent: sc.parent(), Job: want.job})
+			}
+		} else if change.Changes = diffJobs(have, want.job); len(change.Changes) == 0 {
+			change.Action = ActionUnchanged
+		} else {
+			change.Action = ActionUpdate
+			if !dryRun {
+				_, opErr = sc.Client.UpdateJob(ctx, &schedulerpb.UpdateJobRequest{
+					Job:        want.job,
+					UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"description", "schedule", "time_zone", "http_target"}},
+				})
+			}
+		}
+		if opErr != nil {
+			change.Error = opErr.Error()
+		}
+		report.Jobs = append(report.Jobs, change)
+	}
+
+	// Anything we own that is no longer desired belongs to a removed or disabled data source
+	var stale []string
+	keep := make(map[string]bool)
+	for _, change := range invalid {
+		keep[change.JobName] = true
+	}
+	for name := range existing {
+		if _, ok := desired[name]; !ok && !keep[name] {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	for _, name := range stale {
+		change := JobChange{Action: ActionDelete, JobName: name}
+		if !dryRun {
+			if err := sc.DeleteJob(ctx, name); err != nil {
+				change.Error = err.Error()
+			}
+		}
+		report.Jobs = append(report.Jobs, change)
+	}
+
+	return report, nil
+}
+
+type desiredJob struct {
+	sourceKey string
+	job       *schedulerpb.Job
+}
+
+// desiredJobs builds the job definition for every enabled data source, keyed by full job name.
+// Sources whose job cannot be built are returned as failed changes.
+func (sc *SchedulerClient) desiredJobs() (map[string]desiredJob, []JobChange) {
+	keys := make([]string, 0, len(sc.Cfg.DataSources))
+	for key := range sc.Cfg.DataSources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	desired := make(map[string]desiredJob)
+	var invalid []JobChange
+	for _, key := range keys {
+		jobCfg := sc.Cfg.DataSources[key]
+		if !jobCfg.Enabled {
+			continue
+		}
+		job, err := sc.buildJob(key, jobCfg)
+		if err != nil {
+			invalid = append(invalid, JobChange{
+				Action:    ActionSkip,
+				JobName:   sc.jobName(key),
+				SourceKey: key,
+				Error:     err.Error(),
+			})
+			continue
+		}
+		desired[job.Name] = desiredJob{sourceKey: key, job: job}
+	}
+	return desired, invalid
+}
+
+// listJobs returns the harvester-owned jobs under the project/location, keyed by full job name.
+func (sc *SchedulerClient) listJobs(ctx context.Context) (map[string]*schedulerpb.Job, error) {
+	prefix := sc.parent() + "/jobs/" + JobNamePrefix
+	jobs := make(map[string]*schedulerpb.Job)
+	it := sc.Client.ListJobs(ctx, &schedulerpb.ListJobsRequest{Parent: sc.parent()})
+	for {
+		job, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scheduler jobs: %v", err)
+		}
+		if strings.HasPrefix(job.Name, prefix) {
+			jobs[job.Name] = job
+		}
+	}
+	return jobs, nil
+}
+
+func (sc *SchedulerClient) parent() string {
+	return fmt.Sprintf("projects/%s/locations/%s", sc.Cfg.ProjectID, sc.Cfg.LocationID)
+}
+
+func (sc *SchedulerClient) jobName(sourceKey string) string {
+	return fmt.Sprintf("%s/jobs/%s", sc.parent(), formatJobName(sourceKey))
+}
+
+func (sc *SchedulerClient) buildJob(sourceKey string, jobCfg config.DataSourceConfig) (*schedulerpb.Job, error) {
+	var httpTarget *schedulerpb.HttpTarget
+	var err error
+	if needsRunTimeExpansion(jobCfg.Endpoint) {
+		httpTarget, err = sc.fetchDataTarget(sourceKey)
+	} else {
+		httpTarget, err = providerTarget(jobCfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// OAuthToken and OidcToken should be set if needed here
+
+	return &schedulerpb.Job{
+		Name:        sc.jobName(sourceKey),
+		Description: jobCfg.Description,
+		Target:      &schedulerpb.Job_HttpTarget{HttpTarget: httpTarget},
+		Schedule:    jobCfg.Schedule,
+		TimeZone:    jobCfg.TimeZone,
+	}, nil
+}
+
+// needsRunTimeExpansion reports whether the endpoint has placeholders other than {apiKey}, which
+// Cloud Scheduler cannot fill because they depend on the run date or the vineyard.
+func needsRunTimeExpansion(endpoint string) bool {
+	for _, name := range client.Placeholders(endpoint) {
+		if name != client.PlaceholderAPIKey {
+			return true
+		}
+	}
+	return false
+}
+
+// providerTarget calls the data source endpoint directly. Sources without an httpMethod use GET, as the
+// local scheduler does.
+func providerTarget(jobCfg config.DataSourceConfig) (*schedulerpb.HttpTarget, error) {
+	httpMethod := jobCfg.HttpMethod
+	if httpMethod == "" {
+		httpMethod = http.MethodGet
+	}
+	method, ok := schedulerpb.HttpMethod_value[strings.ToUpper(httpMethod)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported HTTP method %q", jobCfg.HttpMethod)
+	}
+
+	uri, err := client.ExpandEndpoint(jobCfg.Endpoint, client.EndpointVars{APIKey: jobCfg.APIKey})
+	if err != nil {
+		return nil, err
+	}
+	uri = client.AppendParams(uri, jobCfg.Params)
 
 	// Build the HTTP target based on the new documentation
 	httpTarget := &schedulerpb.HttpTarget{
-		Uri:        jobCfg.Endpoint,
-		HttpMethod: schedulerpb.HttpMethod(schedulerpb.HttpMethod_value[jobCfg.HttpMethod]),
+		Uri:        uri,
+		HttpMethod: schedulerpb.HttpMethod(method),
 	}
 
 	// Add headers if any
@@ -70,40 +300,96 @@ func (sc *SchedulerClient) createJob(ctx context.Context, jobCfg config.DataSour
 	}
 
 	// Set the body if the method is POST, PUT, or PATCH
-	if jobCfg.HttpMethod == "POST" || jobCfg.HttpMethod == "PUT" || jobCfg.HttpMethod == "PATCH" {
+	if httpTarget.HttpMethod == schedulerpb.HttpMethod_POST || httpTarget.HttpMethod == schedulerpb.HttpMethod_PUT ||
+		httpTarget.HttpMethod == schedulerpb.HttpMethod_PATCH {
 		httpTarget.Body = []byte(jobCfg.Body)
 	}
+	return httpTarget, nil
+}
+
+// fetchDataTarget calls the harvester's own /fetch-data endpoint, which expands the template at run time.
+func (sc *SchedulerClient) fetchDataTarget(sourceKey string) (*schedulerpb.HttpTarget, error) {
+	if sc.Cfg.App.PublicURL == "" {
+		return nil, fmt.Errorf("endpoint uses per-run placeholders; set app.publicURL so the job can call /fetch-data")
+	}
+	httpTarget := &schedulerpb.HttpTarget{
+		Uri:        strings.TrimRight(sc.Cfg.App.PublicURL, "/") + "/fetch-data/" + url.PathEscape(sourceKey) + "?trigger=" + model.RunTriggerScheduled,
+		HttpMethod: schedulerpb.HttpMethod_GET,
+	}
+	if len(sc.Cfg.ValidAPIKeys) > 0 {
+		httpTarget.Headers = map[string]string{"X-API-Key": sc.Cfg.ValidAPIKeys[0]}
+	}
+	return httpTarget, nil
+}
 
-	// OAuthToken and OidcToken should be set if needed here
+// serverManagedHeaders are added by Cloud Scheduler itself and ignored when comparing jobs.
+var serverManagedHeaders = map[string]bool{"user-agent": true, "content-type": true}
 
-	job := &schedulerpb.Job{
-		Name:     fmt.Sprintf("%s/jobs/%s", parent, formatJobName(jobCfg.Description)),
-		Target:   &schedulerpb.Job_HttpTarget{HttpTarget: httpTarget},
-		Schedule: jobCfg.Schedule,
-		TimeZone: jobCfg.TimeZone,
+// diffJobs lists the configurable fields that differ between an existing and a desired job.
+func diffJobs(have, want *schedulerpb.Job) []string {
+	var changes []string
+	if have.Description != want.Description {
+		changes = append(changes, "description")
+	}
+	if have.Schedule != want.Schedule {
+		changes = append(changes, "schedule")
+	}
+	if have.TimeZone != want.TimeZone {
+		changes = append(changes, "time_zone")
 	}
 
-	// Use the CreateJob method of the Cloud Scheduler client
-	_, err := sc.Client.CreateJob(ctx, &schedulerpb.CreateJobRequest{
-		Parent: parent,
-		Job:    job,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create job for %s: %v", jobCfg.Description, err)
+	haveTarget, wantTarget := have.GetHttpTarget(), want.GetHttpTarget()
+	if haveTarget == nil {
+		return append(changes, "http_target")
 	}
-	return nil
+	if haveTarget.Uri != wantTarget.Uri {
+		changes = append(changes, "http_target.uri")
+	}
+	if haveTarget.HttpMethod != wantTarget.HttpMethod {
+		changes = append(changes, "http_target.http_method")
+	}
+	if string(haveTarget.Body) != string(wantTarget.Body) {
+		changes = append(changes, "http_target.body")
+	}
+	if !sameHeaders(haveTarget.Headers, wantTarget.Headers) {
+		changes = append(changes, "http_target.headers")
+	}
+	return changes
 }
 
-func buildQueryParams(params map[string]string) string {
-	var parts []string
-	for key, value := range params {
-		parts = append(parts, fmt.Sprintf("%s=%s", key, value))
+func sameHeaders(have, want map[string]string) bool {
+	normalize := func(headers map[string]string, keep map[string]string) map[string]string {
+		out := make(map[string]string)
+		for k, v := range headers {
+			lk := strings.ToLower(k)
+			if _, explicit := keep[lk]; serverManagedHeaders[lk] && !explicit {
+				continue
+			}
+			out[lk] = v
+		}
+		return out
+	}
+	wantLower := make(map[string]string)
+	for k, v := range want {
+		wantLower[strings.ToLower(k)] = v
+	}
+	h, w := normalize(have, wantLower), normalize(want, wantLower)
+	if len(h) != len(w) {
+		return false
 	}
-	return strings.Join(parts, "&")
+	for k, v := range w {
+		if h[k] != v {
+			return false
+		}
+	}
+	return true
 }
 
-func formatJobName(description string) string {
-	return strings.ReplaceAll(strings.ToLower(description), " ", "-")
+var invalidJobIDChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// formatJobName derives a valid Cloud Scheduler job ID from a data source key.
+func formatJobName(sourceKey string) string {
+	return JobNamePrefix + strings.Trim(invalidJobIDChars.ReplaceAllString(strings.ToLower(sourceKey), "-"), "-")
 }
 
 func (sc *SchedulerClient) DeleteJob(ctx context.Context, jobName string) error {
@@ -0,0 +1,228 @@
+package scheduler_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/scheduler/apiv1/schedulerpb"
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/scheduler"
+	"github.com/sthompson732/viticulture-harvester-app/internal/scheduler/schedulertest"
+)
+
+const parent = "projects/test-project/locations/us-east1"
+
+func testConfig() *config.Config {
+	return &config.Config{
+		App:          config.AppConfig{PublicURL: "https://harvester.example.com"},
+		ProjectID:    "test-project",
+		LocationID:   "us-east1",
+		ValidAPIKeys: []string{"test-key"},
+		DataSources: map[string]config.DataSourceConfig{
+			"healthCheck": {
+				Enabled:     true,
+				Schedule:    "*/15 * * * *",
+				TimeZone:    "UTC",
+				Endpoint:    "https://example.com/health?key={apiKey}",
+				APIKey:      "secret",
+				Description: "Health check",
+			},
+			"satellite": {
+				Enabled:    true,
+				Schedule:   "0 */6 * * *",
+				TimeZone:   "UTC",
+				HttpMethod: "GET",
+				Endpoint:   "https://example.com/imagery?lat={lat}&lon={lon}&date={date}",
+			},
+			"retired": {
+				Enabled:  false,
+				Schedule: "0 0 * * *",
+				Endpoint: "https://example.com/retired",
+			},
+		},
+	}
+}
+
+func newTestClient(t *testing.T, cfg *config.Config) (*scheduler.SchedulerClient, *schedulertest.FakeServer) {
+	t.Helper()
+	fake, err := schedulertest.NewFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fake.Close)
+	sc, err := scheduler.NewSchedulerClientWithOptions(context.Background(), cfg, fake.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sc.Client.Close() })
+	return sc, fake
+}
+
+// actions maps each job name in the report to its action.
+func actions(t *testing.T, report *scheduler.ReconcileReport) map[string]string {
+	t.Helper()
+	got := make(map[string]string)
+	for _, job := range report.Jobs {
+		if job.Error != "" {
+			t.Errorf("job %s (%s): %s", job.JobName, job.Action, job.Error)
+		}
+		got[job.JobName] = job.Action
+	}
+	return got
+}
+
+func jobsByName(fake *schedulertest.FakeServer) map[string]*schedulerpb.Job {
+	jobs := make(map[string]*schedulerpb.Job)
+	for _, job := range fake.Jobs() {
+		jobs[job.Name] = job
+	}
+	return jobs
+}
+
+func TestReconcileCreatesJobs(t *testing.T) {
+	sc, fake := newTestClient(t, testConfig())
+	ctx := context.Background()
+
+	report, err := sc.Reconcile(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		parent + "/jobs/harvester-healthcheck": scheduler.ActionCreate,
+		parent + "/jobs/harvester-satellite":   scheduler.ActionCreate,
+	}
+	if got := actions(t, report); !reflect.DeepEqual(got, want) {
+		t.Fatalf("actions = %v, want %v", got, want)
+	}
+
+	jobs := jobsByName(fake)
+	health := jobs[parent+"/jobs/harvester-healthcheck"].GetHttpTarget()
+	if health.Uri != "https://example.com/health?key=secret" || health.HttpMethod != schedulerpb.HttpMethod_GET {
+		t.Errorf("health check target = %s %s, want GET with the API key filled in", health.HttpMethod, health.Uri)
+	}
+	satellite := jobs[parent+"/jobs/harvester-satellite"].GetHttpTarget()
+	if satellite.Uri != "https://harvester.example.com/fetch-data/satellite?trigger=scheduled" {
+		t.Errorf("satellite target = %s, want the harvester's /fetch-data", satellite.Uri)
+	}
+	if satellite.Headers["X-API-Key"] != "test-key" {
+		t.Errorf("satellite headers = %v, want the harvester API key", satellite.Headers)
+	}
+
+	// A second run finds nothing to do
+	calls := len(fake.Calls())
+	report, err = sc.Reconcile(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, action := range actions(t, report) {
+		if action != scheduler.ActionUnchanged {
+			t.Errorf("second run: %s = %s, want %s", name, action, scheduler.ActionUnchanged)
+		}
+	}
+	if extra := fake.Calls()[calls:]; len(extra) != 0 {
+		t.Errorf("second run made calls %v", extra)
+	}
+}
+
+func TestReconcileUpdatesChangedJobs(t *testing.T) {
+	cfg := testConfig()
+	sc, fake := newTestClient(t, cfg)
+	ctx := context.Background()
+	if _, err := sc.Reconcile(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	health := cfg.DataSources["healthCheck"]
+	health.Schedule = "*/5 * * * *"
+	health.Endpoint = "https://example.com/v2/health?key={apiKey}"
+	cfg.DataSources["healthCheck"] = health
+
+	report, err := sc.Reconcile(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := parent + "/jobs/harvester-healthcheck"
+	for _, change := range report.Jobs {
+		if change.JobName != name {
+			continue
+		}
+		if change.Action != scheduler.ActionUpdate {
+			t.Fatalf("action = %s, want %s", change.Action, scheduler.ActionUpdate)
+		}
+		if want := []string{"schedule", "http_target.uri"}; !reflect.DeepEqual(change.Changes, want) {
+			t.Errorf("changes = %v, want %v", change.Changes, want)
+		}
+	}
+
+	job := jobsByName(fake)[name]
+	if job.Schedule != "*/5 * * * *" || job.GetHttpTarget().Uri != "https://example.com/v2/health?key=secret" {
+		t.Errorf("job = %s %s, want the new schedule and URI", job.Schedule, job.GetHttpTarget().Uri)
+	}
+}
+
+func TestReconcileDeletesOnlyStaleHarvesterJobs(t *testing.T) {
+	sc, fake := newTestClient(t, testConfig())
+	stale := &schedulerpb.Job{Name: parent + "/jobs/harvester-retired", Schedule: "0 0 * * *"}
+	foreign := &schedulerpb.Job{Name: parent + "/jobs/nightly-backup", Schedule: "0 3 * * *"}
+	fake.PutJob(stale)
+	fake.PutJob(foreign)
+
+	report, err := sc.Reconcile(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := actions(t, report)
+	if got[stale.Name] != scheduler.ActionDelete {
+		t.Errorf("%s = %q, want %s", stale.Name, got[stale.Name], scheduler.ActionDelete)
+	}
+	if action, ok := got[foreign.Name]; ok {
+		t.Errorf("foreign job was reported as %s", action)
+	}
+
+	jobs := jobsByName(fake)
+	if _, ok := jobs[stale.Name]; ok {
+		t.Errorf("stale job %s was not deleted", stale.Name)
+	}
+	if _, ok := jobs[foreign.Name]; !ok {
+		t.Errorf("foreign job %s was deleted", foreign.Name)
+	}
+}
+
+func TestReconcileDryRunMakesNoCalls(t *testing.T) {
+	cfg := testConfig()
+	sc, fake := newTestClient(t, cfg)
+	fake.PutJob(&schedulerpb.Job{
+		Name:     parent + "/jobs/harvester-healthcheck",
+		Schedule: "0 * * * *",
+		TimeZone: "UTC",
+		Target: &schedulerpb.Job_HttpTarget{HttpTarget: &schedulerpb.HttpTarget{
+			Uri:        "https://example.com/health?key=secret",
+			HttpMethod: schedulerpb.HttpMethod_GET,
+		}},
+	})
+	fake.PutJob(&schedulerpb.Job{Name: parent + "/jobs/harvester-retired"})
+	before := fake.Jobs()
+
+	report, err := sc.Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun {
+		t.Error("report is not marked as a dry run")
+	}
+	want := map[string]string{
+		parent + "/jobs/harvester-healthcheck": scheduler.ActionUpdate,
+		parent + "/jobs/harvester-satellite":   scheduler.ActionCreate,
+		parent + "/jobs/harvester-retired":     scheduler.ActionDelete,
+	}
+	if got := actions(t, report); !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("dry run made calls %v", calls)
+	}
+	if after := fake.Jobs(); len(after) != len(before) {
+		t.Errorf("dry run changed the jobs from %d to %d", len(before), len(after))
+	}
+}
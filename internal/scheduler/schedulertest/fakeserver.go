@@ -0,0 +1,185 @@
+/*
+ * fakeserver.go: In-memory fake of the Google Cloud Scheduler gRPC API.
+ * Usage:
+ *   - Start a FakeServer and pass its ClientOptions to scheduler.NewSchedulerClientWithOptions
+ *     to exercise job reconciliation without a GCP project or network access.
+ *   - Seed or inspect jobs with PutJob and Jobs; Calls lists the create, update and delete requests.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package schedulertest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/scheduler/apiv1/schedulerpb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// FakeServer implements the Cloud Scheduler service over a local gRPC listener.
+type FakeServer struct {
+	schedulerpb.UnimplementedCloudSchedulerServer
+
+	Addr string
+
+	mu    sync.Mutex
+	jobs  map[string]*schedulerpb.Job
+	calls []string
+	srv   *grpc.Server
+}
+
+// NewFakeServer starts a fake Cloud Scheduler on a random localhost port.
+func NewFakeServer() (*FakeServer, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for fake scheduler: %w", err)
+	}
+	f := &FakeServer{
+		Addr: lis.Addr().String(),
+		jobs: make(map[string]*schedulerpb.Job),
+		srv:  grpc.NewServer(),
+	}
+	schedulerpb.RegisterCloudSchedulerServer(f.srv, f)
+	go f.srv.Serve(lis)
+	return f, nil
+}
+
+// ClientOptions returns the options that point a Cloud Scheduler client at the fake.
+func (f *FakeServer) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(f.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
+
+// Close stops the server.
+func (f *FakeServer) Close() {
+	f.srv.Stop()
+}
+
+// PutJob stores a job directly, bypassing CreateJob validation.
+func (f *FakeServer) PutJob(job *schedulerpb.Job) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobs[job.Name] = proto.Clone(job).(*schedulerpb.Job)
+}
+
+// Jobs returns copies of all stored jobs, ordered by name.
+func (f *FakeServer) Jobs() []*schedulerpb.Job {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sortedJobs("")
+}
+
+// Calls returns the create, update and delete requests received, in order, as "Method jobName".
+func (f *FakeServer) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *FakeServer) ListJobs(ctx context.Context, req *schedulerpb.ListJobsRequest) (*schedulerpb.ListJobsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &schedulerpb.ListJobsResponse{Jobs: f.sortedJobs(req.Parent + "/jobs/")}, nil
+}
+
+func (f *FakeServer) GetJob(ctx context.Context, req *schedulerpb.GetJobRequest) (*schedulerpb.Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, ok := f.jobs[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Name)
+	}
+	return proto.Clone(job).(*schedulerpb.Job), nil
+}
+
+func (f *FakeServer) CreateJob(ctx context.Context, req *schedulerpb.CreateJobRequest) (*schedulerpb.Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "CreateJob "+req.Job.GetName())
+	job := proto.Clone(req.Job).(*schedulerpb.Job)
+	if !strings.HasPrefix(job.Name, req.Parent+"/jobs/") {
+		return nil, status.Errorf(codes.InvalidArgument, "job name %s is not under %s", job.Name, req.Parent)
+	}
+	if _, exists := f.jobs[job.Name]; exists {
+		return nil, status.Errorf(codes.AlreadyExists, "job %s already exists", job.Name)
+	}
+	job.State = schedulerpb.Job_ENABLED
+	// The real service adds its own User-Agent header to HTTP targets
+	if target := job.GetHttpTarget(); target != nil {
+		if target.Headers == nil {
+			target.Headers = make(map[string]string)
+		}
+		target.Headers["User-Agent"] = "Google-Cloud-Scheduler"
+	}
+	f.jobs[job.Name] = job
+	return proto.Clone(job).(*schedulerpb.Job), nil
+}
+
+func (f *FakeServer) UpdateJob(ctx context.Context, req *schedulerpb.UpdateJobRequest) (*schedulerpb.Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "UpdateJob "+req.Job.GetName())
+	existing, ok := f.jobs[req.Job.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Job.Name)
+	}
+	updated := proto.Clone(existing).(*schedulerpb.Job)
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = []string{"description", "schedule", "time_zone", "http_target"}
+	}
+	for _, path := range paths {
+		switch path {
+		case "description":
+			updated.Description = req.Job.Description
+		case "schedule":
+			updated.Schedule = req.Job.Schedule
+		case "time_zone":
+			updated.TimeZone = req.Job.TimeZone
+		case "http_target":
+			updated.Target = proto.Clone(req.Job).(*schedulerpb.Job).Target
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported update mask path %q", path)
+		}
+	}
+	f.jobs[updated.Name] = updated
+	return proto.Clone(updated).(*schedulerpb.Job), nil
+}
+
+func (f *FakeServer) DeleteJob(ctx context.Context, req *schedulerpb.DeleteJobRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "DeleteJob "+req.Name)
+	if _, ok := f.jobs[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Name)
+	}
+	delete(f.jobs, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// sortedJobs copies the jobs whose names start with prefix. Callers must hold f.mu.
+func (f *FakeServer) sortedJobs(prefix string) []*schedulerpb.Job {
+	var jobs []*schedulerpb.Job
+	for name, job := range f.jobs {
+		if strings.HasPrefix(name, prefix) {
+			jobs = append(jobs, proto.Clone(job).(*schedulerpb.Job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
@@ -9,8 +9,11 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -25,11 +28,31 @@ func NewServer(router *mux.Router) *Server {
 	}
 }
 
-func (s *Server) Start(port string) error {
+// shutdownTimeout bounds how long open requests may take to finish once the server is stopping.
+const shutdownTimeout = 30 * time.Second
+
+// Start serves requests until ctx is cancelled, then stops accepting connections and waits for open
+// requests to finish.
+func (s *Server) Start(ctx context.Context, port string) error {
+	srv := &http.Server{Addr: ":" + port, Handler: s.Router}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
 	log.Printf("Starting server on port %s\n", port)
-	if err := http.ListenAndServe(":"+port, s.Router); err != nil {
+	select {
+	case err := <-serveErr:
 		log.Printf("Server failed to start: %v", err)
 		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
 	return nil
 }
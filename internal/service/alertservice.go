@@ -0,0 +1,401 @@
+/*
+ * alertservice.go: Frost, heat-stress and other threshold alerts on incoming weather readings.
+ * Usage: Each vineyard has alert rules comparing one WeatherData field with a threshold, optionally only
+ *        between two days of the year or while the reading's block is between two E-L growth stages,
+ *        as PhenologyService.StagesOn estimates them for the reading's day. The weather service calls
+ *        EvaluateWeather for every record it creates; readings older than alertMaxReadingAge, such as
+ *        backfilled history, are not evaluated. A firing reading raises an alert and queues email to
+ *        the rule's recipients and the vineyard's alert subscribers; further readings firing within the
+ *        rule's cooldown are counted on that alert instead, until it is resolved.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/notify"
+	"github.com/sthompson732/viticulture-harvester-app/internal/phenology"
+	"github.com/sthompson732/viticulture-harvester-app/internal/webhook"
+)
+
+// ErrInvalidAlertRule is returned for alert rules with missing or unsupported settings.
+var ErrInvalidAlertRule = errors.New("invalid alert rule")
+
+// ErrAlertResolved is returned when acknowledging or resolving an alert that is already resolved.
+var ErrAlertResolved = errors.New("alert is already resolved")
+
+// DefaultAlertCooldownMinutes applies to rules created without a cooldown.
+const DefaultAlertCooldownMinutes = 360
+
+// alertMaxReadingAge is how long after its observation time a reading can still raise an alert. It
+// covers sources fetched once a day, and leaves out backfilled history, which would otherwise raise an
+// alert and send email for every past night that crossed a threshold.
+const alertMaxReadingAge = 48 * time.Hour
+
+// Listing limits for ListAlerts.
+const (
+	DefaultAlertListLimit = 100
+	MaxAlertListLimit     = 1000
+)
+
+// alertMetrics maps the WeatherData fields rules can test to their unit.
+var alertMetrics = map[string]string{
+	"temperature":   "°C",
+	"humidity":      "%",
+	"precipitation": "mm",
+	"leafWetness":   "min",
+	"windSpeed":     "m/s",
+	"dewPoint":      "°C",
+}
+
+var alertOperators = map[string]string{
+	model.AlertBelow:     "below",
+	model.AlertAtOrBelow: "at or below",
+	model.AlertAbove:     "above",
+	model.AlertAtOrAbove: "at or above",
+}
+
+type AlertService interface {
+	CreateRule(ctx context.Context, rule *model.AlertRule) error
+	GetRule(ctx context.Context, vineyardID, id int) (*model.AlertRule, error)
+	UpdateRule(ctx context.Context, rule *model.AlertRule) error
+	DeleteRule(ctx context.Context, vineyardID, id int) error
+	ListRules(ctx context.Context, vineyardID int) ([]model.AlertRule, error)
+	EvaluateWeather(ctx context.Context, weather *model.WeatherData) error
+	GetAlert(ctx context.Context, id int) (*model.Alert, error)
+	ListAlerts(ctx context.Context, vineyardID int, status string, limit int) ([]model.Alert, error)
+	AcknowledgeAlert(ctx context.Context, id int, by string) (*model.Alert, error)
+	ResolveAlert(ctx context.Context, id int) (*model.Alert, error)
+}
+
+type alertServiceImpl struct {
+	db            *db.DB
+	loc           *time.Location
+	notifications NotificationService
+	webhooks      WebhookService
+	phenology     PhenologyService
+}
+
+// NewAlertService creates the alert service. Days of the year are taken in the climate time zone, and
+// growth stages are the blocks' current stages from the phenology service.
+func NewAlertService(db *db.DB, settings climate.Settings, notifications NotificationService,
+	webhooks WebhookService, phenology PhenologyService) AlertService {
+	return &alertServiceImpl{db: db, loc: settings.Location, notifications: notifications, webhooks: webhooks,
+		phenology: phenology}
+}
+
+func (as *alertServiceImpl) CreateRule(ctx context.Context, rule *model.AlertRule) error {
+	if rule == nil {
+		return errors.New("cannot create a nil alert rule")
+	}
+	if rule.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := validateAlertRule(rule); err != nil {
+		return err
+	}
+	if _, err := as.db.GetVineyard(ctx, rule.VineyardID); err != nil {
+		return err
+	}
+	return as.db.SaveAlertRule(ctx, rule)
+}
+
+func (as *alertServiceImpl) GetRule(ctx context.Context, vineyardID, id int) (*model.AlertRule, error) {
+	if vineyardID <= 0 || id <= 0 {
+		return nil, errors.New("invalid alert rule ID")
+	}
+	return as.db.GetAlertRule(ctx, vineyardID, id)
+}
+
+func (as *alertServiceImpl) UpdateRule(ctx context.Context, rule *model.AlertRule) error {
+	if rule == nil {
+		return errors.New("cannot update a nil alert rule")
+	}
+	if rule.VineyardID <= 0 || rule.ID <= 0 {
+		return errors.New("invalid alert rule ID")
+	}
+	if err := validateAlertRule(rule); err != nil {
+		return err
+	}
+	return as.db.UpdateAlertRule(ctx, rule)
+}
+
+func (as *alertServiceImpl) DeleteRule(ctx context.Context, vineyardID, id int) error {
+	if vineyardID <= 0 || id <= 0 {
+		return errors.New("invalid alert rule ID")
+	}
+	return as.db.DeleteAlertRule(ctx, vineyardID, id)
+}
+
+func (as *alertServiceImpl) ListRules(ctx context.Context, vineyardID int) ([]model.AlertRule, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return as.db.ListAlertRules(ctx, vineyardID, false)
+}
+
+// validateAlertRule checks a rule's settings and fills in the default severity and cooldown.
+func validateAlertRule(rule *model.AlertRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAlertRule)
+	}
+	if _, ok := alertMetrics[rule.Metric]; !ok {
+		return fmt.Errorf("%w: unsupported metric %q", ErrInvalidAlertRule, rule.Metric)
+	}
+	if _, ok := alertOperators[rule.Operator]; !ok {
+		return fmt.Errorf("%w: operator must be lt, lte, gt or gte", ErrInvalidAlertRule)
+	}
+	if (rule.ActiveFrom == "") != (rule.ActiveTo == "") {
+		return fmt.Errorf("%w: activeFrom and activeTo must be given together", ErrInvalidAlertRule)
+	}
+	for _, day := range []string{rule.ActiveFrom, rule.ActiveTo} {
+		if _, err := time.Parse("01-02", day); day != "" && err != nil {
+			return fmt.Errorf("%w: %q is not a MM-DD day", ErrInvalidAlertRule, day)
+		}
+	}
+	for _, stage := range []*int{rule.MinELStage, rule.MaxELStage} {
+		if stage == nil {
+			continue
+		}
+		if _, ok := phenology.ByEL(*stage); !ok {
+			return fmt.Errorf("%w: %d is not an E-L stage", ErrInvalidAlertRule, *stage)
+		}
+	}
+	if rule.MinELStage != nil && rule.MaxELStage != nil && *rule.MinELStage > *rule.MaxELStage {
+		return fmt.Errorf("%w: minELStage is after maxELStage", ErrInvalidAlertRule)
+	}
+	switch {
+	case rule.CooldownMinutes < 0:
+		return fmt.Errorf("%w: cooldownMinutes cannot be negative", ErrInvalidAlertRule)
+	case rule.CooldownMinutes == 0:
+		rule.CooldownMinutes = DefaultAlertCooldownMinutes
+	}
+	switch rule.Severity {
+	case "":
+		rule.Severity = model.AlertSeverityWarning
+	case model.AlertSeverityInfo, model.AlertSeverityWarning, model.AlertSeverityCritical:
+	default:
+		return fmt.Errorf("%w: severity must be info, warning or critical", ErrInvalidAlertRule)
+	}
+	if rule.Recipients == nil {
+		rule.Recipients = []string{}
+	}
+	if err := notify.ValidateAddresses(rule.Recipients); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAlertRule, err)
+	}
+	return nil
+}
+
+// EvaluateWeather tests a saved weather record against its vineyard's enabled rules. Readings older
+// than alertMaxReadingAge are skipped. Delivery failures are logged and leave the alert unnotified
+// rather than failing the evaluation.
+func (as *alertServiceImpl) EvaluateWeather(ctx context.Context, weather *model.WeatherData) error {
+	if weather == nil || weather.VineyardID <= 0 {
+		return errors.New("invalid weather data")
+	}
+	if time.Since(weather.ObservationTime) > alertMaxReadingAge {
+		return nil
+	}
+	rules, err := as.db.ListAlertRules(ctx, weather.VineyardID, true)
+	if err != nil {
+		return err
+	}
+	var vineyard *model.Vineyard
+	var stages []model.PhenologyStage
+	for i := range rules {
+		rule := &rules[i]
+		value, ok := alertMetricValue(weather, rule.Metric)
+		if !ok || !as.active(rule, weather.ObservationTime) || !alertFires(rule, value) {
+			continue
+		}
+		// Stages are estimated from the season's heat, so they are only read once a staged rule fires
+		if rule.MinELStage != nil || rule.MaxELStage != nil {
+			if stages == nil {
+				if stages, err = as.phenology.StagesOn(ctx, weather.VineyardID, weather.ObservationTime); err != nil {
+					return err
+				}
+			}
+			if !inStageRange(rule, weather.BlockID, stages) {
+				continue
+			}
+		}
+		if vineyard == nil {
+			if vineyard, err = as.db.GetVineyard(ctx, weather.VineyardID); err != nil {
+				return err
+			}
+		}
+		alert, created, err := as.db.RecordAlertTrigger(ctx, rule, weather, value, as.message(rule, vineyard, weather, value))
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+		if created {
+			as.webhooks.Emit(ctx, webhook.EventAlertRaised, &alert.VineyardID, alert)
+			as.deliver(ctx, alert, rule, vineyard)
+		}
+	}
+	return nil
+}
+
+// active reports whether the rule applies on the day of an observation.
+func (as *alertServiceImpl) active(rule *model.AlertRule, observed time.Time) bool {
+	if rule.ActiveFrom == "" {
+		return true
+	}
+	day := observed.In(as.loc).Format("01-02")
+	if rule.ActiveFrom <= rule.ActiveTo {
+		return day >= rule.ActiveFrom && day <= rule.ActiveTo
+	}
+	return day >= rule.ActiveFrom || day <= rule.ActiveTo
+}
+
+// inStageRange reports whether the reading's block is within the rule's E-L stages. A reading without a
+// block, or of a block without a stage entry, is within range when any block is. A block whose stage
+// cannot be estimated counts as within range, so that a missing observation does not silence the rule.
+func inStageRange(rule *model.AlertRule, blockID *int, stages []model.PhenologyStage) bool {
+	candidates := stages
+	if blockID != nil {
+		for _, s := range stages {
+			if s.BlockID != nil && *s.BlockID == *blockID {
+				candidates = []model.PhenologyStage{s}
+				break
+			}
+		}
+	}
+	for _, s := range candidates {
+		switch {
+		case s.ELStage == nil:
+			return true
+		case rule.MinELStage != nil && *s.ELStage < *rule.MinELStage:
+		case rule.MaxELStage != nil && *s.ELStage > *rule.MaxELStage:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func alertFires(rule *model.AlertRule, value float64) bool {
+	switch rule.Operator {
+	case model.AlertBelow:
+		return value < rule.Threshold
+	case model.AlertAtOrBelow:
+		return value <= rule.Threshold
+	case model.AlertAbove:
+		return value > rule.Threshold
+	case model.AlertAtOrAbove:
+		return value >= rule.Threshold
+	}
+	return false
+}
+
+// alertMetricValue returns the reading's value for a metric; optional fields the reading lacks are not
+// evaluated.
+func alertMetricValue(weather *model.WeatherData, metric string) (float64, bool) {
+	var v *float64
+	switch metric {
+	case "temperature":
+		return weather.Temperature, true
+	case "humidity":
+		return weather.Humidity, true
+	case "precipitation":
+		v = weather.Precipitation
+	case "leafWetness":
+		v = weather.LeafWetness
+	case "windSpeed":
+		v = weather.WindSpeed
+	case "dewPoint":
+		v = weather.DewPoint
+	}
+	if v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+func (as *alertServiceImpl) message(rule *model.AlertRule, vineyard *model.Vineyard, weather *model.WeatherData, value float64) string {
+	unit := alertMetrics[rule.Metric]
+	return fmt.Sprintf("%s at %s: %s was %g %s at %s, %s the threshold of %g %s", rule.Name, vineyard.Name,
+		rule.Metric, value, unit, weather.ObservationTime.In(as.loc).Format("2006-01-02 15:04 MST"),
+		alertOperators[rule.Operator], rule.Threshold, unit)
+}
+
+// deliver queues email for a new alert and records that it was queued.
+func (as *alertServiceImpl) deliver(ctx context.Context, alert *model.Alert, rule *model.AlertRule, vineyard *model.Vineyard) {
+	queued, err := as.notifications.NotifyAlert(ctx, alert, rule, vineyard)
+	if err != nil {
+		log.Printf("Failed to queue email for alert %d: %v", alert.ID, err)
+		return
+	}
+	if !queued {
+		return
+	}
+	if err := as.db.MarkAlertNotified(ctx, alert.ID); err != nil {
+		log.Printf("Failed to record notification of alert %d: %v", alert.ID, err)
+	}
+}
+
+func (as *alertServiceImpl) GetAlert(ctx context.Context, id int) (*model.Alert, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid alert ID")
+	}
+	return as.db.GetAlert(ctx, id)
+}
+
+// ListAlerts returns a vineyard's alerts newest first, optionally of one status. limit defaults to
+// DefaultAlertListLimit and is capped at MaxAlertListLimit.
+func (as *alertServiceImpl) ListAlerts(ctx context.Context, vineyardID int, status string, limit int) ([]model.Alert, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if limit <= 0 {
+		limit = DefaultAlertListLimit
+	}
+	if limit > MaxAlertListLimit {
+		limit = MaxAlertListLimit
+	}
+	return as.db.ListAlerts(ctx, vineyardID, status, limit)
+}
+
+// AcknowledgeAlert marks an alert as seen. It keeps absorbing triggers until resolved.
+func (as *alertServiceImpl) AcknowledgeAlert(ctx context.Context, id int, by string) (*model.Alert, error) {
+	return as.changeAlert(ctx, id, func() (*model.Alert, error) {
+		return as.db.AcknowledgeAlert(ctx, id, strings.TrimSpace(by))
+	})
+}
+
+// ResolveAlert closes an alert; the rule's next trigger raises a new one.
+func (as *alertServiceImpl) ResolveAlert(ctx context.Context, id int) (*model.Alert, error) {
+	return as.changeAlert(ctx, id, func() (*model.Alert, error) {
+		return as.db.ResolveAlert(ctx, id)
+	})
+}
+
+// changeAlert applies a status change to an unresolved alert, telling a missing alert (sql.ErrNoRows)
+// from a resolved one (ErrAlertResolved).
+func (as *alertServiceImpl) changeAlert(ctx context.Context, id int, update func() (*model.Alert, error)) (*model.Alert, error) {
+	alert, err := as.GetAlert(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if alert.Status == model.AlertStatusResolved {
+		return nil, fmt.Errorf("%w: alert %d", ErrAlertResolved, id)
+	}
+	alert, err = update()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: alert %d", ErrAlertResolved, id)
+	}
+	return alert, err
+}
@@ -0,0 +1,330 @@
+/*
+ * backfillservice.go: Ingests a data source's history for one vineyard.
+ * Usage: Backfill runs a job to completion (the `harvester backfill` command); Start runs it in the
+ *        background (the admin API). The range is split into windows of the source's backfill chunk,
+ *        each fetched through the IngestionService with {startDate}/{endDate} set to the window.
+ *        Windows that already have records are skipped, requests are paced by backfill.requestsPerMinute,
+ *        and the job's checkpoint is saved after every window so an interrupted job picks up where it
+ *        stopped when the same source, vineyard and range are requested again. Backfilled weather is
+ *        run through the disease models once the job completes rather than after every window.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	client "github.com/sthompson732/viticulture-harvester-app/internal/clients"
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/mapping"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+var (
+	// ErrBackfillUnsupported is returned for sources whose endpoint cannot be pointed at past dates.
+	ErrBackfillUnsupported = errors.New("data source cannot be backfilled")
+	// ErrBackfillActive is returned when the same backfill is already running in this process.
+	ErrBackfillActive = errors.New("backfill already running")
+)
+
+const oneDay = 24 * time.Hour
+
+// BackfillRequest selects the source, vineyard and inclusive day range to backfill.
+type BackfillRequest struct {
+	SourceKey  string
+	VineyardID int
+	From       time.Time
+	To         time.Time
+}
+
+type BackfillService interface {
+	Backfill(ctx context.Context, req BackfillRequest) (*model.BackfillJob, error)
+	Start(ctx context.Context, req BackfillRequest) (*model.BackfillJob, error)
+	GetJob(ctx context.Context, id int) (*model.BackfillJob, error)
+	ListJobs(ctx context.Context, limit int) ([]model.BackfillJob, error)
+}
+
+type backfillServiceImpl struct {
+	cfg              *config.Config
+	db               *db.DB
+	vineyardService  VineyardService
+	ingestionService IngestionService
+	diseaseService   DiseaseService
+
+	mu     sync.Mutex
+	active map[int]bool
+}
+
+func NewBackfillService(cfg *config.Config, db *db.DB, vineyardService VineyardService,
+	ingestionService IngestionService, diseaseService DiseaseService) BackfillService {
+	return &backfillServiceImpl{
+		cfg:              cfg,
+		db:               db,
+		vineyardService:  vineyardService,
+		ingestionService: ingestionService,
+		diseaseService:   diseaseService,
+		active:           make(map[int]bool),
+	}
+}
+
+// Backfill creates or resumes the job for the request and runs it until it completes or fails.
+func (bs *backfillServiceImpl) Backfill(ctx context.Context, req BackfillRequest) (*model.BackfillJob, error) {
+	job, err := bs.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer bs.release(job.ID)
+	return job, bs.run(ctx, job)
+}
+
+// Start creates or resumes the job for the request and runs it in the background.
+// The returned job reflects the state before any window was processed.
+func (bs *backfillServiceImpl) Start(ctx context.Context, req BackfillRequest) (*model.BackfillJob, error) {
+	job, err := bs.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *job
+	go func() {
+		defer bs.release(job.ID)
+		// Detached from the request, which ends as soon as the job is accepted
+		if err := bs.run(context.WithoutCancel(ctx), job); err != nil {
+			log.Printf("Backfill %d of %s for vineyard %d stopped: %v", job.ID, job.SourceKey, job.VineyardID, err)
+		}
+	}()
+	return &snapshot, nil
+}
+
+func (bs *backfillServiceImpl) GetJob(ctx context.Context, id int) (*model.BackfillJob, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid backfill job ID")
+	}
+	return bs.db.GetBackfillJob(ctx, id)
+}
+
+func (bs *backfillServiceImpl) ListJobs(ctx context.Context, limit int) ([]model.BackfillJob, error) {
+	if limit <= 0 {
+		limit = DefaultRunListLimit
+	}
+	return bs.db.ListBackfillJobs(ctx, limit)
+}
+
+// prepare validates the request and returns the job to run, claimed for this process.
+func (bs *backfillServiceImpl) prepare(ctx context.Context, req BackfillRequest) (*model.BackfillJob, error) {
+	dataSource, ok := bs.cfg.DataSources[req.SourceKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSource, req.SourceKey)
+	}
+	if !bs.ingestionService.Supports(req.SourceKey) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSource, req.SourceKey)
+	}
+	if req.VineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	from, to := truncateDay(req.From), truncateDay(req.To)
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return nil, errors.New("invalid backfill range")
+	}
+	if _, err := bs.vineyardService.GetVineyard(ctx, req.VineyardID); err != nil {
+		return nil, err
+	}
+
+	if _, ok := recordModel(req.SourceKey, dataSource); !ok {
+		return nil, fmt.Errorf("%w: %s has no record model", ErrBackfillUnsupported, req.SourceKey)
+	}
+	chunkDays, err := backfillChunkDays(req.SourceKey, dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := bs.db.FindResumableBackfillJob(ctx, req.SourceKey, req.VineyardID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		job = &model.BackfillJob{
+			SourceKey:    req.SourceKey,
+			VineyardID:   req.VineyardID,
+			From:         from,
+			To:           to,
+			ChunkDays:    chunkDays,
+			Status:       model.BackfillStatusRunning,
+			Checkpoint:   from,
+			WindowsTotal: windowCount(from, to, chunkDays),
+		}
+		if err := bs.db.CreateBackfillJob(ctx, job); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Printf("Resuming backfill %d of %s for vineyard %d from %s", job.ID, job.SourceKey, job.VineyardID,
+			job.Checkpoint.Format("2006-01-02"))
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if bs.active[job.ID] {
+		return nil, fmt.Errorf("%w: job %d", ErrBackfillActive, job.ID)
+	}
+	bs.active[job.ID] = true
+	return job, nil
+}
+
+func (bs *backfillServiceImpl) release(jobID int) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	delete(bs.active, jobID)
+}
+
+// run processes the job's windows from its checkpoint, saving progress after each one.
+func (bs *backfillServiceImpl) run(ctx context.Context, job *model.BackfillJob) error {
+	dataSource := bs.cfg.DataSources[job.SourceKey]
+	modelName, _ := recordModel(job.SourceKey, dataSource)
+	rpm := dataSource.Backfill.RequestsPerMinute
+	if rpm <= 0 {
+		rpm = config.DefaultBackfillRequestsPerMinute
+	}
+	interval := time.Minute / time.Duration(rpm)
+
+	// A resumed job keeps the chunk it started with, so its window boundaries do not move
+	job.Status = model.BackfillStatusRunning
+	job.Error = ""
+	if err := bs.db.UpdateBackfillJob(ctx, job); err != nil {
+		return err
+	}
+
+	var lastRequest time.Time
+	for !job.Checkpoint.After(job.To) {
+		start := job.Checkpoint
+		end := start.Add(time.Duration(job.ChunkDays-1) * oneDay)
+		if end.After(job.To) {
+			end = job.To
+		}
+
+		existing, err := bs.db.CountRecordsInRange(ctx, modelName, job.VineyardID, start, end.Add(oneDay))
+		if err != nil {
+			return bs.stop(job, model.BackfillStatusFailed, err)
+		}
+		if existing > 0 {
+			job.WindowsSkipped++
+		} else {
+			if wait := time.Until(lastRequest.Add(interval)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return bs.stop(job, model.BackfillStatusInterrupted, ctx.Err())
+				case <-time.After(wait):
+				}
+			}
+			lastRequest = time.Now()
+
+			summary, err := bs.ingestionService.FetchDataFromSource(ctx, job.SourceKey, FetchOptions{
+				VineyardID: job.VineyardID,
+				Date:       end,
+				StartDate:  start,
+				EndDate:    end,
+				Trigger:    model.RunTriggerBackfill,
+			})
+			if err == nil && summary.Failed > 0 {
+				err = errors.New(summary.Vineyards[0].Error)
+			}
+			if err != nil {
+				status := model.BackfillStatusFailed
+				if ctx.Err() != nil {
+					status = model.BackfillStatusInterrupted
+				}
+				return bs.stop(job, status, fmt.Errorf("window %s to %s: %w",
+					start.Format("2006-01-02"), end.Format("2006-01-02"), err))
+			}
+			job.WindowsFetched++
+			job.Records += summary.Records
+		}
+
+		job.Checkpoint = end.Add(oneDay)
+		if err := bs.db.UpdateBackfillJob(ctx, job); err != nil {
+			return bs.stop(job, model.BackfillStatusInterrupted, err)
+		}
+	}
+
+	// Each day's risk builds on the day before, so one run over the whole range replaces a rerun
+	// through today after every window. The records are saved either way, so a failed run is only logged.
+	if modelName == mapping.ModelWeather && job.WindowsFetched > 0 {
+		if err := bs.diseaseService.RunModels(ctx, job.VineyardID, job.From, time.Time{}); err != nil {
+			log.Printf("Backfill %d: failed to update disease risk for vineyard %d: %v", job.ID, job.VineyardID, err)
+		}
+	}
+
+	job.Status = model.BackfillStatusCompleted
+	return bs.db.UpdateBackfillJob(ctx, job)
+}
+
+// stop records why a job ended early. The checkpoint still points at the failed window, so it is retried on resume.
+func (bs *backfillServiceImpl) stop(job *model.BackfillJob, status string, cause error) error {
+	job.Status = status
+	job.Error = cause.Error()
+	// The job's context may be the reason it stopped, so the final state is saved without it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := bs.db.UpdateBackfillJob(ctx, job); err != nil {
+		log.Printf("Failed to save backfill %d: %v", job.ID, err)
+	}
+	return cause
+}
+
+// recordModel reports which model a source's records are stored as.
+func recordModel(sourceKey string, dataSource config.DataSourceConfig) (string, bool) {
+	if dataSource.Mapping != nil {
+		return dataSource.Mapping.Model, true
+	}
+	switch sourceKey {
+	case mapping.ModelWeather, mapping.ModelSatellite:
+		return sourceKey, true
+	default:
+		return "", false
+	}
+}
+
+// backfillChunkDays sizes the windows. Endpoints without {startDate}/{endDate} can only ask for a
+// single {date}, so they are backfilled one day at a time.
+func backfillChunkDays(sourceKey string, dataSource config.DataSourceConfig) (int, error) {
+	var hasDate, hasRange bool
+	for _, name := range client.Placeholders(dataSource.Endpoint) {
+		switch name {
+		case client.PlaceholderDate:
+			hasDate = true
+		case client.PlaceholderStartDate, client.PlaceholderEndDate:
+			hasRange = true
+		}
+	}
+	switch {
+	case hasRange:
+		days, err := dataSource.BackfillChunkDays()
+		if err != nil {
+			return 0, fmt.Errorf("data source %s: %w", sourceKey, err)
+		}
+		return days, nil
+	case hasDate:
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("%w: %s endpoint has no date placeholders", ErrBackfillUnsupported, sourceKey)
+	}
+}
+
+func windowCount(from, to time.Time, chunkDays int) int {
+	days := int(to.Sub(from)/oneDay) + 1
+	return (days + chunkDays - 1) / chunkDays
+}
+
+func truncateDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
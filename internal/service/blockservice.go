@@ -0,0 +1,126 @@
+/*
+ * blockservice.go: Manages vineyard blocks and their planting metadata.
+ * Usage: Blocks are created and listed per vineyard under /vineyards/{id}/blocks. Pest, soil, weather
+ *        and image records name a block with block_id, or are assigned the block whose boundary
+ *        contains them when they are saved; checkRecordBlock validates a given block_id.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// ErrInvalidBlock is returned for blocks with missing or out-of-range fields, and for records naming a
+// block of another vineyard.
+var ErrInvalidBlock = errors.New("invalid block")
+
+// earliestPlantingYear bounds plantingYear; older vines exist, but not in this app's vineyards.
+const earliestPlantingYear = 1850
+
+type BlockService interface {
+	CreateBlock(ctx context.Context, block *model.Block) error
+	GetBlock(ctx context.Context, vineyardID, id int) (*model.Block, error)
+	UpdateBlock(ctx context.Context, block *model.Block) error
+	DeleteBlock(ctx context.Context, vineyardID, id int) error
+	ListBlocks(ctx context.Context, vineyardID int) ([]model.Block, error)
+}
+
+type blockServiceImpl struct {
+	db *db.DB
+}
+
+func NewBlockService(db *db.DB) BlockService {
+	return &blockServiceImpl{db: db}
+}
+
+func (bs *blockServiceImpl) CreateBlock(ctx context.Context, block *model.Block) error {
+	if block == nil {
+		return errors.New("cannot create a nil block")
+	}
+	if block.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := validateBlock(block); err != nil {
+		return err
+	}
+	if _, err := bs.db.GetVineyard(ctx, block.VineyardID); err != nil {
+		return err
+	}
+	return bs.db.SaveBlock(ctx, block)
+}
+
+func (bs *blockServiceImpl) GetBlock(ctx context.Context, vineyardID, id int) (*model.Block, error) {
+	if vineyardID <= 0 || id <= 0 {
+		return nil, errors.New("invalid block ID")
+	}
+	return bs.db.GetBlock(ctx, vineyardID, id)
+}
+
+func (bs *blockServiceImpl) UpdateBlock(ctx context.Context, block *model.Block) error {
+	if block == nil {
+		return errors.New("cannot update a nil block")
+	}
+	if block.VineyardID <= 0 || block.ID <= 0 {
+		return errors.New("invalid block ID")
+	}
+	if err := validateBlock(block); err != nil {
+		return err
+	}
+	return bs.db.UpdateBlock(ctx, block)
+}
+
+func (bs *blockServiceImpl) DeleteBlock(ctx context.Context, vineyardID, id int) error {
+	if vineyardID <= 0 || id <= 0 {
+		return errors.New("invalid block ID")
+	}
+	return bs.db.DeleteBlock(ctx, vineyardID, id)
+}
+
+func (bs *blockServiceImpl) ListBlocks(ctx context.Context, vineyardID int) ([]model.Block, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return bs.db.ListBlocks(ctx, vineyardID)
+}
+
+func validateBlock(block *model.Block) error {
+	if block.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidBlock)
+	}
+	if y := block.PlantingYear; y != nil && (*y < earliestPlantingYear || *y > time.Now().Year()+1) {
+		return fmt.Errorf("%w: plantingYear %d is out of range", ErrInvalidBlock, *y)
+	}
+	if block.RowSpacing != nil && *block.RowSpacing <= 0 {
+		return fmt.Errorf("%w: rowSpacing must be positive", ErrInvalidBlock)
+	}
+	if block.VineSpacing != nil && *block.VineSpacing <= 0 {
+		return fmt.Errorf("%w: vineSpacing must be positive", ErrInvalidBlock)
+	}
+	return geo.ValidateField("boundary", block.Boundary)
+}
+
+// checkRecordBlock verifies that a record's explicit block belongs to the record's vineyard.
+// A nil blockID is left for the database to assign from the record's location.
+func checkRecordBlock(ctx context.Context, database *db.DB, vineyardID int, blockID *int) error {
+	if blockID == nil {
+		return nil
+	}
+	if _, err := database.GetBlock(ctx, vineyardID, *blockID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: block %d is not a block of vineyard %d", ErrInvalidBlock, *blockID, vineyardID)
+		}
+		return err
+	}
+	return nil
+}
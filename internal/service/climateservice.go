@@ -0,0 +1,145 @@
+/*
+ * climateservice.go: Growing degree days and heat summation indices for vineyards.
+ * Usage: Backs /vineyards/{id}/climate/gdd. Weather observations are aggregated into daily minimum and
+ *        maximum temperatures, from which the climate package computes the degree day series and the
+ *        Winkler, Huglin and BEDD indices. The vineyard's bounding box gives its hemisphere and latitude.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// MaxClimateRangeDays caps the length of a degree day series.
+const MaxClimateRangeDays = 731
+
+// ErrInvalidClimateQuery is returned for degree day reports with an unusable range or method.
+var ErrInvalidClimateQuery = errors.New("invalid climate query")
+
+type ClimateService interface {
+	GrowingDegreeDays(ctx context.Context, vineyardID int, query model.GDDQuery) (*model.GrowingDegreeDays, error)
+}
+
+type climateServiceImpl struct {
+	db       *db.DB
+	settings climate.Settings
+}
+
+func NewClimateService(db *db.DB, settings climate.Settings) ClimateService {
+	return &climateServiceImpl{db: db, settings: settings}
+}
+
+// GrowingDegreeDays reports the degree days of each day from query.From to query.To, accumulated from
+// the season start. To defaults to today and From to the season start, which is query.Budbreak or
+// else the conventional start for the vineyard's hemisphere.
+func (cs *climateServiceImpl) GrowingDegreeDays(ctx context.Context, vineyardID int, query model.GDDQuery) (*model.GrowingDegreeDays, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	settings := cs.settings
+	if query.Method != "" {
+		settings.Method = query.Method
+	}
+	if query.CapTemp != nil {
+		settings.CapTemp = *query.CapTemp
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClimateQuery, err)
+	}
+
+	vineyard, err := cs.db.GetVineyard(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	if len(vineyard.BoundingBox) == 0 {
+		return nil, fmt.Errorf("%w: vineyard %d", ErrNoBoundingBox, vineyardID)
+	}
+	geometry, err := cs.db.GetVineyardGeometry(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	latitude := geometry.Centroid.Lat
+
+	loc := settings.Location
+	to := calendarDate(time.Now().In(loc), loc)
+	if !query.To.IsZero() {
+		to = calendarDate(query.To, loc)
+	}
+	seasonStart := climate.SeasonStart(to, latitude)
+	if !query.Budbreak.IsZero() {
+		seasonStart = calendarDate(query.Budbreak, loc)
+	}
+	from := seasonStart
+	if !query.From.IsZero() {
+		from = calendarDate(query.From, loc)
+	}
+	switch {
+	case seasonStart.After(to):
+		return nil, fmt.Errorf("%w: budbreak is after the end date", ErrInvalidClimateQuery)
+	case from.After(to):
+		return nil, fmt.Errorf("%w: end date is before start date", ErrInvalidClimateQuery)
+	case climate.DayCount(from, to) > MaxClimateRangeDays:
+		return nil, fmt.Errorf("%w: at most %d days can be reported", ErrInvalidClimateQuery, MaxClimateRangeDays)
+	}
+
+	// The indices always start at the conventional season start, which may precede budbreak and from.
+	indexStart := climate.SeasonStart(to, latitude)
+	earliest := from
+	for _, d := range []time.Time{seasonStart, indexStart} {
+		if d.Before(earliest) {
+			earliest = d
+		}
+	}
+	temps, err := cs.db.ListDailyTemperatures(ctx, vineyardID, earliest, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.GrowingDegreeDays{
+		VineyardID:  vineyardID,
+		Method:      settings.Method,
+		BaseTemp:    settings.BaseTemp,
+		CapTemp:     settings.CapTemp,
+		SeasonStart: seasonStart,
+		From:        from,
+		To:          to,
+		Days:        []model.DegreeDay{},
+		Winkler:     climate.Winkler(temps, indexStart, to),
+		Huglin:      climate.Huglin(temps, indexStart, to, latitude),
+		BEDD:        climate.BEDD(temps, indexStart, to, latitude),
+	}
+	accumulated := 0.0
+	for _, t := range temps {
+		gdd := settings.DegreeDays(t.Min, t.Max)
+		if !t.Date.Before(seasonStart) {
+			accumulated += gdd
+		}
+		if t.Date.Before(from) {
+			continue
+		}
+		day := model.DegreeDay{DailyTemperature: t, GDD: climate.Round(gdd)}
+		if !t.Date.Before(seasonStart) {
+			day.Accumulated = climate.Round(accumulated)
+		}
+		report.Days = append(report.Days, day)
+	}
+	report.SeasonToDate = climate.Round(accumulated)
+	report.MissingDays = climate.DayCount(from, to) - len(report.Days)
+	return report, nil
+}
+
+// calendarDate returns midnight in loc of the calendar date t carries, without converting t to loc first:
+// dates parsed from requests and read from DATE columns carry their date in whatever zone they were read in.
+func calendarDate(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
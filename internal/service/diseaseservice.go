@@ -0,0 +1,186 @@
+/*
+ * diseaseservice.go: Runs the disease risk models over vineyards' weather and stores their daily index.
+ * Usage: RunModels recomputes every model for a range of days; ingestion calls it for the days of newly
+ *        saved weather observations. A model's state carries from day to day within a growing season
+ *        and restarts at the season start (1 April, or 1 October in the southern hemisphere). When an
+ *        index reaches its model's spray threshold a pest record of kind "risk" is created for the day.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/disease"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// ErrUnknownDiseaseModel is returned for model names that are not in disease.Models.
+var ErrUnknownDiseaseModel = errors.New("unknown disease model")
+
+type DiseaseService interface {
+	RunModels(ctx context.Context, vineyardID int, from, to time.Time) error
+	UpdateSince(ctx context.Context, vineyardID int, since time.Time) error
+	ListRisk(ctx context.Context, vineyardID int, modelName string, from, to time.Time) ([]model.DiseaseRisk, error)
+}
+
+type diseaseServiceImpl struct {
+	db  *db.DB
+	loc *time.Location
+}
+
+func NewDiseaseService(db *db.DB, settings climate.Settings) DiseaseService {
+	return &diseaseServiceImpl{db: db, loc: settings.Location}
+}
+
+// RunModels recomputes every model for the vineyard from one calendar day to another, by default today.
+// A model is run from the day after its last result if that is earlier, or from the season start when
+// it has no results in the season yet, so the index always builds up over consecutive days.
+func (ds *diseaseServiceImpl) RunModels(ctx context.Context, vineyardID int, from, to time.Time) error {
+	if vineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if to.IsZero() {
+		to = time.Now().In(ds.loc)
+	}
+	from, to = calendarDate(from, ds.loc), calendarDate(to, ds.loc)
+	if to.Before(from) {
+		return errors.New("end date is before start date")
+	}
+	if _, err := ds.db.GetVineyard(ctx, vineyardID); err != nil {
+		return err
+	}
+	// Without a bounding box the vineyard is taken to be in the northern hemisphere
+	var latitude float64
+	if geometry, err := ds.db.GetVineyardGeometry(ctx, vineyardID); err == nil {
+		latitude = geometry.Centroid.Lat
+	}
+
+	for _, m := range disease.Models() {
+		if err := ds.runModel(ctx, m, vineyardID, latitude, from, to); err != nil {
+			return fmt.Errorf("running %s model: %w", m.Name(), err)
+		}
+	}
+	return nil
+}
+
+// UpdateSince reruns the models from the day of an observation through today, e.g. after new weather
+// observations were saved.
+func (ds *diseaseServiceImpl) UpdateSince(ctx context.Context, vineyardID int, since time.Time) error {
+	since = since.In(ds.loc)
+	to := time.Now().In(ds.loc)
+	if to.Before(since) {
+		to = since
+	}
+	return ds.RunModels(ctx, vineyardID, since, to)
+}
+
+func (ds *diseaseServiceImpl) runModel(ctx context.Context, m disease.Model, vineyardID int, latitude float64, from, to time.Time) error {
+	seasonStart := climate.SeasonStart(from, latitude)
+	prev, err := ds.db.GetLatestDiseaseRisk(ctx, vineyardID, m.Name(), seasonStart, from)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		prev, from = nil, seasonStart
+	case err != nil:
+		return err
+	default:
+		prev.Day = calendarDate(prev.Day, ds.loc)
+		if next := prev.Day.AddDate(0, 0, 1); next.Before(from) {
+			from = next
+		}
+	}
+
+	// Risk records already raised in the range are kept rather than raised again
+	stored, err := ds.db.ListDiseaseRisk(ctx, vineyardID, m.Name(), from, to)
+	if err != nil {
+		return err
+	}
+	raised := make(map[time.Time]*int, len(stored))
+	for _, r := range stored {
+		raised[calendarDate(r.Day, ds.loc)] = r.PestDataID
+	}
+
+	observations, err := ds.db.ListWeatherDataByDateRange(ctx, vineyardID, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		return err
+	}
+	for _, day := range disease.GroupDays(observations, ds.loc) {
+		if !climate.SeasonStart(day.Date, latitude).Equal(seasonStart) {
+			seasonStart, prev = climate.SeasonStart(day.Date, latitude), nil
+		}
+		risk := m.Day(day, prev)
+		risk.VineyardID = vineyardID
+		risk.Model = m.Name()
+		risk.PestDataID = raised[day.Date]
+		if risk.PestDataID == nil && disease.Crossed(m, prev, risk) {
+			pest, err := ds.raiseRisk(ctx, m, vineyardID, risk)
+			if err != nil {
+				return err
+			}
+			risk.PestDataID = &pest.ID
+		}
+		if err := ds.db.SaveDiseaseRisk(ctx, &risk); err != nil {
+			return err
+		}
+		prev = &risk
+	}
+	return nil
+}
+
+// raiseRisk records a threshold crossing as a vineyard-wide pest record.
+func (ds *diseaseServiceImpl) raiseRisk(ctx context.Context, m disease.Model, vineyardID int, risk model.DiseaseRisk) (*model.PestData, error) {
+	pest := &model.PestData{
+		VineyardID: vineyardID,
+		Description: fmt.Sprintf("%s risk index reached %g, at or above the spray threshold of %g",
+			m.Title(), risk.Index, m.Threshold()),
+		Type:            m.PestType(),
+		Severity:        risk.Level,
+		ObservationDate: risk.Day,
+		Kind:            model.PestKindRisk,
+	}
+	if err := ds.db.SavePestData(ctx, pest); err != nil {
+		return nil, err
+	}
+	return pest, nil
+}
+
+// ListRisk returns a model's stored daily results for a vineyard. to defaults to today and from to the
+// start of the season containing to.
+func (ds *diseaseServiceImpl) ListRisk(ctx context.Context, vineyardID int, modelName string, from, to time.Time) ([]model.DiseaseRisk, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if _, ok := disease.Lookup(modelName); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDiseaseModel, modelName)
+	}
+	if _, err := ds.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	if to.IsZero() {
+		to = time.Now().In(ds.loc)
+	}
+	to = calendarDate(to, ds.loc)
+	if from.IsZero() {
+		var latitude float64
+		if geometry, err := ds.db.GetVineyardGeometry(ctx, vineyardID); err == nil {
+			latitude = geometry.Centroid.Lat
+		}
+		from = climate.SeasonStart(to, latitude)
+	}
+	risks, err := ds.db.ListDiseaseRisk(ctx, vineyardID, modelName, calendarDate(from, ds.loc), to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range risks {
+		risks[i].Day = calendarDate(risks[i].Day, ds.loc)
+	}
+	return risks, nil
+}
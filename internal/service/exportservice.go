@@ -0,0 +1,105 @@
+/*
+ * exportservice.go: Exports vineyards and their records as GeoJSON FeatureCollections.
+ * Usage: Backs the .geojson endpoints. Records are streamed from the database straight into a
+ *        geo.FeatureWriter; each feature's geometry is the record's location or bounding box and
+ *        its properties are the record's remaining JSON fields.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// Per-vineyard datasets that can be exported.
+const (
+	DatasetPests     = "pests"
+	DatasetSoil      = "soil"
+	DatasetWeather   = "weather"
+	DatasetImages    = "images"
+	DatasetSatellite = "satellite"
+)
+
+// ErrUnknownDataset is returned for dataset names other than the Dataset constants.
+var ErrUnknownDataset = errors.New("unknown dataset")
+
+// JSON names of the model fields that become feature geometries.
+const (
+	locationField    = "location"
+	boundingBoxField = "boundingBox"
+)
+
+type ExportService interface {
+	ExportVineyards(ctx context.Context, fw *geo.FeatureWriter) error
+	ExportDataset(ctx context.Context, dataset string, vineyardID int, filter model.RecordFilter, fw *geo.FeatureWriter) error
+}
+
+type exportServiceImpl struct {
+	db *db.DB
+}
+
+func NewExportService(db *db.DB) ExportService {
+	return &exportServiceImpl{db: db}
+}
+
+// ExportVineyards writes every vineyard, with its bounding box as the geometry.
+func (es *exportServiceImpl) ExportVineyards(ctx context.Context, fw *geo.FeatureWriter) error {
+	return es.db.EachVineyard(ctx, func(v *model.Vineyard) error {
+		return writeFeature(fw, v.ID, v.BoundingBox, v, boundingBoxField)
+	})
+}
+
+// ExportDataset writes one vineyard's records of a dataset. The vineyard is looked up first, so a
+// missing vineyard is reported before anything is written.
+func (es *exportServiceImpl) ExportDataset(ctx context.Context, dataset string, vineyardID int, filter model.RecordFilter, fw *geo.FeatureWriter) error {
+	if vineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if filter.Limit < 0 {
+		return errors.New("limit must be a positive number")
+	}
+	if _, err := es.db.GetVineyard(ctx, vineyardID); err != nil {
+		return err
+	}
+
+	switch dataset {
+	case DatasetPests:
+		return es.db.EachPestData(ctx, vineyardID, filter, func(p *model.PestData) error {
+			return writeFeature(fw, p.ID, p.Location, p, locationField)
+		})
+	case DatasetSoil:
+		return es.db.EachSoilData(ctx, vineyardID, filter, func(s *model.SoilData) error {
+			return writeFeature(fw, s.ID, s.Location, s, locationField)
+		})
+	case DatasetWeather:
+		return es.db.EachWeatherData(ctx, vineyardID, filter, func(w *model.WeatherData) error {
+			return writeFeature(fw, w.ID, w.Location, w, locationField)
+		})
+	case DatasetImages:
+		return es.db.EachImage(ctx, vineyardID, filter, func(img *model.Image) error {
+			return writeFeature(fw, img.ID, img.BoundingBox, img, boundingBoxField)
+		})
+	case DatasetSatellite:
+		return es.db.EachSatelliteImagery(ctx, vineyardID, filter, func(s *model.SatelliteData) error {
+			return writeFeature(fw, s.ID, s.BoundingBox, s, boundingBoxField)
+		})
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownDataset, dataset)
+	}
+}
+
+func writeFeature(fw *geo.FeatureWriter, id int, geometry geo.Geometry, record interface{}, geometryField string) error {
+	props, err := geo.Properties(record, geometryField)
+	if err != nil {
+		return fmt.Errorf("encoding feature %d: %w", id, err)
+	}
+	return fw.Write(geo.Feature{ID: id, Geometry: geometry, Properties: props})
+}
@@ -0,0 +1,587 @@
+/*
+ * harvestforecastservice.go: Forecasts the pick window of each block of a vineyard.
+ * Usage: Three signals each estimate a block's pick date with a spread: its Brix trend reaching the Brix
+ *        its fruit was delivered at in past seasons, season-to-date GDD reaching the GDD of its past
+ *        picks at the recent daily rate, and the day of the season it was picked on before. They are
+ *        combined weighted by the inverse of their variance, widened when they disagree. New weather and
+ *        maturity records schedule a recompute, which stores every forecast that changed so that its
+ *        accuracy can be judged against the actual pick after the season.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+const (
+	// forecastHistorySeasons is how many past seasons of harvests the heat and history signals use.
+	forecastHistorySeasons = 10
+	// forecastRateDays is the number of recent days whose mean GDD is taken as the rate to come.
+	forecastRateDays = 14
+	// forecastMinCoverage is the share of a season's days up to a pick that need weather observations
+	// for its GDD to be used.
+	forecastMinCoverage = 0.9
+	// forecastWindowZ scales the combined standard deviation into the pick window, an 80% interval.
+	forecastWindowZ = 1.28
+	// forecastDebounce is how long triggers are collected before the vineyards they name are
+	// recomputed, so that a batch of ingested weather records causes one recompute.
+	forecastDebounce = time.Minute
+)
+
+type HarvestForecastService interface {
+	Forecast(ctx context.Context, vineyardID int) (*model.VineyardHarvestForecast, error)
+	Recompute(ctx context.Context, vineyardID int, trigger string) (*model.VineyardHarvestForecast, error)
+	History(ctx context.Context, vineyardID, season, blockID int) (*model.HarvestForecastHistory, error)
+	Schedule(vineyardID int, trigger string)
+	RunRecompute(ctx context.Context)
+}
+
+type harvestForecastServiceImpl struct {
+	db      *db.DB
+	climate ClimateService
+	loc     *time.Location
+
+	mu      sync.Mutex
+	pending map[int]string // Vineyards to recompute, with the first trigger since the last run
+}
+
+// NewHarvestForecastService creates the forecast service. Growing degree days come from the climate
+// service; dates are calendar days in the climate time zone.
+func NewHarvestForecastService(db *db.DB, climateService ClimateService, settings climate.Settings) HarvestForecastService {
+	return &harvestForecastServiceImpl{
+		db:      db,
+		climate: climateService,
+		loc:     settings.Location,
+		pending: make(map[int]string),
+	}
+}
+
+// Forecast computes the current season's forecast of each block without storing it.
+func (fs *harvestForecastServiceImpl) Forecast(ctx context.Context, vineyardID int) (*model.VineyardHarvestForecast, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return fs.forecast(ctx, vineyardID)
+}
+
+// Recompute computes the forecast and stores each block's when its estimate, window or confidence
+// differs from the last one stored.
+func (fs *harvestForecastServiceImpl) Recompute(ctx context.Context, vineyardID int, trigger string) (*model.VineyardHarvestForecast, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	forecast, err := fs.forecast(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := fs.db.LatestHarvestForecasts(ctx, vineyardID, forecast.Season)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[int]model.HarvestForecast)
+	for _, f := range stored {
+		latest[blockKey(f.BlockID)] = f
+	}
+	for i := range forecast.Blocks {
+		f := &forecast.Blocks[i]
+		if f.Estimate == nil {
+			continue
+		}
+		if last, ok := latest[blockKey(f.BlockID)]; ok && sameForecast(fs.localForecast(last), *f) {
+			continue
+		}
+		f.Trigger = trigger
+		if err := fs.db.SaveHarvestForecast(ctx, f); err != nil {
+			return nil, err
+		}
+	}
+	return forecast, nil
+}
+
+// sameForecast reports whether two forecasts give the same pick window.
+func sameForecast(a, b model.HarvestForecast) bool {
+	return a.Confidence == b.Confidence && a.Estimate.Equal(*b.Estimate) &&
+		a.WindowStart.Equal(*b.WindowStart) && a.WindowEnd.Equal(*b.WindowEnd)
+}
+
+// History returns the forecasts stored for a season, 0 for the current one, with their error against the
+// first pick of each block that has been picked.
+func (fs *harvestForecastServiceImpl) History(ctx context.Context, vineyardID, season, blockID int) (*model.HarvestForecastHistory, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if _, err := fs.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	latitude := vineyardLatitude(ctx, fs.db, vineyardID)
+	if season == 0 {
+		season = climate.SeasonYear(time.Now().In(fs.loc), latitude)
+	}
+	if err := checkSeason(season); err != nil {
+		return nil, err
+	}
+	stored, err := fs.db.ListHarvestForecasts(ctx, vineyardID, season, blockID)
+	if err != nil {
+		return nil, err
+	}
+	from, to := climate.Season(season, latitude, fs.loc)
+	harvests, err := fs.db.ListHarvestsByDateRange(ctx, vineyardID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	actual := fs.firstPicks(harvests)
+	names, err := fs.blockNames(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &model.HarvestForecastHistory{
+		VineyardID: vineyardID,
+		Season:     season,
+		Forecasts:  []model.HarvestForecast{},
+		Accuracy:   []model.ForecastAccuracy{},
+	}
+	accuracy := make(map[int]*model.ForecastAccuracy)
+	var order []int
+	for _, f := range stored {
+		f = fs.localForecast(f)
+		key := blockKey(f.BlockID)
+		f.BlockName = names[key]
+		if picked, ok := actual[key]; ok {
+			errorDays := int(sampleDay(picked, *f.Estimate))
+			inWindow := !picked.Before(*f.WindowStart) && !picked.After(*f.WindowEnd)
+			f.ActualDate, f.ErrorDays, f.InWindow = &picked, &errorDays, &inWindow
+
+			a, ok := accuracy[key]
+			if !ok {
+				a = &model.ForecastAccuracy{BlockID: f.BlockID, ActualDate: picked}
+				accuracy[key] = a
+				order = append(order, key)
+			}
+			a.Forecasts++
+			a.MeanAbsErrorDays += math.Abs(float64(errorDays))
+			if inWindow {
+				a.InWindowShare++
+			}
+		}
+		history.Forecasts = append(history.Forecasts, f)
+	}
+	for _, key := range order {
+		a := accuracy[key]
+		a.MeanAbsErrorDays = roundTo(a.MeanAbsErrorDays/float64(a.Forecasts), 1)
+		a.InWindowShare = roundTo(a.InWindowShare/float64(a.Forecasts), 2)
+		history.Accuracy = append(history.Accuracy, *a)
+	}
+	return history, nil
+}
+
+// Schedule queues a vineyard for the next recompute. It does not block.
+func (fs *harvestForecastServiceImpl) Schedule(vineyardID int, trigger string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.pending[vineyardID]; !ok {
+		fs.pending[vineyardID] = trigger
+	}
+}
+
+// RunRecompute recomputes the scheduled vineyards every forecastDebounce until ctx is cancelled.
+func (fs *harvestForecastServiceImpl) RunRecompute(ctx context.Context) {
+	ticker := time.NewTicker(forecastDebounce)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fs.mu.Lock()
+		batch := fs.pending
+		fs.pending = make(map[int]string)
+		fs.mu.Unlock()
+		for vineyardID, trigger := range batch {
+			if _, err := fs.Recompute(ctx, vineyardID, trigger); err != nil {
+				log.Printf("Failed to recompute the harvest forecast of vineyard %d: %v", vineyardID, err)
+			}
+		}
+	}
+}
+
+// seasonHeat is the accumulated GDD of each day of a past season with weather observations.
+type seasonHeat map[time.Time]float64
+
+// at returns the GDD accumulated by day, provided enough of the days up to it had observations.
+func (h seasonHeat) at(from, day time.Time) (float64, bool) {
+	observed := 0
+	for d := range h {
+		if !d.After(day) {
+			observed++
+		}
+	}
+	v, ok := h[day]
+	return v, ok && float64(observed) >= forecastMinCoverage*float64(climate.DayCount(from, day))
+}
+
+// forecast computes the forecast of the current season.
+func (fs *harvestForecastServiceImpl) forecast(ctx context.Context, vineyardID int) (*model.VineyardHarvestForecast, error) {
+	if _, err := fs.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	latitude := vineyardLatitude(ctx, fs.db, vineyardID)
+	today := calendarDate(time.Now().In(fs.loc), fs.loc)
+	season := climate.SeasonYear(today, latitude)
+	seasonFrom, _ := climate.Season(season, latitude, fs.loc)
+
+	blocks, err := fs.db.ListBlocks(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := fs.db.ListHarvestsByDateRange(ctx, vineyardID, seasonFrom, today)
+	if err != nil {
+		return nil, err
+	}
+	historyFrom, _ := climate.Season(season-forecastHistorySeasons, latitude, fs.loc)
+	past, err := fs.db.ListHarvestsByDateRange(ctx, vineyardID, historyFrom, seasonFrom.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	samples, err := fs.db.ListMaturitySamplesByDateRange(ctx, vineyardID, seasonFrom, today)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := fs.firstPicks(current)
+	pastPicks := make(map[int]map[int]time.Time) // Block key, then season, to the season's first pick
+	pastBrix := make(map[int][2]float64)         // Block key to the sums of tons·Brix and tons
+	for _, h := range past {
+		h.HarvestDate = calendarDate(h.HarvestDate, fs.loc)
+		key, s := blockKey(h.BlockID), climate.SeasonYear(h.HarvestDate, latitude)
+		if pastPicks[key] == nil {
+			pastPicks[key] = make(map[int]time.Time)
+		}
+		if first, ok := pastPicks[key][s]; !ok || h.HarvestDate.Before(first) {
+			pastPicks[key][s] = h.HarvestDate
+		}
+		if h.Brix != nil {
+			sums := pastBrix[key]
+			pastBrix[key] = [2]float64{sums[0] + *h.Brix*h.Tons, sums[1] + h.Tons}
+		}
+	}
+	readings := make(map[int][]model.MaturitySample)
+	for _, s := range samples {
+		if s.Brix != nil {
+			s.SampleDate = calendarDate(s.SampleDate, fs.loc)
+			readings[blockKey(s.BlockID)] = append(readings[blockKey(s.BlockID)], s)
+		}
+	}
+
+	heat, rate, heatNote, err := fs.currentHeat(ctx, vineyardID, seasonFrom, today)
+	if err != nil {
+		return nil, err
+	}
+	pastHeat := make(map[int]seasonHeat) // By season, read once for all blocks
+
+	result := &model.VineyardHarvestForecast{
+		VineyardID: vineyardID,
+		Season:     season,
+		ComputedAt: time.Now().UTC(),
+		Blocks:     []model.HarvestForecast{},
+	}
+	keys := make([]int, 0, len(blocks)+1)
+	names := make(map[int]string)
+	for _, b := range blocks {
+		keys = append(keys, b.ID)
+		names[b.ID] = b.Name
+	}
+	if len(readings[0]) > 0 || len(pastPicks[0]) > 0 || !picked[0].IsZero() {
+		keys = append(keys, 0)
+	}
+	for _, key := range keys {
+		f := model.HarvestForecast{
+			VineyardID: vineyardID,
+			BlockName:  names[key],
+			Season:     season,
+			ComputedAt: result.ComputedAt,
+			Signals:    []model.ForecastSignal{},
+		}
+		if key != 0 {
+			id := key
+			f.BlockID = &id
+		}
+		if first, ok := picked[key]; ok {
+			f.HarvestedOn = &first
+			result.Blocks = append(result.Blocks, f)
+			continue
+		}
+
+		target := DefaultTargetBrix
+		if sums := pastBrix[key]; sums[1] > 0 {
+			target = roundTo(sums[0]/sums[1], 1)
+		}
+		if signal, ok := maturitySignal(readings[key], target, today); ok {
+			f.Signals = append(f.Signals, signal)
+		}
+
+		var gdds, offsets []float64
+		for s, day := range pastPicks[key] {
+			from, _ := climate.Season(s, latitude, fs.loc)
+			offsets = append(offsets, sampleDay(from, day))
+			if heat == nil {
+				continue
+			}
+			h, ok := pastHeat[s]
+			if !ok {
+				if h, err = fs.seasonHeat(ctx, vineyardID, from); err != nil {
+					return nil, err
+				}
+				pastHeat[s] = h
+			}
+			if gdd, ok := h.at(from, day); ok {
+				gdds = append(gdds, gdd)
+			}
+		}
+		if heat != nil {
+			if signal, ok := heatSignal(gdds, *heat, rate, today); ok {
+				f.Signals = append(f.Signals, signal)
+			}
+		}
+		if signal, ok := historySignal(offsets, seasonFrom, today); ok {
+			f.Signals = append(f.Signals, signal)
+		}
+		combineSignals(&f, today)
+		if f.Estimate == nil {
+			f.Note = "no maturity samples this season and no earlier harvests to forecast from"
+			if heatNote != "" {
+				f.Note += "; " + heatNote
+			}
+		}
+		result.Blocks = append(result.Blocks, f)
+	}
+	return result, nil
+}
+
+// currentHeat returns the season's GDD to date and the mean daily GDD of the recent days, or a note
+// explaining why heat cannot be used.
+func (fs *harvestForecastServiceImpl) currentHeat(ctx context.Context, vineyardID int, from, today time.Time) (*float64, float64, string, error) {
+	report, err := fs.climate.GrowingDegreeDays(ctx, vineyardID, model.GDDQuery{Budbreak: from, From: from, To: today})
+	if errors.Is(err, ErrNoBoundingBox) {
+		return nil, 0, "the vineyard has no bounding box for heat accumulation", nil
+	}
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if coverage(report) < forecastMinCoverage {
+		return nil, 0, "too few weather observations this season for heat accumulation", nil
+	}
+	recent := report.Days
+	if len(recent) > forecastRateDays {
+		recent = recent[len(recent)-forecastRateDays:]
+	}
+	var rate float64
+	for _, d := range recent {
+		rate += d.GDD
+	}
+	if len(recent) > 0 {
+		rate /= float64(len(recent))
+	}
+	return &report.SeasonToDate, rate, "", nil
+}
+
+// seasonHeat reads the accumulated GDD of each day of the past season starting on from.
+func (fs *harvestForecastServiceImpl) seasonHeat(ctx context.Context, vineyardID int, from time.Time) (seasonHeat, error) {
+	report, err := fs.climate.GrowingDegreeDays(ctx, vineyardID, model.GDDQuery{Budbreak: from, From: from, To: from.AddDate(1, 0, -1)})
+	if err != nil {
+		return nil, err
+	}
+	h := make(seasonHeat)
+	for _, d := range report.Days {
+		h[d.Date] = d.Accumulated
+	}
+	return h, nil
+}
+
+// coverage returns the share of a report's days with weather observations.
+func coverage(report *model.GrowingDegreeDays) float64 {
+	days := len(report.Days) + report.MissingDays
+	if days == 0 {
+		return 0
+	}
+	return float64(len(report.Days)) / float64(days)
+}
+
+// maturitySignal projects the Brix trend of this season's readings to the target.
+func maturitySignal(readings []model.MaturitySample, target float64, today time.Time) (model.ForecastSignal, bool) {
+	if len(readings) == 0 {
+		return model.ForecastSignal{}, false
+	}
+	trend := fitBlockTrend(readings, target)
+	switch {
+	case trend.ReachedOn != nil:
+		return model.ForecastSignal{
+			Method:     model.ForecastSignalMaturity,
+			Date:       today,
+			SpreadDays: 2,
+			Detail:     fmt.Sprintf("reached %g °Brix on %s", target, trend.ReachedOn.Format("2006-01-02")),
+		}, true
+	case trend.ProjectedDate != nil:
+		date := laterOf(*trend.ProjectedDate, today)
+		return model.ForecastSignal{
+			Method:     model.ForecastSignalMaturity,
+			Date:       date,
+			SpreadDays: roundTo(2+0.2*sampleDay(today, date), 1),
+			Detail: fmt.Sprintf("%s Brix trend of %d samples (r² %.2f) reaches %g °Brix", trend.Method, trend.Samples,
+				*trend.RSquared, target),
+		}, true
+	}
+	return model.ForecastSignal{}, false
+}
+
+// heatSignal projects the season's GDD at the recent rate to the mean GDD of past picks.
+func heatSignal(gdds []float64, current, rate float64, today time.Time) (model.ForecastSignal, bool) {
+	if len(gdds) == 0 {
+		return model.ForecastSignal{}, false
+	}
+	target, spread := meanSpread(gdds, 0.1)
+	perDay := math.Max(rate, 1) // Spreads in days would explode as the rate nears zero
+	signal := model.ForecastSignal{Method: model.ForecastSignalHeat, Date: today}
+	if current < target {
+		if rate < 1 {
+			return model.ForecastSignal{}, false // Heat accumulation has stalled
+		}
+		days := (target - current) / rate
+		signal.Date = today.AddDate(0, 0, int(math.Round(days)))
+		spread += 0.1 * days * rate
+	}
+	signal.SpreadDays = roundTo(math.Max(3, spread/perDay), 1)
+	signal.Detail = fmt.Sprintf("%.0f of %.0f GDD accumulated at past picks over %d seasons, %.1f GDD a day recently",
+		current, target, len(gdds), rate)
+	return signal, true
+}
+
+// historySignal places this season's pick on the mean day of the season of past picks.
+func historySignal(offsets []float64, seasonFrom, today time.Time) (model.ForecastSignal, bool) {
+	if len(offsets) == 0 {
+		return model.ForecastSignal{}, false
+	}
+	mean, spread := meanSpread(offsets, 0)
+	if len(offsets) == 1 {
+		spread = 7
+	}
+	return model.ForecastSignal{
+		Method:     model.ForecastSignalHistory,
+		Date:       laterOf(seasonFrom.AddDate(0, 0, int(math.Round(mean))), today),
+		SpreadDays: roundTo(math.Max(4, spread), 1),
+		Detail:     fmt.Sprintf("first picked on day %.0f of the season on average over %d seasons", mean+1, len(offsets)),
+	}, true
+}
+
+// combineSignals sets a forecast's estimate, window and confidence from its signals.
+func combineSignals(f *model.HarvestForecast, today time.Time) {
+	if len(f.Signals) == 0 {
+		return
+	}
+	var weights, mean float64
+	for _, s := range f.Signals {
+		w := 1 / (s.SpreadDays * s.SpreadDays)
+		weights += w
+		mean += w * sampleDay(today, s.Date)
+	}
+	mean /= weights
+	var disagreement float64
+	for _, s := range f.Signals {
+		d := sampleDay(today, s.Date) - mean
+		disagreement += d * d / (s.SpreadDays * s.SpreadDays)
+	}
+	spread := math.Max(math.Sqrt(1/weights), math.Sqrt(disagreement/weights))
+	half := int(math.Ceil(forecastWindowZ * spread))
+
+	estimate := today.AddDate(0, 0, int(math.Round(mean)))
+	start := laterOf(estimate.AddDate(0, 0, -half), today)
+	end := estimate.AddDate(0, 0, half)
+	spread = roundTo(spread, 1)
+	f.Estimate, f.WindowStart, f.WindowEnd, f.SpreadDays = &estimate, &start, &end, &spread
+	switch {
+	case spread <= 4 && len(f.Signals) > 1:
+		f.Confidence = model.ForecastConfidenceHigh
+	case spread <= 8:
+		f.Confidence = model.ForecastConfidenceMedium
+	default:
+		f.Confidence = model.ForecastConfidenceLow
+	}
+}
+
+// meanSpread returns the mean of values and their sample standard deviation; with a single value the
+// spread is share of the mean.
+func meanSpread(values []float64, share float64) (float64, float64) {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if len(values) == 1 {
+		return mean, share * mean
+	}
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sum / float64(len(values)-1))
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return b
+	}
+	return a
+}
+
+// firstPicks returns the first harvest date of each block key.
+func (fs *harvestForecastServiceImpl) firstPicks(harvests []model.Harvest) map[int]time.Time {
+	first := make(map[int]time.Time)
+	for _, h := range harvests {
+		date, key := calendarDate(h.HarvestDate, fs.loc), blockKey(h.BlockID)
+		if d, ok := first[key]; !ok || date.Before(d) {
+			first[key] = date
+		}
+	}
+	return first
+}
+
+func (fs *harvestForecastServiceImpl) blockNames(ctx context.Context, vineyardID int) (map[int]string, error) {
+	blocks, err := fs.db.ListBlocks(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string)
+	for _, b := range blocks {
+		names[b.ID] = b.Name
+	}
+	return names, nil
+}
+
+// localForecast returns a stored forecast with its dates, read from DATE columns, in the climate time zone.
+func (fs *harvestForecastServiceImpl) localForecast(f model.HarvestForecast) model.HarvestForecast {
+	estimate, start, end := calendarDate(*f.Estimate, fs.loc), calendarDate(*f.WindowStart, fs.loc), calendarDate(*f.WindowEnd, fs.loc)
+	f.Estimate, f.WindowStart, f.WindowEnd = &estimate, &start, &end
+	return f
+}
+
+// blockKey returns a block ID, or 0 for records outside any block.
+func blockKey(blockID *int) int {
+	if blockID == nil {
+		return 0
+	}
+	return *blockID
+}
@@ -0,0 +1,486 @@
+/*
+ * harvestservice.go: Harvest records and the yield of vineyards and their blocks.
+ * Usage: Each pick of a block is recorded with its tons, bins, crew, destination winery and the fruit's
+ *        Brix and pH at delivery. SeasonYield totals a season's harvests per block and relates them to
+ *        the block's area, from its boundary, and vine count, from its row and vine spacing, alongside the
+ *        season's growing degree days, pest observations and soil moisture. CompareSeasons does so for a
+ *        run of seasons and correlates yield with each of those.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// MaxYieldSeasons caps the number of seasons one comparison covers.
+const MaxYieldSeasons = 20
+
+// Unit conversions for yields.
+const (
+	squareMetresPerAcre = 4046.8564224
+	kilogramsPerTon     = 907.18474 // Short ton
+)
+
+// ErrInvalidHarvest is returned for harvests with a missing date, a non-positive weight or out-of-range
+// values, and for yield queries over an unusable range of seasons.
+var ErrInvalidHarvest = errors.New("invalid harvest")
+
+type HarvestService interface {
+	CreateHarvest(ctx context.Context, harvest *model.Harvest) error
+	GetHarvest(ctx context.Context, id int) (*model.Harvest, error)
+	UpdateHarvest(ctx context.Context, harvest *model.Harvest) error
+	DeleteHarvest(ctx context.Context, id int) error
+	ListHarvests(ctx context.Context, vineyardID, blockID int) ([]model.Harvest, error)
+	ListHarvestsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.Harvest, error)
+	SeasonYield(ctx context.Context, vineyardID, season int) (*model.SeasonYield, error)
+	CompareSeasons(ctx context.Context, vineyardID, from, to int) (*model.YieldComparison, error)
+}
+
+type harvestServiceImpl struct {
+	db      *db.DB
+	climate ClimateService
+	sprays  SprayService
+	loc     *time.Location
+}
+
+// NewHarvestService creates the harvest service. Harvest dates are calendar days in the climate time
+// zone, and season growing degree days come from the climate service. Harvests dated inside the
+// pre-harvest interval of a spray application are refused.
+func NewHarvestService(db *db.DB, climateService ClimateService, settings climate.Settings, sprays SprayService) HarvestService {
+	return &harvestServiceImpl{db: db, climate: climateService, sprays: sprays, loc: settings.Location}
+}
+
+func (hs *harvestServiceImpl) CreateHarvest(ctx context.Context, harvest *model.Harvest) error {
+	if harvest == nil {
+		return errors.New("cannot create a nil harvest")
+	}
+	if harvest.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := hs.validateHarvest(harvest); err != nil {
+		return err
+	}
+	if _, err := hs.db.GetVineyard(ctx, harvest.VineyardID); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, hs.db, harvest.VineyardID, harvest.BlockID); err != nil {
+		return err
+	}
+	if err := hs.sprays.CheckHarvest(ctx, harvest.VineyardID, harvest.BlockID, harvest.HarvestDate); err != nil {
+		return err
+	}
+	return hs.db.SaveHarvest(ctx, harvest)
+}
+
+func (hs *harvestServiceImpl) GetHarvest(ctx context.Context, id int) (*model.Harvest, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid harvest ID")
+	}
+	harvest, err := hs.db.GetHarvest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	harvest.HarvestDate = calendarDate(harvest.HarvestDate, hs.loc)
+	return harvest, nil
+}
+
+func (hs *harvestServiceImpl) UpdateHarvest(ctx context.Context, harvest *model.Harvest) error {
+	if harvest == nil {
+		return errors.New("cannot update a nil harvest")
+	}
+	if harvest.ID <= 0 {
+		return errors.New("invalid harvest ID")
+	}
+	if err := hs.validateHarvest(harvest); err != nil {
+		return err
+	}
+	existing, err := hs.db.GetHarvest(ctx, harvest.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, hs.db, existing.VineyardID, harvest.BlockID); err != nil {
+		return err
+	}
+	if err := hs.sprays.CheckHarvest(ctx, existing.VineyardID, harvest.BlockID, harvest.HarvestDate); err != nil {
+		return err
+	}
+	return hs.db.UpdateHarvest(ctx, harvest)
+}
+
+func (hs *harvestServiceImpl) DeleteHarvest(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid harvest ID")
+	}
+	return hs.db.DeleteHarvest(ctx, id)
+}
+
+// ListHarvests returns a vineyard's harvests by date, only those of one block when blockID is not zero.
+func (hs *harvestServiceImpl) ListHarvests(ctx context.Context, vineyardID, blockID int) ([]model.Harvest, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	harvests, err := hs.db.ListHarvests(ctx, vineyardID, blockID)
+	if err != nil {
+		return nil, err
+	}
+	return hs.localDates(harvests), nil
+}
+
+func (hs *harvestServiceImpl) ListHarvestsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.Harvest, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	harvests, err := hs.db.ListHarvestsByDateRange(ctx, vineyardID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return hs.localDates(harvests), nil
+}
+
+// localDates returns harvest dates, read from a DATE column, as midnight in the climate time zone.
+func (hs *harvestServiceImpl) localDates(harvests []model.Harvest) []model.Harvest {
+	for i := range harvests {
+		harvests[i].HarvestDate = calendarDate(harvests[i].HarvestDate, hs.loc)
+	}
+	return harvests
+}
+
+func (hs *harvestServiceImpl) validateHarvest(harvest *model.Harvest) error {
+	if harvest.HarvestDate.IsZero() {
+		return fmt.Errorf("%w: harvestDate is required", ErrInvalidHarvest)
+	}
+	harvest.HarvestDate = calendarDate(harvest.HarvestDate, hs.loc)
+	switch {
+	case harvest.Tons <= 0:
+		return fmt.Errorf("%w: tons must be positive", ErrInvalidHarvest)
+	case harvest.Bins != nil && *harvest.Bins < 0:
+		return fmt.Errorf("%w: bins must not be negative", ErrInvalidHarvest)
+	case harvest.Brix != nil && (*harvest.Brix < 0 || *harvest.Brix > 40):
+		return fmt.Errorf("%w: brix must be between 0 and 40", ErrInvalidHarvest)
+	case harvest.PH != nil && (*harvest.PH < 2 || *harvest.PH > 5):
+		return fmt.Errorf("%w: ph must be between 2 and 5", ErrInvalidHarvest)
+	}
+	harvest.Crew = strings.TrimSpace(harvest.Crew)
+	harvest.DestinationWinery = strings.TrimSpace(harvest.DestinationWinery)
+	return nil
+}
+
+// SeasonYield reports a season's harvest; season 0 is the current one.
+func (hs *harvestServiceImpl) SeasonYield(ctx context.Context, vineyardID, season int) (*model.SeasonYield, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if _, err := hs.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	latitude := vineyardLatitude(ctx, hs.db, vineyardID)
+	if season == 0 {
+		season = climate.SeasonYear(time.Now().In(hs.loc), latitude)
+	}
+	if err := checkSeason(season); err != nil {
+		return nil, err
+	}
+	return hs.seasonYield(ctx, vineyardID, season, latitude)
+}
+
+// CompareSeasons reports the seasons from from to to and correlates their yield with each season's
+// growing degree days, pest observations and mean soil moisture. to defaults to the current season and
+// from to four seasons before it.
+func (hs *harvestServiceImpl) CompareSeasons(ctx context.Context, vineyardID, from, to int) (*model.YieldComparison, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if _, err := hs.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	latitude := vineyardLatitude(ctx, hs.db, vineyardID)
+	if to == 0 {
+		to = climate.SeasonYear(time.Now().In(hs.loc), latitude)
+	}
+	if from == 0 {
+		from = to - 4
+	}
+	for _, season := range []int{from, to} {
+		if err := checkSeason(season); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case from > to:
+		return nil, fmt.Errorf("%w: from season is after to season", ErrInvalidHarvest)
+	case to-from+1 > MaxYieldSeasons:
+		return nil, fmt.Errorf("%w: at most %d seasons can be compared", ErrInvalidHarvest, MaxYieldSeasons)
+	}
+
+	comparison := &model.YieldComparison{VineyardID: vineyardID, From: from, To: to, Yield: "tonsPerAcre"}
+	for season := from; season <= to; season++ {
+		sy, err := hs.seasonYield(ctx, vineyardID, season, latitude)
+		if err != nil {
+			return nil, err
+		}
+		if sy.Tons > 0 && sy.TonsPerAcre == nil {
+			comparison.Yield = "tons"
+		}
+		comparison.Seasons = append(comparison.Seasons, *sy)
+	}
+
+	// Seasons without harvests are skipped rather than counted as failed crops
+	var yields, gdd, pests, moisture []float64
+	var gddYields, moistureYields []float64
+	for _, sy := range comparison.Seasons {
+		if sy.Tons == 0 {
+			continue
+		}
+		y := sy.Tons
+		if comparison.Yield == "tonsPerAcre" {
+			y = *sy.TonsPerAcre
+		}
+		yields = append(yields, y)
+		pests = append(pests, float64(sy.PestPressure.Observations))
+		if sy.GDD != nil {
+			gddYields, gdd = append(gddYields, y), append(gdd, *sy.GDD)
+		}
+		if sy.SoilMoisture != nil {
+			moistureYields, moisture = append(moistureYields, y), append(moisture, sy.SoilMoisture.Mean)
+		}
+	}
+	comparison.Correlations = model.YieldCorrelations{
+		GDD:              correlation(gddYields, gdd),
+		PestObservations: correlation(yields, pests),
+		SoilMoisture:     correlation(moistureYields, moisture),
+	}
+	return comparison, nil
+}
+
+// checkSeason rejects season years that cannot be meant.
+func checkSeason(season int) error {
+	if season < earliestPlantingYear || season > time.Now().Year()+1 {
+		return fmt.Errorf("%w: season %d is out of range", ErrInvalidHarvest, season)
+	}
+	return nil
+}
+
+// vineyardLatitude returns the latitude of a vineyard's bounding box, or 0, the northern hemisphere,
+// without one.
+func vineyardLatitude(ctx context.Context, database *db.DB, vineyardID int) float64 {
+	if geometry, err := database.GetVineyardGeometry(ctx, vineyardID); err == nil {
+		return geometry.Centroid.Lat
+	}
+	return 0
+}
+
+func (hs *harvestServiceImpl) seasonYield(ctx context.Context, vineyardID, season int, latitude float64) (*model.SeasonYield, error) {
+	from, to := climate.Season(season, latitude, hs.loc)
+	harvests, err := hs.ListHarvestsByDateRange(ctx, vineyardID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := hs.db.ListBlocks(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	areas, err := hs.db.BlockAreas(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	end := to.AddDate(0, 0, 1)
+	pressure, err := hs.db.PestPressureByBlock(ctx, vineyardID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	moisture, err := hs.db.SoilMoistureByBlock(ctx, vineyardID, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	sy := &model.SeasonYield{
+		VineyardID:   vineyardID,
+		Season:       season,
+		From:         from,
+		To:           to,
+		PestPressure: model.PestPressure{BySeverity: map[string]int{}},
+		Blocks:       []model.BlockYield{},
+	}
+	sy.GDD, err = hs.seasonGDD(ctx, vineyardID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every block, in name order, then harvests and records outside any block
+	byBlock := make(map[int][]model.Harvest)
+	for _, h := range harvests {
+		key := 0
+		if h.BlockID != nil {
+			key = *h.BlockID
+		}
+		byBlock[key] = append(byBlock[key], h)
+	}
+	var acres float64
+	allAreas := len(byBlock[0]) == 0
+	for _, b := range blocks {
+		by := blockYield(byBlock[b.ID], pressure[b.ID], moisture[b.ID])
+		id := b.ID
+		by.BlockID, by.BlockName, by.Varietal = &id, b.Name, b.Varietal
+		if area, ok := areas[b.ID]; ok {
+			by.Acres = roundedPtr(area/squareMetresPerAcre, 2)
+			if b.RowSpacing != nil && b.VineSpacing != nil {
+				vines := int(math.Round(area / (*b.RowSpacing * *b.VineSpacing)))
+				by.Vines = &vines
+			}
+		}
+		if by.Tons > 0 {
+			sy.Tons += by.Tons
+			if by.Acres != nil && *by.Acres > 0 {
+				acres += *by.Acres
+				by.TonsPerAcre = roundedPtr(by.Tons / *by.Acres, 2)
+			} else {
+				allAreas = false
+			}
+			if by.Vines != nil && *by.Vines > 0 {
+				by.KgPerVine = roundedPtr(by.Tons*kilogramsPerTon/float64(*by.Vines), 2)
+			}
+		}
+		sy.Blocks = append(sy.Blocks, by)
+	}
+	if len(byBlock[0]) > 0 || pressure[0] != nil || moisture[0] != nil {
+		by := blockYield(byBlock[0], pressure[0], moisture[0])
+		sy.Tons += by.Tons
+		sy.Blocks = append(sy.Blocks, by)
+	}
+
+	// A vineyard-wide yield per acre needs the area of every harvested block
+	if sy.Tons > 0 && allAreas {
+		sy.Acres = roundedPtr(acres, 2)
+		sy.TonsPerAcre = roundedPtr(sy.Tons/acres, 2)
+	}
+	sy.Tons = roundTo(sy.Tons, 2)
+	for _, p := range pressure {
+		sy.PestPressure.Observations += p.Observations
+		for severity, n := range p.BySeverity {
+			sy.PestPressure.BySeverity[severity] += n
+		}
+	}
+	for _, m := range moisture {
+		sy.SoilMoisture = mergeMoisture(sy.SoilMoisture, m)
+	}
+	if sy.SoilMoisture != nil {
+		sy.SoilMoisture.Mean = roundTo(sy.SoilMoisture.Mean, 2)
+	}
+	return sy, nil
+}
+
+// seasonGDD returns the growing degree days of a season up to today, or nil for a season that has not
+// started or a vineyard without a bounding box.
+func (hs *harvestServiceImpl) seasonGDD(ctx context.Context, vineyardID int, from, to time.Time) (*float64, error) {
+	today := calendarDate(time.Now().In(hs.loc), hs.loc)
+	if from.After(today) {
+		return nil, nil
+	}
+	if to.After(today) {
+		to = today
+	}
+	report, err := hs.climate.GrowingDegreeDays(ctx, vineyardID, model.GDDQuery{From: to, To: to, Budbreak: from})
+	if errors.Is(err, ErrNoBoundingBox) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report.SeasonToDate, nil
+}
+
+// blockYield totals the harvests of one block, with its pest pressure and soil moisture when it has any.
+func blockYield(harvests []model.Harvest, pressure *model.PestPressure, moisture *model.SoilMoisture) model.BlockYield {
+	by := model.BlockYield{Harvests: len(harvests), PestPressure: model.PestPressure{BySeverity: map[string]int{}}}
+	if pressure != nil {
+		by.PestPressure = *pressure
+	}
+	if moisture != nil {
+		m := *moisture
+		m.Mean = roundTo(m.Mean, 2)
+		by.SoilMoisture = &m
+	}
+	var brixSum, brixTons, phSum, phTons float64
+	for _, h := range harvests {
+		by.Tons += h.Tons
+		if h.Bins != nil {
+			by.Bins += *h.Bins
+		}
+		if h.Brix != nil {
+			brixSum, brixTons = brixSum+*h.Brix*h.Tons, brixTons+h.Tons
+		}
+		if h.PH != nil {
+			phSum, phTons = phSum+*h.PH*h.Tons, phTons+h.Tons
+		}
+	}
+	by.Tons = roundTo(by.Tons, 2)
+	if brixTons > 0 {
+		by.Brix = roundedPtr(brixSum/brixTons, 1)
+	}
+	if phTons > 0 {
+		by.PH = roundedPtr(phSum/phTons, 2)
+	}
+	return by
+}
+
+// mergeMoisture combines two soil moisture summaries; either may be nil.
+func mergeMoisture(a, b *model.SoilMoisture) *model.SoilMoisture {
+	if a == nil || b == nil {
+		if b == nil {
+			return a
+		}
+		m := *b
+		return &m
+	}
+	n := a.Samples + b.Samples
+	return &model.SoilMoisture{
+		Samples: n,
+		Mean:    (a.Mean*float64(a.Samples) + b.Mean*float64(b.Samples)) / float64(n),
+		Min:     math.Min(a.Min, b.Min),
+		Max:     math.Max(a.Max, b.Max),
+	}
+}
+
+// correlation returns the Pearson coefficient of xs and ys, or nil with fewer than three pairs or when
+// either does not vary.
+func correlation(xs, ys []float64) *float64 {
+	n := float64(len(xs))
+	if len(xs) < 3 {
+		return nil
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+	return roundedPtr(cov/math.Sqrt(varX*varY), 3)
+}
+
+func roundedPtr(v float64, places int) *float64 {
+	r := roundTo(v, places)
+	return &r
+}
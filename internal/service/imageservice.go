@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
 	"github.com/sthompson732/viticulture-harvester-app/internal/storage"
 )
@@ -33,11 +34,11 @@ type ImageService interface {
 // imageServiceImpl is the concrete implementation of ImageService using a database and storage service.
 type imageServiceImpl struct {
 	db      *db.DB
-	storage *storage.StorageService
+	storage storage.BlobStore
 }
 
 // NewImageService constructs a new ImageService given a database and a storage service instance.
-func NewImageService(db *db.DB, storage *storage.StorageService) ImageService {
+func NewImageService(db *db.DB, storage storage.BlobStore) ImageService {
 	return &imageServiceImpl{
 		db:      db,
 		storage: storage,
@@ -49,13 +50,22 @@ func (is *imageServiceImpl) SaveImage(ctx context.Context, image *model.Image, i
 	if image == nil {
 		return errors.New("cannot save nil image")
 	}
-
-	// Upload image data to cloud storage and retrieve the URL
-	imageURL, err := is.storage.UploadFile(ctx, "vineyard_images/"+time.Now().Format("20060102_150405")+"_"+image.URL, imageData)
-	if err != nil {
+	if err := geo.ValidateField("boundingBox", image.BoundingBox); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, is.db, image.VineyardID, image.BlockID); err != nil {
 		return err
 	}
-	image.URL = imageURL // Update image URL with the URL from storage
+
+	// Upload image data to cloud storage and retrieve the URL. Without image data the
+	// image is assumed to be hosted elsewhere and image.URL is stored as given.
+	if imageData != nil {
+		imageURL, err := is.storage.UploadFile(ctx, "vineyard_images/"+time.Now().Format("20060102_150405")+"_"+image.URL, imageData)
+		if err != nil {
+			return err
+		}
+		image.URL = imageURL // Update image URL with the URL from storage
+	}
 
 	// Save image metadata in the database
 	return is.db.SaveImage(ctx, image)
@@ -77,6 +87,12 @@ func (is *imageServiceImpl) UpdateImage(ctx context.Context, image *model.Image)
 	if image.ID == 0 {
 		return errors.New("invalid image ID")
 	}
+	if err := geo.ValidateField("boundingBox", image.BoundingBox); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, is.db, image.VineyardID, image.BlockID); err != nil {
+		return err
+	}
 	return is.db.UpdateImage(ctx, image)
 }
 
@@ -0,0 +1,109 @@
+/*
+ * ingestionrunservice.go: Records and lists the history of data source fetches.
+ * Usage: The ingestion service and the local scheduler call StartRun before each fetch and FinishRun after it;
+ *        the /ingestion/runs API reads the history back. Failed runs are sent to webhooks, and a vineyard's
+ *        failed runs are emailed to its subscribers.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/webhook"
+)
+
+// Listing limits for ListRuns.
+const (
+	DefaultRunListLimit = 100
+	MaxRunListLimit     = 1000
+)
+
+type IngestionRunService interface {
+	StartRun(ctx context.Context, run *model.IngestionRun) error
+	FinishRun(ctx context.Context, run *model.IngestionRun, records, httpStatus int, runErr error) error
+	GetRun(ctx context.Context, id int) (*model.IngestionRun, error)
+	ListRuns(ctx context.Context, filter model.IngestionRunFilter) ([]model.IngestionRun, error)
+}
+
+type ingestionRunServiceImpl struct {
+	db            *db.DB
+	notifications NotificationService
+	webhooks      WebhookService
+}
+
+func NewIngestionRunService(db *db.DB, notifications NotificationService, webhooks WebhookService) IngestionRunService {
+	return &ingestionRunServiceImpl{db: db, notifications: notifications, webhooks: webhooks}
+}
+
+// StartRun stores a run in the running state, defaulting its trigger and start time.
+func (rs *ingestionRunServiceImpl) StartRun(ctx context.Context, run *model.IngestionRun) error {
+	if run == nil {
+		return errors.New("cannot start a nil ingestion run")
+	}
+	if run.SourceKey == "" {
+		return errors.New("ingestion run requires a source key")
+	}
+	if run.Trigger == "" {
+		run.Trigger = model.RunTriggerManual
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	run.Status = model.RunStatusRunning
+	return rs.db.CreateIngestionRun(ctx, run)
+}
+
+// FinishRun marks a run succeeded or failed. A zero httpStatus means no response was received.
+func (rs *ingestionRunServiceImpl) FinishRun(ctx context.Context, run *model.IngestionRun, records, httpStatus int, runErr error) error {
+	if run == nil || run.ID == 0 {
+		return errors.New("invalid ingestion run ID")
+	}
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	run.Records = records
+	run.HTTPStatus = nil
+	if httpStatus != 0 {
+		run.HTTPStatus = &httpStatus
+	}
+	run.Status = model.RunStatusSucceeded
+	run.Error = ""
+	if runErr != nil {
+		run.Status = model.RunStatusFailed
+		run.Error = runErr.Error()
+	}
+	if err := rs.db.FinishIngestionRun(ctx, run); err != nil {
+		return err
+	}
+	if runErr != nil {
+		rs.webhooks.Emit(ctx, webhook.EventIngestionFailed, run.VineyardID, run)
+		if _, err := rs.notifications.NotifyIngestionFailure(ctx, run); err != nil {
+			log.Printf("Failed to queue email for ingestion run %d: %v", run.ID, err)
+		}
+	}
+	return nil
+}
+
+func (rs *ingestionRunServiceImpl) GetRun(ctx context.Context, id int) (*model.IngestionRun, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid ingestion run ID")
+	}
+	return rs.db.GetIngestionRun(ctx, id)
+}
+
+func (rs *ingestionRunServiceImpl) ListRuns(ctx context.Context, filter model.IngestionRunFilter) ([]model.IngestionRun, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultRunListLimit
+	}
+	if filter.Limit > MaxRunListLimit {
+		filter.Limit = MaxRunListLimit
+	}
+	return rs.db.ListIngestionRuns(ctx, filter)
+}
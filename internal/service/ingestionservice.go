@@ -0,0 +1,466 @@
+/*
+ * ingestionservice.go: Fetches data from configured external sources and stores it.
+ * Shared by the /fetch-data API handler and the schedulers.
+ * Usage: Call FetchDataFromSource with a key from Config.DataSources to run one ingestion.
+ *        Without a vineyard in FetchOptions the source is called once per vineyard, up to
+ *        IngestionSettings.ParallelIngestions at a time, and a per-vineyard summary is returned.
+ *        The endpoint template is expanded with the vineyard's geometry and the run dates in FetchOptions.
+ *        Sources with a mapping block are decoded by internal/mapping; weather and satellite sources
+ *        without one are decoded directly into their models.
+ *        Requests go through the per-source client from internal/transport, so they are retried
+ *        and stop early while the source's circuit breaker is open.
+ *        Every per-vineyard fetch is recorded in ingestion_runs; RetryRun repeats a failed one.
+ *        Saved weather observations rerun the disease risk models from their first day.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	client "github.com/sthompson732/viticulture-harvester-app/internal/clients"
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
+	"github.com/sthompson732/viticulture-harvester-app/internal/mapping"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/transport"
+)
+
+var (
+	// ErrUnknownSource is returned for keys missing from Config.DataSources.
+	ErrUnknownSource = errors.New("data source configuration not found")
+	// ErrUnsupportedSource is returned for configured sources that have no ingestion logic.
+	ErrUnsupportedSource = errors.New("unsupported data source")
+	// ErrVineyardRequired is returned when the endpoint uses vineyard placeholders but no vineyard was given.
+	ErrVineyardRequired = errors.New("data source endpoint requires a vineyard")
+	// ErrRunNotRetryable is returned by RetryRun for runs that did not fail or have no vineyard to repeat.
+	ErrRunNotRetryable = errors.New("ingestion run cannot be retried")
+)
+
+// FetchOptions selects the vineyard and dates filled into a data source's endpoint template.
+type FetchOptions struct {
+	VineyardID int       // Restricts the run to one vineyard; every vineyard is fetched when zero
+	Date       time.Time // Run date, defaults to now
+	StartDate  time.Time // Defaults to Date minus the data source's window
+	EndDate    time.Time // Defaults to Date
+	Trigger    string    // Recorded on each ingestion run, defaults to manual
+	RetryOf    int       // Run being repeated, if any
+}
+
+// VineyardResult is the outcome of fetching a data source for one vineyard.
+type VineyardResult struct {
+	VineyardID   int    `json:"vineyard_id"`
+	VineyardName string `json:"vineyardName,omitempty"`
+	RunID        int    `json:"runId,omitempty"`
+	Records      int    `json:"records"`
+	Error        string `json:"error,omitempty"`
+}
+
+// IngestionSummary reports a fetch across one or more vineyards.
+type IngestionSummary struct {
+	SourceKey  string           `json:"sourceKey"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Succeeded  int              `json:"succeeded"`
+	Failed     int              `json:"failed"`
+	Records    int              `json:"records"`
+	Vineyards  []VineyardResult `json:"vineyards"`
+}
+
+type IngestionService interface {
+	Supports(sourceKey string) bool
+	FetchDataFromSource(ctx context.Context, sourceKey string, opts FetchOptions) (*IngestionSummary, error)
+	EndpointURL(ctx context.Context, sourceKey string, opts FetchOptions) (string, error)
+	RetryRun(ctx context.Context, runID int) (*IngestionSummary, error)
+}
+
+type ingestionServiceImpl struct {
+	cfg              *config.Config
+	vineyardService  VineyardService
+	weatherService   WeatherService
+	satelliteService SatelliteService
+	soilDataService  SoilDataService
+	pestService      PestService
+	runService       IngestionRunService
+	diseaseService   DiseaseService
+	transport        *transport.Registry
+}
+
+func NewIngestionService(cfg *config.Config, vineyardService VineyardService, weatherService WeatherService,
+	satelliteService SatelliteService, soilDataService SoilDataService, pestService PestService,
+	runService IngestionRunService, diseaseService DiseaseService, registry *transport.Registry) IngestionService {
+	return &ingestionServiceImpl{
+		cfg:              cfg,
+		vineyardService:  vineyardService,
+		weatherService:   weatherService,
+		satelliteService: satelliteService,
+		soilDataService:  soilDataService,
+		pestService:      pestService,
+		runService:       runService,
+		diseaseService:   diseaseService,
+		transport:        registry,
+	}
+}
+
+// Supports reports whether the source key has ingestion logic behind it: either a mapping
+// block in its config, or one of the built-in weather and satellite decoders.
+func (is *ingestionServiceImpl) Supports(sourceKey string) bool {
+	if dataSource, ok := is.cfg.DataSources[sourceKey]; ok && dataSource.Mapping != nil {
+		return true
+	}
+	switch sourceKey {
+	case "weather", "satellite":
+		return true
+	default:
+		return false
+	}
+}
+
+// EndpointURL expands the data source's endpoint template for the given vineyard and dates.
+func (is *ingestionServiceImpl) EndpointURL(ctx context.Context, sourceKey string, opts FetchOptions) (string, error) {
+	dataSource, ok := is.cfg.DataSources[sourceKey]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownSource, sourceKey)
+	}
+
+	var geometry *model.VineyardGeometry
+	if client.NeedsVineyard(dataSource.Endpoint) {
+		if opts.VineyardID == 0 {
+			return "", fmt.Errorf("%w: %s", ErrVineyardRequired, sourceKey)
+		}
+		var err error
+		if geometry, err = is.vineyardService.GetVineyardGeometry(ctx, opts.VineyardID); err != nil {
+			return "", err
+		}
+	}
+	return expandEndpoint(sourceKey, dataSource, opts, geometry)
+}
+
+func expandEndpoint(sourceKey string, dataSource config.DataSourceConfig, opts FetchOptions,
+	geometry *model.VineyardGeometry) (string, error) {
+	vars, err := client.NewEndpointVars(dataSource, runDate(opts))
+	if err != nil {
+		return "", fmt.Errorf("data source %s: %w", sourceKey, err)
+	}
+	if !opts.StartDate.IsZero() {
+		vars.StartDate = opts.StartDate
+	}
+	if !opts.EndDate.IsZero() {
+		vars.EndDate = opts.EndDate
+	}
+	vars.Geometry = geometry
+	return client.ExpandEndpoint(dataSource.Endpoint, vars)
+}
+
+func runDate(opts FetchOptions) time.Time {
+	if opts.Date.IsZero() {
+		return time.Now().UTC()
+	}
+	return opts.Date
+}
+
+// FetchDataFromSource calls the configured source for each vineyard, or only for opts.VineyardID,
+// and saves the results. Per-vineyard failures are reported in the summary, not as an error.
+func (is *ingestionServiceImpl) FetchDataFromSource(ctx context.Context, sourceKey string, opts FetchOptions) (*IngestionSummary, error) {
+	dataSource, ok := is.cfg.DataSources[sourceKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSource, sourceKey)
+	}
+	if !is.Supports(sourceKey) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSource, sourceKey)
+	}
+	opts.Date = runDate(opts)
+
+	// Resolve the window once so every vineyard's run records the same dates
+	vars, err := client.NewEndpointVars(dataSource, opts.Date)
+	if err != nil {
+		return nil, fmt.Errorf("data source %s: %w", sourceKey, err)
+	}
+	if opts.StartDate.IsZero() {
+		opts.StartDate = vars.StartDate
+	}
+	if opts.EndDate.IsZero() {
+		opts.EndDate = vars.EndDate
+	}
+	if opts.Trigger == "" {
+		opts.Trigger = model.RunTriggerManual
+	}
+
+	var vineyards []model.Vineyard
+	if opts.VineyardID != 0 {
+		vineyard, err := is.vineyardService.GetVineyard(ctx, opts.VineyardID)
+		if err != nil {
+			return nil, err
+		}
+		vineyards = []model.Vineyard{*vineyard}
+	} else {
+		var err error
+		if vineyards, err = is.vineyardService.ListVineyards(ctx); err != nil {
+			return nil, fmt.Errorf("listing vineyards: %w", err)
+		}
+	}
+
+	summary := &IngestionSummary{
+		SourceKey: sourceKey,
+		StartedAt: time.Now().UTC(),
+		Vineyards: make([]VineyardResult, len(vineyards)),
+	}
+
+	workers := is.cfg.IngestionSettings.ParallelIngestions
+	if workers <= 0 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, vineyard := range vineyards {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, vineyard model.Vineyard) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			summary.Vineyards[i] = is.runForVineyard(ctx, sourceKey, dataSource, vineyard, opts)
+		}(i, vineyard)
+	}
+	wg.Wait()
+
+	for _, result := range summary.Vineyards {
+		if result.Error != "" {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+		summary.Records += result.Records
+	}
+	summary.FinishedAt = time.Now().UTC()
+	return summary, nil
+}
+
+// RetryRun repeats a failed run for its vineyard and window, recording the new run as a retry of it.
+func (is *ingestionServiceImpl) RetryRun(ctx context.Context, runID int) (*IngestionSummary, error) {
+	run, err := is.runService.GetRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != model.RunStatusFailed || run.VineyardID == nil {
+		return nil, fmt.Errorf("%w: run %d is %s", ErrRunNotRetryable, runID, run.Status)
+	}
+
+	opts := FetchOptions{
+		VineyardID: *run.VineyardID,
+		Trigger:    model.RunTriggerRetry,
+		RetryOf:    run.ID,
+	}
+	if run.WindowStart != nil && run.WindowEnd != nil {
+		opts.StartDate, opts.EndDate = *run.WindowStart, *run.WindowEnd
+		opts.Date = *run.WindowEnd
+	}
+	return is.FetchDataFromSource(ctx, run.SourceKey, opts)
+}
+
+// runForVineyard fetches a vineyard's data inside a recorded ingestion run.
+func (is *ingestionServiceImpl) runForVineyard(ctx context.Context, sourceKey string, dataSource config.DataSourceConfig,
+	vineyard model.Vineyard, opts FetchOptions) VineyardResult {
+	result := VineyardResult{VineyardID: vineyard.ID, VineyardName: vineyard.Name}
+
+	vineyardID := vineyard.ID
+	run := &model.IngestionRun{
+		SourceKey:   sourceKey,
+		VineyardID:  &vineyardID,
+		Trigger:     opts.Trigger,
+		WindowStart: &opts.StartDate,
+		WindowEnd:   &opts.EndDate,
+	}
+	if opts.RetryOf != 0 {
+		run.RetryOf = &opts.RetryOf
+	}
+	if err := is.runService.StartRun(ctx, run); err != nil {
+		result.Error = fmt.Sprintf("recording ingestion run: %v", err)
+		return result
+	}
+	result.RunID = run.ID
+
+	n, httpStatus, err := is.fetchForVineyard(ctx, sourceKey, dataSource, vineyard.ID, opts)
+	result.Records = n
+	if err != nil {
+		result.Error = err.Error()
+	}
+	// The run must be closed even when the request context was cancelled mid-fetch
+	if finishErr := is.runService.FinishRun(context.WithoutCancel(ctx), run, n, httpStatus, err); finishErr != nil {
+		log.Printf("Failed to record the end of ingestion run %d: %v", run.ID, finishErr)
+	}
+	return result
+}
+
+// fetchForVineyard runs one request for a vineyard and saves every record it produced.
+// It also returns the provider's HTTP status, or zero when no response was received.
+func (is *ingestionServiceImpl) fetchForVineyard(ctx context.Context, sourceKey string, dataSource config.DataSourceConfig,
+	vineyardID int, opts FetchOptions) (int, int, error) {
+	// The geometry also supplies the location attached to each record, so it is looked up
+	// even when the endpoint does not need it
+	geometry, err := is.vineyardService.GetVineyardGeometry(ctx, vineyardID)
+	if err != nil {
+		if client.NeedsVineyard(dataSource.Endpoint) {
+			return 0, 0, err
+		}
+		geometry = nil
+	}
+
+	endpoint, err := expandEndpoint(sourceKey, dataSource, opts, geometry)
+	if err != nil {
+		return 0, 0, err
+	}
+	body, httpStatus, err := is.fetch(ctx, sourceKey, dataSource, endpoint)
+	if err != nil {
+		return 0, httpStatus, err
+	}
+
+	var records []interface{}
+	if dataSource.Mapping != nil {
+		records, err = mapping.Decode(*dataSource.Mapping, body)
+	} else {
+		records, err = decodeBuiltIn(sourceKey, body)
+	}
+	if err != nil {
+		return 0, httpStatus, fmt.Errorf("decoding %s data: %w", sourceKey, err)
+	}
+
+	var earliestWeather time.Time
+	for i, record := range records {
+		attachVineyard(record, vineyardID, geometry, opts.Date)
+		if err := is.saveRecord(ctx, record); err != nil {
+			return i, httpStatus, fmt.Errorf("saving %s data: %w", sourceKey, err)
+		}
+		if weather, ok := record.(*model.WeatherData); ok {
+			if earliestWeather.IsZero() || weather.ObservationTime.Before(earliestWeather) {
+				earliestWeather = weather.ObservationTime
+			}
+		}
+	}
+
+	// New weather changes the disease risk from its first day on. The records are saved either way,
+	// so a failed model run is only logged. Backfills run the models once when they complete.
+	if !earliestWeather.IsZero() && opts.Trigger != model.RunTriggerBackfill {
+		if err := is.diseaseService.UpdateSince(ctx, vineyardID, earliestWeather); err != nil {
+			log.Printf("Failed to update disease risk for vineyard %d: %v", vineyardID, err)
+		}
+	}
+	return len(records), httpStatus, nil
+}
+
+func (is *ingestionServiceImpl) fetch(ctx context.Context, sourceKey string, dataSource config.DataSourceConfig,
+	endpoint string) ([]byte, int, error) {
+	req, err := client.NewSourceRequest(ctx, dataSource, endpoint)
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating request: %w", err)
+	}
+	if dataSource.APIKey != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+dataSource.APIKey)
+	}
+
+	resp, err := is.transport.Client(sourceKey).Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("fetching data, server returned: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("reading response body: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// decodeBuiltIn handles the sources whose responses already match the model's JSON.
+func decodeBuiltIn(sourceKey string, body []byte) ([]interface{}, error) {
+	switch sourceKey {
+	case "weather":
+		var data model.WeatherData
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+		return []interface{}{&data}, nil
+	case "satellite":
+		var data model.SatelliteData
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+		return []interface{}{&data}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSource, sourceKey)
+	}
+}
+
+// attachVineyard assigns a record to its vineyard. Records without a location of their own get the
+// vineyard's centroid (or bounding box for imagery), and a missing timestamp defaults to the run date.
+func attachVineyard(record interface{}, vineyardID int, geometry *model.VineyardGeometry, observedAt time.Time) {
+	var centroid geo.Point
+	if geometry != nil {
+		centroid = geometry.Centroid
+	}
+
+	switch data := record.(type) {
+	case *model.WeatherData:
+		data.VineyardID = vineyardID
+		if data.Location.IsZero() {
+			data.Location = centroid
+		}
+		if data.ObservationTime.IsZero() {
+			data.ObservationTime = observedAt
+		}
+	case *model.SoilData:
+		data.VineyardID = vineyardID
+		if data.Location.IsZero() {
+			data.Location = centroid
+		}
+		if data.SampledAt.IsZero() {
+			data.SampledAt = observedAt
+		}
+	case *model.PestData:
+		data.VineyardID = vineyardID
+		if data.Location.IsZero() {
+			data.Location = centroid
+		}
+		if data.ObservationDate.IsZero() {
+			data.ObservationDate = observedAt
+		}
+	case *model.SatelliteData:
+		data.VineyardID = vineyardID
+		if len(data.BoundingBox) == 0 && geometry != nil {
+			data.BoundingBox = geometry.Polygon
+		}
+		if data.CapturedAt.IsZero() {
+			data.CapturedAt = observedAt
+		}
+	}
+}
+
+// saveRecord stores a decoded record with the owning service.
+func (is *ingestionServiceImpl) saveRecord(ctx context.Context, record interface{}) error {
+	switch data := record.(type) {
+	case *model.WeatherData:
+		return is.weatherService.CreateWeatherData(ctx, data)
+	case *model.SoilData:
+		return is.soilDataService.CreateSoilData(ctx, data)
+	case *model.PestData:
+		return is.pestService.CreatePestData(ctx, data)
+	case *model.SatelliteData:
+		return is.satelliteService.SaveSatelliteData(ctx, data, nil)
+	default:
+		return fmt.Errorf("unsupported record type %T", record)
+	}
+}
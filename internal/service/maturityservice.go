@@ -0,0 +1,330 @@
+/*
+ * maturityservice.go: Grape maturity samples and the Brix trend of each block.
+ * Usage: Samples record Brix, pH, titratable acidity, YAN and berry weight for a vineyard or one of its
+ *        blocks on a calendar day. Trend fits a curve to each block's Brix readings of the season and
+ *        projects the day the block reaches the target Brix, to plan picking.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/maturity"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// DefaultTargetBrix is the Brix a maturity trend is projected to when the query does not set one.
+const DefaultTargetBrix = 24.0
+
+// ErrInvalidMaturitySample is returned for samples without measurements or with out-of-range values,
+// and for trend queries with an unusable target or range.
+var ErrInvalidMaturitySample = errors.New("invalid maturity sample")
+
+// maturityRanges bounds each measurement to values a grape sample can plausibly have.
+var maturityRanges = []struct {
+	name     string
+	value    func(s *model.MaturitySample) *float64
+	min, max float64
+}{
+	{"brix", func(s *model.MaturitySample) *float64 { return s.Brix }, 0, 40},
+	{"ph", func(s *model.MaturitySample) *float64 { return s.PH }, 2, 5},
+	{"ta", func(s *model.MaturitySample) *float64 { return s.TA }, 0, 40},
+	{"yan", func(s *model.MaturitySample) *float64 { return s.YAN }, 0, 1000},
+	{"berryWeight", func(s *model.MaturitySample) *float64 { return s.BerryWeight }, 0, 10},
+}
+
+type MaturityService interface {
+	CreateSample(ctx context.Context, sample *model.MaturitySample) error
+	GetSample(ctx context.Context, id int) (*model.MaturitySample, error)
+	UpdateSample(ctx context.Context, sample *model.MaturitySample) error
+	DeleteSample(ctx context.Context, id int) error
+	ListSamples(ctx context.Context, vineyardID, blockID int) ([]model.MaturitySample, error)
+	ListSamplesByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.MaturitySample, error)
+	Trend(ctx context.Context, vineyardID int, query model.MaturityTrendQuery) (*model.MaturityTrend, error)
+}
+
+type maturityServiceImpl struct {
+	db        *db.DB
+	loc       *time.Location
+	forecasts HarvestForecastService
+}
+
+// NewMaturityService creates the maturity service. Sample dates are calendar days in the climate time
+// zone. Every change to a sample schedules a harvest forecast recompute.
+func NewMaturityService(db *db.DB, settings climate.Settings, forecasts HarvestForecastService) MaturityService {
+	return &maturityServiceImpl{db: db, loc: settings.Location, forecasts: forecasts}
+}
+
+func (ms *maturityServiceImpl) CreateSample(ctx context.Context, sample *model.MaturitySample) error {
+	if sample == nil {
+		return errors.New("cannot create a nil maturity sample")
+	}
+	if sample.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := ms.validateSample(sample); err != nil {
+		return err
+	}
+	if _, err := ms.db.GetVineyard(ctx, sample.VineyardID); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, ms.db, sample.VineyardID, sample.BlockID); err != nil {
+		return err
+	}
+	if err := ms.db.SaveMaturitySample(ctx, sample); err != nil {
+		return err
+	}
+	ms.forecasts.Schedule(sample.VineyardID, model.ForecastTriggerMaturity)
+	return nil
+}
+
+func (ms *maturityServiceImpl) GetSample(ctx context.Context, id int) (*model.MaturitySample, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid maturity sample ID")
+	}
+	sample, err := ms.db.GetMaturitySample(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sample.SampleDate = calendarDate(sample.SampleDate, ms.loc)
+	return sample, nil
+}
+
+func (ms *maturityServiceImpl) UpdateSample(ctx context.Context, sample *model.MaturitySample) error {
+	if sample == nil {
+		return errors.New("cannot update a nil maturity sample")
+	}
+	if sample.ID <= 0 {
+		return errors.New("invalid maturity sample ID")
+	}
+	if err := ms.validateSample(sample); err != nil {
+		return err
+	}
+	if sample.BlockID != nil {
+		existing, err := ms.db.GetMaturitySample(ctx, sample.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkRecordBlock(ctx, ms.db, existing.VineyardID, sample.BlockID); err != nil {
+			return err
+		}
+	}
+	if err := ms.db.UpdateMaturitySample(ctx, sample); err != nil {
+		return err
+	}
+	ms.forecasts.Schedule(sample.VineyardID, model.ForecastTriggerMaturity)
+	return nil
+}
+
+func (ms *maturityServiceImpl) DeleteSample(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid maturity sample ID")
+	}
+	sample, err := ms.db.GetMaturitySample(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := ms.db.DeleteMaturitySample(ctx, id); err != nil {
+		return err
+	}
+	ms.forecasts.Schedule(sample.VineyardID, model.ForecastTriggerMaturity)
+	return nil
+}
+
+// ListSamples returns a vineyard's samples by date, only those of one block when blockID is not zero.
+func (ms *maturityServiceImpl) ListSamples(ctx context.Context, vineyardID, blockID int) ([]model.MaturitySample, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	samples, err := ms.db.ListMaturitySamples(ctx, vineyardID, blockID)
+	if err != nil {
+		return nil, err
+	}
+	return ms.localDates(samples), nil
+}
+
+func (ms *maturityServiceImpl) ListSamplesByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.MaturitySample, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	samples, err := ms.db.ListMaturitySamplesByDateRange(ctx, vineyardID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return ms.localDates(samples), nil
+}
+
+// localDates returns sample dates, read from a DATE column, as midnight in the climate time zone.
+func (ms *maturityServiceImpl) localDates(samples []model.MaturitySample) []model.MaturitySample {
+	for i := range samples {
+		samples[i].SampleDate = calendarDate(samples[i].SampleDate, ms.loc)
+	}
+	return samples
+}
+
+func (ms *maturityServiceImpl) validateSample(sample *model.MaturitySample) error {
+	if sample.SampleDate.IsZero() {
+		return fmt.Errorf("%w: sampleDate is required", ErrInvalidMaturitySample)
+	}
+	sample.SampleDate = calendarDate(sample.SampleDate, ms.loc)
+	measured := false
+	for _, r := range maturityRanges {
+		v := r.value(sample)
+		if v == nil {
+			continue
+		}
+		measured = true
+		if *v < r.min || *v > r.max {
+			return fmt.Errorf("%w: %s must be between %g and %g", ErrInvalidMaturitySample, r.name, r.min, r.max)
+		}
+	}
+	if !measured {
+		return fmt.Errorf("%w: at least one of brix, ph, ta, yan and berryWeight is required", ErrInvalidMaturitySample)
+	}
+	if sample.PhotoURL != "" {
+		if u, err := url.Parse(sample.PhotoURL); err != nil || !u.IsAbs() {
+			return fmt.Errorf("%w: photoUrl must be an absolute URL", ErrInvalidMaturitySample)
+		}
+	}
+	return nil
+}
+
+// Trend fits each block's Brix readings from query.From to query.To. To defaults to today and From to
+// the season start; the target defaults to DefaultTargetBrix.
+func (ms *maturityServiceImpl) Trend(ctx context.Context, vineyardID int, query model.MaturityTrendQuery) (*model.MaturityTrend, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	target := query.TargetBrix
+	if target == 0 {
+		target = DefaultTargetBrix
+	}
+	if target < 5 || target > 40 {
+		return nil, fmt.Errorf("%w: targetBrix must be between 5 and 40", ErrInvalidMaturitySample)
+	}
+	if _, err := ms.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	to := calendarDate(time.Now().In(ms.loc), ms.loc)
+	if !query.To.IsZero() {
+		to = calendarDate(query.To, ms.loc)
+	}
+	from := query.From
+	if from.IsZero() {
+		var latitude float64
+		if geometry, err := ms.db.GetVineyardGeometry(ctx, vineyardID); err == nil {
+			latitude = geometry.Centroid.Lat
+		}
+		from = climate.SeasonStart(to, latitude)
+	}
+	from = calendarDate(from, ms.loc)
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: end date is before start date", ErrInvalidMaturitySample)
+	}
+
+	samples, err := ms.ListSamplesByDateRange(ctx, vineyardID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := ms.db.ListBlocks(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks in ID order, then the samples without a block
+	byBlock := make(map[int][]model.MaturitySample)
+	var unassigned []model.MaturitySample
+	for _, s := range samples {
+		if s.Brix == nil {
+			continue
+		}
+		if s.BlockID == nil {
+			unassigned = append(unassigned, s)
+		} else {
+			byBlock[*s.BlockID] = append(byBlock[*s.BlockID], s)
+		}
+	}
+	trend := &model.MaturityTrend{VineyardID: vineyardID, TargetBrix: target, From: from, To: to, Blocks: []model.BlockMaturityTrend{}}
+	for _, b := range blocks {
+		if readings := byBlock[b.ID]; len(readings) > 0 {
+			bt := fitBlockTrend(readings, target)
+			id := b.ID
+			bt.BlockID = &id
+			bt.BlockName, bt.Varietal = b.Name, b.Varietal
+			trend.Blocks = append(trend.Blocks, bt)
+		}
+	}
+	if len(unassigned) > 0 {
+		trend.Blocks = append(trend.Blocks, fitBlockTrend(unassigned, target))
+	}
+	return trend, nil
+}
+
+// fitBlockTrend fits the Brix readings of one block, sorted by date.
+func fitBlockTrend(readings []model.MaturitySample, target float64) model.BlockMaturityTrend {
+	first := readings[0].SampleDate
+	latest := readings[len(readings)-1]
+	bt := model.BlockMaturityTrend{
+		Samples:         len(readings),
+		FirstSampleDate: first,
+		LatestBrix:      *latest.Brix,
+		LatestDate:      latest.SampleDate,
+	}
+	points := make([]maturity.Point, len(readings))
+	for i, s := range readings {
+		points[i] = maturity.Point{Day: sampleDay(first, s.SampleDate), Brix: *s.Brix}
+		bt.Points = append(bt.Points, model.MaturityTrendPoint{Date: s.SampleDate, Brix: *s.Brix})
+		if bt.ReachedOn == nil && *s.Brix >= target {
+			date := s.SampleDate
+			bt.ReachedOn = &date
+		}
+	}
+
+	curve, ok := maturity.Fit(points)
+	if !ok {
+		bt.Note = "samples on at least two days are needed to fit a trend"
+		return bt
+	}
+	bt.Method = curve.Method
+	bt.Coefficients = make([]float64, len(curve.Coefficients))
+	for i, c := range curve.Coefficients {
+		bt.Coefficients[i] = roundTo(c, 6)
+	}
+	r2, rate := roundTo(curve.RSquared, 3), roundTo(curve.Rate(points[len(points)-1].Day), 3)
+	bt.RSquared, bt.BrixPerDay = &r2, &rate
+	for i := range bt.Points {
+		fitted := roundTo(curve.At(points[i].Day), 2)
+		bt.Points[i].Fitted = &fitted
+	}
+	if bt.ReachedOn != nil {
+		return bt
+	}
+	day, ok := curve.Project(target, points[len(points)-1].Day)
+	if !ok {
+		bt.Note = fmt.Sprintf("the trend does not reach %g °Brix within %d days", target, maturity.MaxProjectionDays)
+		return bt
+	}
+	projected := first.AddDate(0, 0, int(math.Ceil(day)))
+	bt.ProjectedDate = &projected
+	return bt
+}
+
+// sampleDay counts whole days from first to date, both midnight in the same zone.
+func sampleDay(first, date time.Time) float64 {
+	return math.Round(date.Sub(first).Hours() / 24)
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
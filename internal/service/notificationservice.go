@@ -0,0 +1,458 @@
+/*
+ * notificationservice.go: Queues and delivers notification email through a persistent outbox.
+ * Usage: Alerts, ingestion failures and weekly reports are rendered with the notify templates and stored
+ *        in email_outbox for the vineyard's subscribers, so mail survives restarts. RunOutbox sends due
+ *        messages in the background and retries failures with exponential backoff, from one minute up
+ *        to an hour, until outbox.maxAttempts is reached. With email disabled nothing is queued.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/notify"
+)
+
+// ErrInvalidSubscriber is returned for subscribers with a malformed email or unknown events.
+var ErrInvalidSubscriber = errors.New("invalid subscriber")
+
+// Outbox retry backoff, doubled for each failed attempt.
+const (
+	outboxMinBackoff = time.Minute
+	outboxMaxBackoff = time.Hour
+	outboxLease      = 5 * time.Minute // How long a claimed message is left alone by other senders
+)
+
+type NotificationService interface {
+	CreateSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error
+	GetSubscriber(ctx context.Context, vineyardID, id int) (*model.NotificationSubscriber, error)
+	UpdateSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error
+	DeleteSubscriber(ctx context.Context, vineyardID, id int) error
+	ListSubscribers(ctx context.Context, vineyardID int) ([]model.NotificationSubscriber, error)
+	NotifyAlert(ctx context.Context, alert *model.Alert, rule *model.AlertRule, vineyard *model.Vineyard) (bool, error)
+	NotifyIngestionFailure(ctx context.Context, run *model.IngestionRun) (bool, error)
+	WeeklyReport(ctx context.Context, vineyardID int, to time.Time) (*model.WeeklyReport, error)
+	SendWeeklyReports(ctx context.Context, to time.Time) (int, error)
+	ListOutbox(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error)
+	RunOutbox(ctx context.Context)
+}
+
+// notificationStore is the part of *db.DB the notification service uses, so the outbox can also run
+// against an in-memory store.
+type notificationStore interface {
+	SaveSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error
+	GetSubscriber(ctx context.Context, vineyardID, id int) (*model.NotificationSubscriber, error)
+	UpdateSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error
+	DeleteSubscriber(ctx context.Context, vineyardID, id int) error
+	ListSubscribers(ctx context.Context, vineyardID int, event string) ([]model.NotificationSubscriber, error)
+	GetVineyard(ctx context.Context, id int) (*model.Vineyard, error)
+	ListVineyards(ctx context.Context) ([]model.Vineyard, error)
+	ListIngestionRuns(ctx context.Context, filter model.IngestionRunFilter) ([]model.IngestionRun, error)
+	ListAlertsRaised(ctx context.Context, vineyardID int, from, to time.Time) ([]model.Alert, error)
+	ListPestDataByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.PestData, error)
+	ListWeatherDataByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.WeatherData, error)
+	EnqueueEmail(ctx context.Context, msg *model.OutboxMessage) error
+	ClaimDueEmails(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkEmailSent(ctx context.Context, id int) error
+	MarkEmailFailed(ctx context.Context, id int, sendErr string, next time.Time, final bool) error
+	ListOutbox(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error)
+}
+
+type notificationServiceImpl struct {
+	db           notificationStore
+	loc          *time.Location
+	mail         notify.Transport
+	pollInterval time.Duration
+	maxAttempts  int
+	batchSize    int
+}
+
+// NewNotificationService creates the notification service. Times in email are shown in the climate
+// time zone. A nil mail transport disables email.
+func NewNotificationService(db *db.DB, settings climate.Settings, mail notify.Transport,
+	outbox config.OutboxConfig) (NotificationService, error) {
+	interval, err := outbox.Interval()
+	if err != nil {
+		return nil, err
+	}
+	ns := &notificationServiceImpl{
+		db:           db,
+		loc:          settings.Location,
+		mail:         mail,
+		pollInterval: interval,
+		maxAttempts:  outbox.MaxAttempts,
+		batchSize:    outbox.BatchSize,
+	}
+	if ns.maxAttempts <= 0 {
+		ns.maxAttempts = config.DefaultOutboxMaxAttempts
+	}
+	if ns.batchSize <= 0 {
+		ns.batchSize = config.DefaultOutboxBatchSize
+	}
+	return ns, nil
+}
+
+func (ns *notificationServiceImpl) CreateSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error {
+	if sub == nil {
+		return errors.New("cannot create a nil subscriber")
+	}
+	if sub.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := validateSubscriber(sub); err != nil {
+		return err
+	}
+	if _, err := ns.db.GetVineyard(ctx, sub.VineyardID); err != nil {
+		return err
+	}
+	return ns.db.SaveSubscriber(ctx, sub)
+}
+
+func (ns *notificationServiceImpl) GetSubscriber(ctx context.Context, vineyardID, id int) (*model.NotificationSubscriber, error) {
+	if vineyardID <= 0 || id <= 0 {
+		return nil, errors.New("invalid subscriber ID")
+	}
+	return ns.db.GetSubscriber(ctx, vineyardID, id)
+}
+
+func (ns *notificationServiceImpl) UpdateSubscriber(ctx context.Context, sub *model.NotificationSubscriber) error {
+	if sub == nil {
+		return errors.New("cannot update a nil subscriber")
+	}
+	if sub.VineyardID <= 0 || sub.ID <= 0 {
+		return errors.New("invalid subscriber ID")
+	}
+	if err := validateSubscriber(sub); err != nil {
+		return err
+	}
+	return ns.db.UpdateSubscriber(ctx, sub)
+}
+
+func (ns *notificationServiceImpl) DeleteSubscriber(ctx context.Context, vineyardID, id int) error {
+	if vineyardID <= 0 || id <= 0 {
+		return errors.New("invalid subscriber ID")
+	}
+	return ns.db.DeleteSubscriber(ctx, vineyardID, id)
+}
+
+func (ns *notificationServiceImpl) ListSubscribers(ctx context.Context, vineyardID int) ([]model.NotificationSubscriber, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return ns.db.ListSubscribers(ctx, vineyardID, "")
+}
+
+func validateSubscriber(sub *model.NotificationSubscriber) error {
+	sub.Email = strings.TrimSpace(sub.Email)
+	if err := notify.ValidateAddresses([]string{sub.Email}); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscriber, err)
+	}
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+	for _, event := range sub.Events {
+		if !knownKind(event) {
+			return fmt.Errorf("%w: unknown event %q, expected one of %s", ErrInvalidSubscriber, event,
+				strings.Join(notify.Kinds, ", "))
+		}
+	}
+	return nil
+}
+
+func knownKind(kind string) bool {
+	for _, k := range notify.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyAlert queues a new alert for the rule's recipients and the vineyard's alert subscribers. It
+// reports whether anything was queued.
+func (ns *notificationServiceImpl) NotifyAlert(ctx context.Context, alert *model.Alert, rule *model.AlertRule,
+	vineyard *model.Vineyard) (bool, error) {
+	local := *alert
+	local.FirstObservedAt = alert.FirstObservedAt.In(ns.loc)
+	local.LastObservedAt = alert.LastObservedAt.In(ns.loc)
+	data := notify.AlertData{Vineyard: vineyard, Alert: &local, CooldownMinutes: rule.CooldownMinutes}
+	return ns.queue(ctx, vineyard.ID, notify.KindAlertRaised, data, rule.Recipients)
+}
+
+// NotifyIngestionFailure queues a failed run for the vineyard's subscribers. Runs without a vineyard
+// are not reported, nor are repeat failures of a source whose previous run already failed.
+func (ns *notificationServiceImpl) NotifyIngestionFailure(ctx context.Context, run *model.IngestionRun) (bool, error) {
+	if run == nil || run.VineyardID == nil || ns.mail == nil {
+		return false, nil
+	}
+	previous, err := ns.db.ListIngestionRuns(ctx, model.IngestionRunFilter{
+		SourceKey:  run.SourceKey,
+		VineyardID: *run.VineyardID,
+		Until:      run.StartedAt,
+		Limit:      1,
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(previous) > 0 && previous[0].Status == model.RunStatusFailed {
+		return false, nil
+	}
+	vineyard, err := ns.db.GetVineyard(ctx, *run.VineyardID)
+	if err != nil {
+		return false, err
+	}
+	local := *run
+	local.StartedAt = run.StartedAt.In(ns.loc)
+	return ns.queue(ctx, vineyard.ID, notify.KindIngestionFailed, notify.IngestionFailureData{Vineyard: vineyard, Run: &local}, nil)
+}
+
+// queue renders a notification and stores it for the vineyard's subscribers to kind plus extra
+// addresses, each address once.
+func (ns *notificationServiceImpl) queue(ctx context.Context, vineyardID int, kind string, data interface{},
+	extra []string) (bool, error) {
+	if ns.mail == nil {
+		return false, nil
+	}
+	subs, err := ns.db.ListSubscribers(ctx, vineyardID, kind)
+	if err != nil {
+		return false, err
+	}
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, address := range extra {
+		if key := strings.ToLower(address); !seen[key] {
+			seen[key] = true
+			recipients = append(recipients, address)
+		}
+	}
+	for _, sub := range subs {
+		if key := strings.ToLower(sub.Email); !seen[key] {
+			seen[key] = true
+			recipients = append(recipients, sub.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		return false, nil
+	}
+
+	msg, err := notify.Render(kind, data)
+	if err != nil {
+		return false, err
+	}
+	queued := &model.OutboxMessage{
+		Kind:       kind,
+		VineyardID: &vineyardID,
+		Recipients: recipients,
+		Subject:    msg.Subject,
+		TextBody:   msg.Body,
+		HTMLBody:   msg.HTML,
+	}
+	if err := ns.db.EnqueueEmail(ctx, queued); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WeeklyReport summarizes the seven calendar days ending on to, by default yesterday.
+func (ns *notificationServiceImpl) WeeklyReport(ctx context.Context, vineyardID int, to time.Time) (*model.WeeklyReport, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	vineyard, err := ns.db.GetVineyard(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	if to.IsZero() {
+		to = time.Now().In(ns.loc).AddDate(0, 0, -1)
+	}
+	to = calendarDate(to, ns.loc)
+	from := to.AddDate(0, 0, -6)
+	end := to.AddDate(0, 0, 1)
+
+	report := &model.WeeklyReport{
+		VineyardID:       vineyardID,
+		VineyardName:     vineyard.Name,
+		From:             from,
+		To:               to,
+		PestObservations: []model.PestData{},
+		RiskWarnings:     []model.PestData{},
+		Alerts:           []model.Alert{},
+		FailedRuns:       []model.IngestionRun{},
+	}
+
+	weather, err := ns.db.ListWeatherDataByDateRange(ctx, vineyardID, from, end.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+	for i, w := range weather {
+		if i == 0 || w.Temperature < *report.MinTemperature {
+			report.MinTemperature = &weather[i].Temperature
+		}
+		if i == 0 || w.Temperature > *report.MaxTemperature {
+			report.MaxTemperature = &weather[i].Temperature
+		}
+		if w.Precipitation != nil {
+			report.Precipitation += *w.Precipitation
+		}
+	}
+	report.WeatherReadings = len(weather)
+	report.Precipitation = climate.Round(report.Precipitation)
+
+	pests, err := ns.db.ListPestDataByDateRange(ctx, vineyardID, from, end.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(pests, func(i, j int) bool { return pests[i].ObservationDate.Before(pests[j].ObservationDate) })
+	for _, p := range pests {
+		if p.Kind == model.PestKindRisk {
+			report.RiskWarnings = append(report.RiskWarnings, p)
+		} else {
+			report.PestObservations = append(report.PestObservations, p)
+		}
+	}
+
+	alerts, err := ns.db.ListAlertsRaised(ctx, vineyardID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range alerts {
+		a.FirstObservedAt = a.FirstObservedAt.In(ns.loc)
+		a.LastObservedAt = a.LastObservedAt.In(ns.loc)
+		report.Alerts = append(report.Alerts, a)
+	}
+
+	runs, err := ns.db.ListIngestionRuns(ctx, model.IngestionRunFilter{
+		VineyardID: vineyardID,
+		Status:     model.RunStatusFailed,
+		Since:      from,
+		Until:      end,
+		Limit:      MaxRunListLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		run.StartedAt = run.StartedAt.In(ns.loc)
+		report.FailedRuns = append(report.FailedRuns, run)
+	}
+	return report, nil
+}
+
+// SendWeeklyReports queues the week ending on to (by default yesterday) for every vineyard with
+// report subscribers, and returns how many reports were queued. A failing vineyard is logged and
+// skipped.
+func (ns *notificationServiceImpl) SendWeeklyReports(ctx context.Context, to time.Time) (int, error) {
+	if ns.mail == nil {
+		return 0, nil
+	}
+	vineyards, err := ns.db.ListVineyards(ctx)
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, vineyard := range vineyards {
+		subs, err := ns.db.ListSubscribers(ctx, vineyard.ID, notify.KindWeeklyReport)
+		if err != nil {
+			return queued, err
+		}
+		if len(subs) == 0 {
+			continue
+		}
+		report, err := ns.WeeklyReport(ctx, vineyard.ID, to)
+		if err == nil {
+			var ok bool
+			ok, err = ns.queue(ctx, vineyard.ID, notify.KindWeeklyReport, report, nil)
+			if ok {
+				queued++
+			}
+		}
+		if err != nil {
+			log.Printf("Failed to queue weekly report for vineyard %d: %v", vineyard.ID, err)
+		}
+	}
+	return queued, nil
+}
+
+func (ns *notificationServiceImpl) ListOutbox(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	if limit <= 0 {
+		limit = DefaultRunListLimit
+	}
+	if limit > MaxRunListLimit {
+		limit = MaxRunListLimit
+	}
+	return ns.db.ListOutbox(ctx, status, limit)
+}
+
+// RunOutbox sends due messages every poll interval until ctx is cancelled. It returns at once when
+// email is disabled.
+func (ns *notificationServiceImpl) RunOutbox(ctx context.Context) {
+	if ns.mail == nil {
+		return
+	}
+	ticker := time.NewTicker(ns.pollInterval)
+	defer ticker.Stop()
+	for {
+		ns.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue sends one batch of due messages, rescheduling or giving up on the ones that fail.
+func (ns *notificationServiceImpl) deliverDue(ctx context.Context) {
+	msgs, err := ns.db.ClaimDueEmails(ctx, ns.batchSize, outboxLease)
+	if err != nil {
+		log.Printf("Failed to read the email outbox: %v", err)
+		return
+	}
+	for _, msg := range msgs {
+		sendErr := ns.mail.Send(ctx, notify.Message{
+			To:      msg.Recipients,
+			Subject: msg.Subject,
+			Body:    msg.TextBody,
+			HTML:    msg.HTMLBody,
+		})
+		if sendErr == nil {
+			err = ns.db.MarkEmailSent(ctx, msg.ID)
+		} else {
+			attempts := msg.Attempts + 1
+			final := attempts >= ns.maxAttempts
+			if final {
+				log.Printf("Giving up on email %d after %d attempts: %v", msg.ID, attempts, sendErr)
+			}
+			err = ns.db.MarkEmailFailed(ctx, msg.ID, sendErr.Error(), time.Now().Add(outboxBackoff(attempts)), final)
+		}
+		if err != nil {
+			log.Printf("Failed to update email %d in the outbox: %v", msg.ID, err)
+		}
+	}
+}
+
+// outboxBackoff returns the delay after the given number of failed attempts.
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxMinBackoff
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxBackoff {
+		delay = outboxMaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/notify"
+)
+
+// memoryOutbox keeps the outbox, subscribers, vineyards and ingestion runs in memory. Store methods the
+// outbox does not use are left to the embedded nil interface and panic if called.
+type memoryOutbox struct {
+	notificationStore
+
+	mu          sync.Mutex
+	messages    []*model.OutboxMessage
+	subscribers []model.NotificationSubscriber
+	vineyards   map[int]*model.Vineyard
+	runs        []model.IngestionRun
+}
+
+func (m *memoryOutbox) ListSubscribers(ctx context.Context, vineyardID int, event string) ([]model.NotificationSubscriber, error) {
+	var subs []model.NotificationSubscriber
+	for _, sub := range m.subscribers {
+		if sub.VineyardID != vineyardID {
+			continue
+		}
+		for _, e := range sub.Events {
+			if e == event {
+				subs = append(subs, sub)
+				break
+			}
+		}
+	}
+	return subs, nil
+}
+
+func (m *memoryOutbox) GetVineyard(ctx context.Context, id int) (*model.Vineyard, error) {
+	v, ok := m.vineyards[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return v, nil
+}
+
+// ListIngestionRuns only applies the filter fields NotifyIngestionFailure sets. Runs are kept newest first.
+func (m *memoryOutbox) ListIngestionRuns(ctx context.Context, filter model.IngestionRunFilter) ([]model.IngestionRun, error) {
+	var runs []model.IngestionRun
+	for _, run := range m.runs {
+		if run.SourceKey != filter.SourceKey || run.VineyardID == nil || *run.VineyardID != filter.VineyardID ||
+			!run.StartedAt.Before(filter.Until) {
+			continue
+		}
+		runs = append(runs, run)
+		if len(runs) == filter.Limit {
+			break
+		}
+	}
+	return runs, nil
+}
+
+func (m *memoryOutbox) EnqueueEmail(ctx context.Context, msg *model.OutboxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msg.ID = len(m.messages) + 1
+	msg.Status = model.OutboxPending
+	msg.CreatedAt = time.Now()
+	msg.NextAttemptAt = msg.CreatedAt
+	queued := *msg
+	m.messages = append(m.messages, &queued)
+	return nil
+}
+
+func (m *memoryOutbox) ClaimDueEmails(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var claimed []model.OutboxMessage
+	for _, msg := range m.messages {
+		if len(claimed) == limit {
+			break
+		}
+		if msg.Status == model.OutboxPending && !msg.NextAttemptAt.After(now) {
+			msg.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, *msg)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *memoryOutbox) MarkEmailSent(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msg := m.messages[id-1]
+	now := time.Now()
+	msg.Status, msg.Attempts, msg.SentAt, msg.LastError = model.OutboxSent, msg.Attempts+1, &now, ""
+	return nil
+}
+
+func (m *memoryOutbox) MarkEmailFailed(ctx context.Context, id int, sendErr string, next time.Time, final bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msg := m.messages[id-1]
+	msg.Attempts++
+	msg.LastError, msg.NextAttemptAt = sendErr, next
+	if final {
+		msg.Status = model.OutboxFailed
+	}
+	return nil
+}
+
+// message returns a copy of a queued message.
+func (m *memoryOutbox) message(id int) model.OutboxMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.messages[id-1]
+}
+
+// makeDue moves a pending message's next attempt to now, as if its backoff had passed.
+func (m *memoryOutbox) makeDue(id int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[id-1].NextAttemptAt = time.Now()
+}
+
+func newTestOutbox() *memoryOutbox {
+	return &memoryOutbox{
+		subscribers: []model.NotificationSubscriber{
+			{VineyardID: 12, Email: "manager@example.com", Events: []string{notify.KindIngestionFailed}},
+			{VineyardID: 12, Email: "Manager@Example.com", Events: []string{notify.KindIngestionFailed}},
+			{VineyardID: 12, Email: "crew@example.com", Events: []string{notify.KindAlertRaised}},
+		},
+		vineyards: map[int]*model.Vineyard{12: {ID: 12, Name: "North Slope"}},
+	}
+}
+
+func newTestNotificationService(store *memoryOutbox, mail *notify.MemoryTransport, maxAttempts int) *notificationServiceImpl {
+	return &notificationServiceImpl{
+		db:           store,
+		loc:          time.UTC,
+		mail:         mail,
+		pollInterval: time.Minute,
+		maxAttempts:  maxAttempts,
+		batchSize:    10,
+	}
+}
+
+func failedRun(id int, startedAt time.Time) *model.IngestionRun {
+	vineyardID := 12
+	return &model.IngestionRun{
+		ID:         id,
+		SourceKey:  "weather",
+		VineyardID: &vineyardID,
+		Trigger:    model.RunTriggerScheduled,
+		Status:     model.RunStatusFailed,
+		StartedAt:  startedAt,
+		Error:      "fetching data, server returned: 503 Service Unavailable",
+	}
+}
+
+func TestOutboxQueuesAndDelivers(t *testing.T) {
+	store, mail := newTestOutbox(), &notify.MemoryTransport{}
+	ns := newTestNotificationService(store, mail, 3)
+	ctx := context.Background()
+
+	queued, err := ns.NotifyIngestionFailure(ctx, failedRun(1, time.Now().Add(-time.Minute)))
+	if err != nil || !queued {
+		t.Fatalf("NotifyIngestionFailure = %v, %v; want queued", queued, err)
+	}
+	msg := store.message(1)
+	if want := []string{"manager@example.com"}; !reflect.DeepEqual(msg.Recipients, want) {
+		t.Errorf("recipients = %v, want %v, each address once and only subscribers to the event", msg.Recipients, want)
+	}
+	if msg.Status != model.OutboxPending || msg.Subject != "Ingestion of weather failed for North Slope" {
+		t.Errorf("queued %s message %q", msg.Status, msg.Subject)
+	}
+	if len(mail.Sent()) != 0 {
+		t.Fatal("message was sent before the outbox ran")
+	}
+
+	ns.deliverDue(ctx)
+	sent := mail.Sent()
+	if len(sent) != 1 || !reflect.DeepEqual(sent[0].To, msg.Recipients) || sent[0].Subject != msg.Subject {
+		t.Fatalf("sent = %+v, want the queued message", sent)
+	}
+	if msg := store.message(1); msg.Status != model.OutboxSent || msg.Attempts != 1 || msg.SentAt == nil {
+		t.Errorf("after delivery: status %s, %d attempts, sentAt %v", msg.Status, msg.Attempts, msg.SentAt)
+	}
+
+	ns.deliverDue(ctx)
+	if len(mail.Sent()) != 1 {
+		t.Error("a sent message was delivered again")
+	}
+}
+
+func TestOutboxClaimLeasesMessages(t *testing.T) {
+	store, mail := newTestOutbox(), &notify.MemoryTransport{}
+	ns := newTestNotificationService(store, mail, 3)
+	ctx := context.Background()
+	if _, err := ns.NotifyIngestionFailure(ctx, failedRun(1, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := store.ClaimDueEmails(ctx, 10, outboxLease)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("ClaimDueEmails = %d messages, %v; want 1", len(claimed), err)
+	}
+	// Another sender polling meanwhile leaves the claimed message alone
+	ns.deliverDue(ctx)
+	if len(mail.Sent()) != 0 {
+		t.Error("a claimed message was sent by a second sender")
+	}
+	store.makeDue(1)
+	ns.deliverDue(ctx)
+	if len(mail.Sent()) != 1 {
+		t.Error("the message was not sent once its lease ran out")
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	want := []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute,
+		time.Hour, time.Hour, time.Hour,
+	}
+	for i, delay := range want {
+		if got := outboxBackoff(i + 1); got != delay {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+}
+
+func TestOutboxRetriesUntilMaxAttempts(t *testing.T) {
+	store, mail := newTestOutbox(), &notify.MemoryTransport{Err: errors.New("connection refused")}
+	ns := newTestNotificationService(store, mail, 3)
+	ctx := context.Background()
+	if _, err := ns.NotifyIngestionFailure(ctx, failedRun(1, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		before := time.Now()
+		ns.deliverDue(ctx)
+		msg := store.message(1)
+		if msg.Attempts != attempt || msg.LastError != "connection refused" {
+			t.Fatalf("attempt %d: %d attempts, last error %q", attempt, msg.Attempts, msg.LastError)
+		}
+		if attempt < 3 {
+			if msg.Status != model.OutboxPending {
+				t.Fatalf("attempt %d: status %s, want %s", attempt, msg.Status, model.OutboxPending)
+			}
+			wait := msg.NextAttemptAt.Sub(before)
+			if backoff := outboxBackoff(attempt); wait < backoff || wait > backoff+time.Second {
+				t.Errorf("attempt %d: retried after %v, want %v", attempt, wait, backoff)
+			}
+			// Not due again until the backoff has passed
+			ns.deliverDue(ctx)
+			if got := store.message(1).Attempts; got != attempt {
+				t.Fatalf("attempt %d: retried before the backoff passed", attempt)
+			}
+			store.makeDue(1)
+		}
+	}
+	if msg := store.message(1); msg.Status != model.OutboxFailed {
+		t.Fatalf("after max attempts: status %s, want %s", msg.Status, model.OutboxFailed)
+	}
+
+	mail.Err = nil
+	store.makeDue(1)
+	ns.deliverDue(ctx)
+	if len(mail.Sent()) != 0 || store.message(1).Attempts != 3 {
+		t.Error("a failed message was retried")
+	}
+}
+
+func TestNotifyIngestionFailureSuppressesRepeats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		previous string // Status of the source's previous run for the vineyard, none when empty
+		want     bool
+	}{
+		{name: "first run", want: true},
+		{name: "after a success", previous: model.RunStatusSucceeded, want: true},
+		{name: "after a failure", previous: model.RunStatusFailed, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestOutbox()
+			if tt.previous != "" {
+				prev := failedRun(1, now.Add(-time.Hour))
+				prev.Status = tt.previous
+				store.runs = append(store.runs, *prev)
+			}
+			ns := newTestNotificationService(store, &notify.MemoryTransport{}, 3)
+
+			queued, err := ns.NotifyIngestionFailure(ctx, failedRun(2, now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if queued != tt.want || (len(store.messages) == 1) != tt.want {
+				t.Errorf("queued = %v with %d messages, want %v", queued, len(store.messages), tt.want)
+			}
+		})
+	}
+
+	t.Run("run without a vineyard", func(t *testing.T) {
+		store := newTestOutbox()
+		ns := newTestNotificationService(store, &notify.MemoryTransport{}, 3)
+		run := failedRun(1, now)
+		run.VineyardID = nil
+		if queued, err := ns.NotifyIngestionFailure(ctx, run); queued || err != nil {
+			t.Errorf("NotifyIngestionFailure = %v, %v; want nothing queued", queued, err)
+		}
+	})
+}
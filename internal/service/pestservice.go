@@ -11,12 +11,18 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/webhook"
 )
 
+// ErrInvalidPestData is returned for pest records, and filters, with an unknown kind.
+var ErrInvalidPestData = errors.New("invalid pest data")
+
 type PestService interface {
 	CreatePestData(ctx context.Context, pest *model.PestData) error
 	GetPestData(ctx context.Context, id int) (*model.PestData, error)
@@ -24,22 +30,40 @@ type PestService interface {
 	DeletePestData(ctx context.Context, id int) error
 	ListPestDataByVineyard(ctx context.Context, vineyardID int) ([]model.PestData, error)
 	ListPestDataByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.PestData, error)
-	FilterPestData(ctx context.Context, vineyardID int, pestType, severity string) ([]model.PestData, error)
+	FilterPestData(ctx context.Context, vineyardID int, pestType, severity, kind string) ([]model.PestData, error)
 }
 
 type pestServiceImpl struct {
-	db *db.DB
+	db       *db.DB
+	webhooks WebhookService
 }
 
-func NewPestService(db *db.DB) PestService {
-	return &pestServiceImpl{db: db}
+func NewPestService(db *db.DB, webhooks WebhookService) PestService {
+	return &pestServiceImpl{db: db, webhooks: webhooks}
 }
 
 func (ps *pestServiceImpl) CreatePestData(ctx context.Context, pest *model.PestData) error {
 	if pest == nil {
 		return errors.New("cannot create nil pest data")
 	}
-	return ps.db.SavePestData(ctx, pest)
+	switch pest.Kind {
+	case "":
+		pest.Kind = model.PestKindObservation
+	case model.PestKindObservation, model.PestKindRisk:
+	default:
+		return fmt.Errorf("%w: kind %q is not observation or risk", ErrInvalidPestData, pest.Kind)
+	}
+	if err := geo.ValidateField("location", pest.Location); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, ps.db, pest.VineyardID, pest.BlockID); err != nil {
+		return err
+	}
+	if err := ps.db.SavePestData(ctx, pest); err != nil {
+		return err
+	}
+	ps.webhooks.Emit(ctx, webhook.EventPestCreated, &pest.VineyardID, pest)
+	return nil
 }
 
 func (ps *pestServiceImpl) GetPestData(ctx context.Context, id int) (*model.PestData, error) {
@@ -56,6 +80,18 @@ func (ps *pestServiceImpl) UpdatePestData(ctx context.Context, pest *model.PestD
 	if pest.ID == 0 {
 		return errors.New("invalid pest data ID")
 	}
+	if err := geo.ValidateField("location", pest.Location); err != nil {
+		return err
+	}
+	if pest.BlockID != nil {
+		existing, err := ps.db.GetPestData(ctx, pest.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkRecordBlock(ctx, ps.db, existing.VineyardID, pest.BlockID); err != nil {
+			return err
+		}
+	}
 	return ps.db.UpdatePestData(ctx, pest)
 }
 
@@ -83,9 +119,16 @@ func (ps *pestServiceImpl) ListPestDataByDateRange(ctx context.Context, vineyard
 	return ps.db.ListPestDataByDateRange(ctx, vineyardID, start, end)
 }
 
-func (ps *pestServiceImpl) FilterPestData(ctx context.Context, vineyardID int, pestType, severity string) ([]model.PestData, error) {
+// FilterPestData returns pest records of a type and severity. kind narrows them to observations or to
+// the risks raised by the disease models; empty returns both.
+func (ps *pestServiceImpl) FilterPestData(ctx context.Context, vineyardID int, pestType, severity, kind string) ([]model.PestData, error) {
 	if vineyardID <= 0 {
 		return nil, errors.New("invalid vineyard ID")
 	}
-	return ps.db.FilterPestData(ctx, vineyardID, pestType, severity)
+	switch kind {
+	case "", model.PestKindObservation, model.PestKindRisk:
+	default:
+		return nil, fmt.Errorf("%w: kind %q is not observation or risk", ErrInvalidPestData, kind)
+	}
+	return ps.db.FilterPestData(ctx, vineyardID, pestType, severity, kind)
 }
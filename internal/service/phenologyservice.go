@@ -0,0 +1,303 @@
+/*
+ * phenologyservice.go: Phenology observations and the current growth stage of each block.
+ * Usage: Observations record the stage a block, or the whole vineyard, was seen at on a calendar day, in
+ *        the modified E-L or the BBCH system, optionally with a photo from the vineyard's images.
+ *        CurrentStages advances each block's latest observation of the season by the GDD accumulated
+ *        since, so that stage-dependent models have a stage between field visits. StagesOn does the same
+ *        as of a past day.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/phenology"
+)
+
+// ErrInvalidPhenologyObservation is returned for observations with an unknown stage, a date in the
+// future or an image of another vineyard.
+var ErrInvalidPhenologyObservation = errors.New("invalid phenology observation")
+
+type PhenologyService interface {
+	CreateObservation(ctx context.Context, obs *model.PhenologyObservation) error
+	GetObservation(ctx context.Context, id int) (*model.PhenologyObservation, error)
+	UpdateObservation(ctx context.Context, obs *model.PhenologyObservation) error
+	DeleteObservation(ctx context.Context, id int) error
+	ListObservations(ctx context.Context, vineyardID, blockID int) ([]model.PhenologyObservation, error)
+	ListObservationsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.PhenologyObservation, error)
+	CurrentStages(ctx context.Context, vineyardID int) ([]model.PhenologyStage, error)
+	StagesOn(ctx context.Context, vineyardID int, day time.Time) ([]model.PhenologyStage, error)
+}
+
+type phenologyServiceImpl struct {
+	db      *db.DB
+	climate ClimateService
+	loc     *time.Location
+}
+
+// NewPhenologyService creates the phenology service. Growing degree days come from the climate service;
+// observation dates are calendar days in the climate time zone.
+func NewPhenologyService(db *db.DB, climateService ClimateService, settings climate.Settings) PhenologyService {
+	return &phenologyServiceImpl{db: db, climate: climateService, loc: settings.Location}
+}
+
+func (ps *phenologyServiceImpl) CreateObservation(ctx context.Context, obs *model.PhenologyObservation) error {
+	if obs == nil {
+		return errors.New("cannot create a nil phenology observation")
+	}
+	if obs.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := ps.validateObservation(obs); err != nil {
+		return err
+	}
+	if _, err := ps.db.GetVineyard(ctx, obs.VineyardID); err != nil {
+		return err
+	}
+	if err := ps.checkReferences(ctx, obs.VineyardID, obs); err != nil {
+		return err
+	}
+	return ps.db.SavePhenologyObservation(ctx, obs)
+}
+
+func (ps *phenologyServiceImpl) GetObservation(ctx context.Context, id int) (*model.PhenologyObservation, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid phenology observation ID")
+	}
+	obs, err := ps.db.GetPhenologyObservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ps.describe(obs)
+	return obs, nil
+}
+
+func (ps *phenologyServiceImpl) UpdateObservation(ctx context.Context, obs *model.PhenologyObservation) error {
+	if obs == nil {
+		return errors.New("cannot update a nil phenology observation")
+	}
+	if obs.ID <= 0 {
+		return errors.New("invalid phenology observation ID")
+	}
+	if err := ps.validateObservation(obs); err != nil {
+		return err
+	}
+	if obs.BlockID != nil || obs.ImageID != nil {
+		existing, err := ps.db.GetPhenologyObservation(ctx, obs.ID)
+		if err != nil {
+			return err
+		}
+		if err := ps.checkReferences(ctx, existing.VineyardID, obs); err != nil {
+			return err
+		}
+	}
+	return ps.db.UpdatePhenologyObservation(ctx, obs)
+}
+
+func (ps *phenologyServiceImpl) DeleteObservation(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid phenology observation ID")
+	}
+	return ps.db.DeletePhenologyObservation(ctx, id)
+}
+
+// ListObservations returns a vineyard's observations by date, only those of one block when blockID is
+// not zero.
+func (ps *phenologyServiceImpl) ListObservations(ctx context.Context, vineyardID, blockID int) ([]model.PhenologyObservation, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	observations, err := ps.db.ListPhenologyObservations(ctx, vineyardID, blockID)
+	if err != nil {
+		return nil, err
+	}
+	return ps.describeAll(observations), nil
+}
+
+func (ps *phenologyServiceImpl) ListObservationsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.PhenologyObservation, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	observations, err := ps.db.ListPhenologyObservationsByDateRange(ctx, vineyardID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return ps.describeAll(observations), nil
+}
+
+// CurrentStages returns the stage of each block today. A block's stage starts from the latest
+// observation of the season, its own or one covering the whole vineyard, and advances by the GDD
+// accumulated since; without one it is estimated from winter bud at the season start. The whole
+// vineyard gets its own entry when it has no blocks or observations without a block.
+func (ps *phenologyServiceImpl) CurrentStages(ctx context.Context, vineyardID int) ([]model.PhenologyStage, error) {
+	return ps.StagesOn(ctx, vineyardID, time.Now())
+}
+
+// StagesOn returns the stage of each block on a calendar day, from the observations and heat of the
+// season up to that day.
+func (ps *phenologyServiceImpl) StagesOn(ctx context.Context, vineyardID int, day time.Time) ([]model.PhenologyStage, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if _, err := ps.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	day = calendarDate(day.In(ps.loc), ps.loc)
+	from := climate.SeasonStart(day, vineyardLatitude(ctx, ps.db, vineyardID))
+	observations, err := ps.ListObservationsByDateRange(ctx, vineyardID, from, day)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := ps.db.ListBlocks(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	heat, heatNote, err := ps.heat(ctx, vineyardID, from, day)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int]*model.PhenologyObservation)
+	for i := range observations {
+		latest[blockKey(observations[i].BlockID)] = &observations[i] // By date, so the last is the latest
+	}
+	var stages []model.PhenologyStage
+	if len(blocks) == 0 || latest[0] != nil {
+		stages = append(stages, currentStage(latest[0], from, heat, heatNote))
+	}
+	for _, b := range blocks {
+		obs := latest[b.ID]
+		if whole := latest[0]; whole != nil && (obs == nil || whole.ObservedOn.After(obs.ObservedOn)) {
+			obs = whole
+		}
+		stage := currentStage(obs, from, heat, heatNote)
+		id := b.ID
+		stage.BlockID, stage.BlockName = &id, b.Name
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// currentStage advances obs, or winter bud on the eve of the season start when obs is nil, by the heat
+// accumulated after it.
+func currentStage(obs *model.PhenologyObservation, seasonStart time.Time, heat *model.GrowingDegreeDays, heatNote string) model.PhenologyStage {
+	var result model.PhenologyStage
+	start, since := phenology.Stages[0], seasonStart.AddDate(0, 0, -1)
+	if obs != nil {
+		start, since = observedStage(obs), obs.ObservedOn
+		result.Observation = obs
+	}
+	stage := start
+	switch {
+	case heatNote != "" && obs == nil:
+		result.Note = heatNote + "; record an observation to show the stage"
+		return result
+	case heatNote != "":
+		result.Note = heatNote
+	default:
+		gdd := climate.Round(heatAfter(heat, since))
+		result.GDDSince = &gdd
+		stage = phenology.Advance(start, gdd)
+	}
+	el, bbch := stage.EL, stage.BBCH
+	result.ELStage, result.BBCH, result.Description = &el, &bbch, stage.Description
+	result.Estimated = obs == nil || stage.EL != start.EL
+	return result
+}
+
+// observedStage returns the stage an observation recorded.
+func observedStage(obs *model.PhenologyObservation) phenology.Stage {
+	if s, err := phenology.Lookup(obs.System, obs.Stage); err == nil {
+		return s
+	}
+	s, _ := phenology.ByEL(obs.ELStage)
+	return s
+}
+
+// heatAfter returns the GDD accumulated after day to the end of the report.
+func heatAfter(report *model.GrowingDegreeDays, day time.Time) float64 {
+	var before float64
+	for _, d := range report.Days {
+		if d.Date.After(day) {
+			break
+		}
+		before = d.Accumulated
+	}
+	return report.SeasonToDate - before
+}
+
+// heat reads the season's GDD from its start to a day, or a note explaining why stages cannot be advanced.
+func (ps *phenologyServiceImpl) heat(ctx context.Context, vineyardID int, from, to time.Time) (*model.GrowingDegreeDays, string, error) {
+	report, err := ps.climate.GrowingDegreeDays(ctx, vineyardID, model.GDDQuery{Budbreak: from, From: from, To: to})
+	if errors.Is(err, ErrNoBoundingBox) {
+		return nil, "the vineyard has no bounding box for heat accumulation", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if coverage(report) < forecastMinCoverage {
+		return nil, "too few weather observations this season for heat accumulation", nil
+	}
+	return report, "", nil
+}
+
+func (ps *phenologyServiceImpl) validateObservation(obs *model.PhenologyObservation) error {
+	if obs.ObservedOn.IsZero() {
+		return fmt.Errorf("%w: observedOn is required", ErrInvalidPhenologyObservation)
+	}
+	obs.ObservedOn = calendarDate(obs.ObservedOn, ps.loc)
+	if obs.ObservedOn.After(time.Now().In(ps.loc)) {
+		return fmt.Errorf("%w: observedOn cannot be in the future", ErrInvalidPhenologyObservation)
+	}
+	obs.System = strings.ToLower(obs.System)
+	if obs.System == "" {
+		obs.System = phenology.SystemEL
+	}
+	stage, err := phenology.Lookup(obs.System, obs.Stage)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPhenologyObservation, err)
+	}
+	obs.ELStage, obs.BBCH, obs.Description = stage.EL, stage.BBCH, stage.Description
+	return nil
+}
+
+// checkReferences checks that the observation's block and image belong to the vineyard.
+func (ps *phenologyServiceImpl) checkReferences(ctx context.Context, vineyardID int, obs *model.PhenologyObservation) error {
+	if err := checkRecordBlock(ctx, ps.db, vineyardID, obs.BlockID); err != nil {
+		return err
+	}
+	if obs.ImageID == nil {
+		return nil
+	}
+	img, err := ps.db.GetImage(ctx, *obs.ImageID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && img.VineyardID != vineyardID {
+		return fmt.Errorf("%w: image %d is not an image of vineyard %d", ErrInvalidPhenologyObservation, *obs.ImageID, vineyardID)
+	}
+	return err
+}
+
+// describe fills in an observation's BBCH code and description, and returns its date, read from a DATE
+// column, as midnight in the climate time zone.
+func (ps *phenologyServiceImpl) describe(obs *model.PhenologyObservation) {
+	stage := observedStage(obs)
+	obs.BBCH, obs.Description = stage.BBCH, stage.Description
+	obs.ObservedOn = calendarDate(obs.ObservedOn, ps.loc)
+}
+
+func (ps *phenologyServiceImpl) describeAll(observations []model.PhenologyObservation) []model.PhenologyObservation {
+	for i := range observations {
+		ps.describe(&observations[i])
+	}
+	return observations
+}
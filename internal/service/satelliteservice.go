@@ -15,8 +15,10 @@ import (
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
 	"github.com/sthompson732/viticulture-harvester-app/internal/storage"
+	"github.com/sthompson732/viticulture-harvester-app/internal/webhook"
 )
 
 type SatelliteService interface {
@@ -26,32 +28,44 @@ type SatelliteService interface {
 	DeleteSatelliteData(ctx context.Context, id int) error
 	ListSatelliteDataByVineyard(ctx context.Context, vineyardID int) ([]model.SatelliteData, error)
 	ListSatelliteImageryByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.SatelliteData, error)
+	GetRecentSatelliteImages(ctx context.Context, vineyardID int, limit int) ([]model.SatelliteData, error)
 	ConcurrentSaveSatelliteData(ctx context.Context, datas []*model.SatelliteData, imageDatas []io.Reader) error
 }
 
 type satelliteServiceImpl struct {
-	db      *db.DB
-	storage *storage.StorageService
+	db       *db.DB
+	storage  storage.BlobStore
+	webhooks WebhookService
 }
 
-func NewSatelliteService(db *db.DB, storage *storage.StorageService) SatelliteService {
-	return &satelliteServiceImpl{db: db, storage: storage}
+func NewSatelliteService(db *db.DB, storage storage.BlobStore, webhooks WebhookService) SatelliteService {
+	return &satelliteServiceImpl{db: db, storage: storage, webhooks: webhooks}
 }
 
 func (s *satelliteServiceImpl) SaveSatelliteData(ctx context.Context, data *model.SatelliteData, imageData io.Reader) error {
 	if data == nil {
 		return errors.New("cannot save nil satellite data")
 	}
-
-	// Upload image data to cloud storage and retrieve the URL
-	imageURL, err := s.storage.UploadFile(ctx, "satellite_images/"+data.ImageURL, imageData)
-	if err != nil {
+	if err := geo.ValidateField("boundingBox", data.BoundingBox); err != nil {
 		return err
 	}
-	data.ImageURL = imageURL // Update image URL with the URL from storage
+
+	// Upload image data to cloud storage and retrieve the URL. Without image data the record
+	// keeps the provider's image URL, e.g. for ingested imagery metadata.
+	if imageData != nil {
+		imageURL, err := s.storage.UploadFile(ctx, "satellite_images/"+data.ImageURL, imageData)
+		if err != nil {
+			return err
+		}
+		data.ImageURL = imageURL // Update image URL with the URL from storage
+	}
 
 	// Save satellite data metadata in the database
-	return s.db.SaveSatelliteImageryMetadata(ctx, data, data.VineyardID)
+	if err := s.db.SaveSatelliteImageryMetadata(ctx, data, data.VineyardID); err != nil {
+		return err
+	}
+	s.webhooks.Emit(ctx, webhook.EventSatelliteIngested, &data.VineyardID, data)
+	return nil
 }
 
 func (s *satelliteServiceImpl) GetSatelliteData(ctx context.Context, id int) (*model.SatelliteData, error) {
@@ -65,11 +79,16 @@ func (s *satelliteServiceImpl) UpdateSatelliteData(ctx context.Context, data *mo
 	if data == nil || data.ID == 0 {
 		return errors.New("invalid satellite data")
 	}
-	imageURL, err := s.storage.UploadFile(ctx, "satellite_images/"+data.ImageURL, imageData)
-	if err != nil {
+	if err := geo.ValidateField("boundingBox", data.BoundingBox); err != nil {
 		return err
 	}
-	data.ImageURL = imageURL
+	if imageData != nil {
+		imageURL, err := s.storage.UploadFile(ctx, "satellite_images/"+data.ImageURL, imageData)
+		if err != nil {
+			return err
+		}
+		data.ImageURL = imageURL
+	}
 	return s.db.UpdateSatelliteImagery(ctx, data)
 }
 
@@ -87,6 +106,16 @@ func (s *satelliteServiceImpl) ListSatelliteDataByVineyard(ctx context.Context,
 	return s.db.ListSatelliteImageryByVineyard(ctx, vineyardID)
 }
 
+func (s *satelliteServiceImpl) GetRecentSatelliteImages(ctx context.Context, vineyardID int, limit int) ([]model.SatelliteData, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if limit <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+	return s.db.GetRecentSatelliteImagery(ctx, vineyardID, limit)
+}
+
 func (s *satelliteServiceImpl) ListSatelliteImageryByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.SatelliteData, error) {
 	if vineyardID <= 0 {
 		return nil, errors.New("invalid vineyard ID")
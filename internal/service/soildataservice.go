@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
 )
 
@@ -38,6 +39,12 @@ func (sds *soilDataServiceImpl) CreateSoilData(ctx context.Context, soilData *mo
 	if soilData == nil {
 		return errors.New("cannot create nil soil data")
 	}
+	if err := geo.ValidateField("location", soilData.Location); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, sds.db, soilData.VineyardID, soilData.BlockID); err != nil {
+		return err
+	}
 	return sds.db.SaveSoilData(ctx, soilData)
 }
 
@@ -55,6 +62,18 @@ func (sds *soilDataServiceImpl) UpdateSoilData(ctx context.Context, soilData *mo
 	if soilData.ID == 0 {
 		return errors.New("invalid soil data ID")
 	}
+	if err := geo.ValidateField("location", soilData.Location); err != nil {
+		return err
+	}
+	if soilData.BlockID != nil {
+		existing, err := sds.db.GetSoilData(ctx, soilData.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkRecordBlock(ctx, sds.db, existing.VineyardID, soilData.BlockID); err != nil {
+			return err
+		}
+	}
 	return sds.db.UpdateSoilData(ctx, soilData)
 }
 
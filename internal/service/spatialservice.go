@@ -0,0 +1,95 @@
+/*
+ * spatialservice.go: Spatial searches across vineyards, pest observations and satellite imagery.
+ * Usage: Backs the /spatial API: vineyards intersecting an area or within a distance of a point,
+ *        pest observations inside an area, and the satellite scenes covering a vineyard.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+// MaxSearchRadiusKm caps distance searches so a typo cannot scan every vineyard on the continent.
+const MaxSearchRadiusKm = 500
+
+// ErrNoBoundingBox is returned for coverage searches on a vineyard without a bounding box.
+var ErrNoBoundingBox = errors.New("vineyard has no bounding box")
+
+type SpatialService interface {
+	VineyardsIntersecting(ctx context.Context, area geo.Geometry) ([]model.Vineyard, error)
+	VineyardsNear(ctx context.Context, point geo.Point, radiusKm float64) ([]model.NearbyVineyard, error)
+	PestsWithin(ctx context.Context, area geo.Geometry, vineyardID int) ([]model.PestData, error)
+	SatelliteCoverage(ctx context.Context, vineyardID int, minPercent float64) ([]model.SatelliteCoverage, error)
+}
+
+type spatialServiceImpl struct {
+	db *db.DB
+}
+
+func NewSpatialService(db *db.DB) SpatialService {
+	return &spatialServiceImpl{db: db}
+}
+
+func (ss *spatialServiceImpl) VineyardsIntersecting(ctx context.Context, area geo.Geometry) ([]model.Vineyard, error) {
+	if err := validateArea(area); err != nil {
+		return nil, err
+	}
+	return ss.db.ListVineyardsIntersecting(ctx, area)
+}
+
+func (ss *spatialServiceImpl) VineyardsNear(ctx context.Context, point geo.Point, radiusKm float64) ([]model.NearbyVineyard, error) {
+	if err := point.Validate(); err != nil {
+		return nil, err
+	}
+	if radiusKm <= 0 || radiusKm > MaxSearchRadiusKm {
+		return nil, fmt.Errorf("radius must be between 0 and %d km", MaxSearchRadiusKm)
+	}
+	return ss.db.ListVineyardsNear(ctx, point, radiusKm)
+}
+
+func (ss *spatialServiceImpl) PestsWithin(ctx context.Context, area geo.Geometry, vineyardID int) ([]model.PestData, error) {
+	if err := validateArea(area); err != nil {
+		return nil, err
+	}
+	if vineyardID < 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return ss.db.ListPestDataWithin(ctx, area, vineyardID)
+}
+
+// SatelliteCoverage returns the scenes covering at least minPercent (0-100) of the vineyard.
+func (ss *spatialServiceImpl) SatelliteCoverage(ctx context.Context, vineyardID int, minPercent float64) ([]model.SatelliteCoverage, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if minPercent < 0 || minPercent > 100 {
+		return nil, errors.New("coverage must be between 0 and 100 percent")
+	}
+	vineyard, err := ss.db.GetVineyard(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	if len(vineyard.BoundingBox) == 0 {
+		return nil, fmt.Errorf("%w: vineyard %d", ErrNoBoundingBox, vineyardID)
+	}
+	return ss.db.ListSatelliteCoverage(ctx, vineyardID, minPercent)
+}
+
+// validateArea checks a search area; only polygons enclose anything.
+func validateArea(area geo.Geometry) error {
+	switch area.(type) {
+	case geo.Polygon, geo.MultiPolygon:
+		return area.Validate()
+	default:
+		return &geo.ValidationError{Field: "area", Reason: "expected a GeoJSON Polygon or MultiPolygon"}
+	}
+}
@@ -0,0 +1,403 @@
+/*
+ * sprayservice.go: Spray and chemical application records and their compliance checks.
+ * Usage: Each application records the product, its registration number, active ingredient and FRAC,
+ *        IRAC or HRAC resistance groups, the rate, the block or area treated, the applicator and the
+ *        product's restricted-entry (REI) and pre-harvest (PHI) intervals, along with the weather reading
+ *        nearest the time of application. CheckHarvest refuses harvest dates inside an application's
+ *        PHI, and every application is flagged when it repeats a resistance group of the previous
+ *        application of its kind to the same ground.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/climate"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+)
+
+const (
+	// MaxPHIDays bounds pre-harvest intervals, and so how far back a harvest date is checked.
+	MaxPHIDays = 365
+	// MaxREIHours bounds restricted-entry intervals.
+	MaxREIHours = 720
+	// sprayWeatherWindow is how far from the time of application a weather reading may be to be copied.
+	sprayWeatherWindow = 3 * time.Hour
+)
+
+var (
+	// ErrInvalidSprayApplication is returned for applications missing a product, rate or applicator, with
+	// out-of-range intervals or with an unknown resistance group.
+	ErrInvalidSprayApplication = errors.New("invalid spray application")
+	// ErrPHIViolation is returned for harvests dated inside the pre-harvest interval of an application
+	// to the same ground.
+	ErrPHIViolation = errors.New("harvest inside a pre-harvest interval")
+)
+
+// resistanceGroupPattern matches a resistance group code such as "FRAC 3", "IRAC 4A" or "frac m5".
+var resistanceGroupPattern = regexp.MustCompile(`^(FRAC|IRAC|HRAC) ?([0-9A-Z]+(\.[0-9A-Z]+)?)$`)
+
+type SprayService interface {
+	CreateApplication(ctx context.Context, app *model.SprayApplication) error
+	GetApplication(ctx context.Context, id int) (*model.SprayApplication, error)
+	UpdateApplication(ctx context.Context, app *model.SprayApplication) error
+	DeleteApplication(ctx context.Context, id int) error
+	ListApplications(ctx context.Context, vineyardID, blockID int) ([]model.SprayApplication, error)
+	ListApplicationsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.SprayApplication, error)
+	ActiveApplications(ctx context.Context, vineyardID int) ([]model.SprayApplication, error)
+	CheckHarvest(ctx context.Context, vineyardID int, blockID *int, day time.Time) error
+}
+
+type sprayServiceImpl struct {
+	db  *db.DB
+	loc *time.Location
+}
+
+// NewSprayService creates the spray service. Pre-harvest intervals count calendar days in the climate
+// time zone.
+func NewSprayService(db *db.DB, settings climate.Settings) SprayService {
+	return &sprayServiceImpl{db: db, loc: settings.Location}
+}
+
+func (ss *sprayServiceImpl) CreateApplication(ctx context.Context, app *model.SprayApplication) error {
+	if app == nil {
+		return errors.New("cannot create a nil spray application")
+	}
+	if app.VineyardID <= 0 {
+		return errors.New("invalid vineyard ID")
+	}
+	if err := validateSprayApplication(app); err != nil {
+		return err
+	}
+	if _, err := ss.db.GetVineyard(ctx, app.VineyardID); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, ss.db, app.VineyardID, app.BlockID); err != nil {
+		return err
+	}
+	if err := ss.fillDerived(ctx, app.VineyardID, app); err != nil {
+		return err
+	}
+	if err := ss.db.SaveSprayApplication(ctx, app); err != nil {
+		return err
+	}
+	return ss.annotate(ctx, app)
+}
+
+func (ss *sprayServiceImpl) GetApplication(ctx context.Context, id int) (*model.SprayApplication, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid spray application ID")
+	}
+	app, err := ss.db.GetSprayApplication(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ss.annotate(ctx, app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// UpdateApplication replaces an application and copies the weather reading nearest its new time.
+func (ss *sprayServiceImpl) UpdateApplication(ctx context.Context, app *model.SprayApplication) error {
+	if app == nil {
+		return errors.New("cannot update a nil spray application")
+	}
+	if app.ID <= 0 {
+		return errors.New("invalid spray application ID")
+	}
+	if err := validateSprayApplication(app); err != nil {
+		return err
+	}
+	existing, err := ss.db.GetSprayApplication(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, ss.db, existing.VineyardID, app.BlockID); err != nil {
+		return err
+	}
+	if err := ss.fillDerived(ctx, existing.VineyardID, app); err != nil {
+		return err
+	}
+	if err := ss.db.UpdateSprayApplication(ctx, app); err != nil {
+		return err
+	}
+	return ss.annotate(ctx, app)
+}
+
+func (ss *sprayServiceImpl) DeleteApplication(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid spray application ID")
+	}
+	return ss.db.DeleteSprayApplication(ctx, id)
+}
+
+// ListApplications returns a vineyard's applications, oldest first, only those of one block when
+// blockID is not zero.
+func (ss *sprayServiceImpl) ListApplications(ctx context.Context, vineyardID, blockID int) ([]model.SprayApplication, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return ss.annotated(ctx, vineyardID, func(app *model.SprayApplication) bool {
+		return blockID == 0 || blockKey(app.BlockID) == blockID
+	})
+}
+
+// ListApplicationsByDateRange returns a vineyard's applications made from start to end, both days
+// included, oldest first.
+func (ss *sprayServiceImpl) ListApplicationsByDateRange(ctx context.Context, vineyardID int, start, end time.Time) ([]model.SprayApplication, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	from, to := calendarDate(start, ss.loc), calendarDate(end, ss.loc).AddDate(0, 0, 1)
+	return ss.annotated(ctx, vineyardID, func(app *model.SprayApplication) bool {
+		return !app.AppliedAt.Before(from) && app.AppliedAt.Before(to)
+	})
+}
+
+// ActiveApplications returns a vineyard's applications whose restricted-entry or pre-harvest interval
+// has not yet ended.
+func (ss *sprayServiceImpl) ActiveApplications(ctx context.Context, vineyardID int) ([]model.SprayApplication, error) {
+	if vineyardID <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	if _, err := ss.db.GetVineyard(ctx, vineyardID); err != nil {
+		return nil, err
+	}
+	now := time.Now().In(ss.loc)
+	today := calendarDate(now, ss.loc)
+	return ss.annotated(ctx, vineyardID, func(app *model.SprayApplication) bool {
+		return app.ReentryAt != nil && app.ReentryAt.After(now) ||
+			app.HarvestAllowedOn != nil && app.HarvestAllowedOn.After(today)
+	})
+}
+
+// CheckHarvest returns ErrPHIViolation when day falls inside the pre-harvest interval of an application
+// that reached the block. An application with an area reached every block its area intersects, one
+// without an area its block, or every block when it has neither. A harvest without a block is checked
+// against every application.
+func (ss *sprayServiceImpl) CheckHarvest(ctx context.Context, vineyardID int, blockID *int, day time.Time) error {
+	day = calendarDate(day, ss.loc)
+	apps, err := ss.db.ListSprayApplicationsOnBlock(ctx, vineyardID, blockID, day.AddDate(0, 0, -MaxPHIDays), day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	var blocking *model.SprayApplication
+	for i := range apps {
+		app := &apps[i]
+		ss.describe(app)
+		if app.HarvestAllowedOn == nil || !day.Before(*app.HarvestAllowedOn) {
+			continue
+		}
+		if blocking == nil || app.HarvestAllowedOn.After(*blocking.HarvestAllowedOn) {
+			blocking = app
+		}
+	}
+	if blocking == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %s was applied on %s with a %d-day PHI, so harvest is allowed from %s", ErrPHIViolation,
+		blocking.Product, blocking.AppliedAt.Format("2006-01-02"), *blocking.PHIDays, blocking.HarvestAllowedOn.Format("2006-01-02"))
+}
+
+// fillDerived works out the acres treated when they are not given, from the area or else the block's
+// boundary, and copies the weather reading nearest the time of application.
+func (ss *sprayServiceImpl) fillDerived(ctx context.Context, vineyardID int, app *model.SprayApplication) error {
+	if app.Acres == nil {
+		var area float64
+		switch {
+		case len(app.Area) > 0:
+			a, err := ss.db.PolygonArea(ctx, app.Area)
+			if err != nil {
+				return err
+			}
+			area = a
+		case app.BlockID != nil:
+			areas, err := ss.db.BlockAreas(ctx, vineyardID)
+			if err != nil {
+				return err
+			}
+			area = areas[*app.BlockID]
+		}
+		if area > 0 {
+			acres := roundTo(area/squareMetresPerAcre, 2)
+			app.Acres = &acres
+		}
+	}
+	app.Weather = nil
+	weather, err := ss.db.NearestWeatherData(ctx, vineyardID, app.AppliedAt, sprayWeatherWindow)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	app.Weather = &model.SprayWeather{
+		WeatherID:     weather.ID,
+		ObservedAt:    weather.ObservationTime,
+		Temperature:   weather.Temperature,
+		Humidity:      weather.Humidity,
+		WindSpeed:     weather.WindSpeed,
+		Precipitation: weather.Precipitation,
+	}
+	return nil
+}
+
+// annotate fills in an application's intervals and resistance flag, which depends on the vineyard's
+// earlier applications.
+func (ss *sprayServiceImpl) annotate(ctx context.Context, app *model.SprayApplication) error {
+	apps, err := ss.annotated(ctx, app.VineyardID, func(a *model.SprayApplication) bool { return a.ID == app.ID })
+	if err != nil {
+		return err
+	}
+	if len(apps) == 1 {
+		*app = apps[0]
+	}
+	return nil
+}
+
+// annotated returns the vineyard's applications that keep selects, oldest first, with their intervals
+// and resistance flags filled in.
+func (ss *sprayServiceImpl) annotated(ctx context.Context, vineyardID int, keep func(*model.SprayApplication) bool) ([]model.SprayApplication, error) {
+	apps, err := ss.db.ListSprayApplications(ctx, vineyardID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range apps {
+		ss.describe(&apps[i])
+	}
+	flagResistance(apps)
+	var kept []model.SprayApplication
+	for i := range apps {
+		if keep(&apps[i]) {
+			kept = append(kept, apps[i])
+		}
+	}
+	return kept, nil
+}
+
+// describe fills in when an application's restricted-entry and pre-harvest intervals end.
+func (ss *sprayServiceImpl) describe(app *model.SprayApplication) {
+	app.AppliedAt = app.AppliedAt.In(ss.loc)
+	app.ReentryAt, app.HarvestAllowedOn = nil, nil
+	if app.REIHours != nil && *app.REIHours > 0 {
+		reentry := app.AppliedAt.Add(time.Duration(*app.REIHours * float64(time.Hour)))
+		app.ReentryAt = &reentry
+	}
+	if app.PHIDays != nil && *app.PHIDays > 0 {
+		allowed := calendarDate(app.AppliedAt, ss.loc).AddDate(0, 0, *app.PHIDays)
+		app.HarvestAllowedOn = &allowed
+	}
+}
+
+// flagResistance flags each application, of applications ordered oldest first, that repeats a
+// resistance group of the previous application to the same ground using the same classification.
+// Multi-site FRAC M groups carry little resistance risk and are not flagged.
+func flagResistance(apps []model.SprayApplication) {
+	for i := range apps {
+		app := &apps[i]
+		var repeated []string
+		var previous *model.SprayApplication
+		for _, group := range app.ResistanceGroups {
+			if strings.HasPrefix(group, "FRAC M") {
+				continue
+			}
+			prev := previousOfClass(apps[:i], app.BlockID, resistanceClass(group))
+			if prev != nil && containsString(prev.ResistanceGroups, group) {
+				repeated, previous = append(repeated, group), prev
+			}
+		}
+		if len(repeated) > 0 {
+			app.ResistanceRisk = true
+			app.ResistanceNote = fmt.Sprintf("%s also used in the previous application, %s on %s; rotate to another group",
+				strings.Join(repeated, ", "), previous.Product, previous.AppliedAt.Format("2006-01-02"))
+		}
+	}
+}
+
+// previousOfClass returns the latest of earlier applications to the same ground as blockID with a group
+// of class, or nil.
+func previousOfClass(earlier []model.SprayApplication, blockID *int, class string) *model.SprayApplication {
+	for i := len(earlier) - 1; i >= 0; i-- {
+		if !sameGround(earlier[i].BlockID, blockID) {
+			continue
+		}
+		for _, group := range earlier[i].ResistanceGroups {
+			if resistanceClass(group) == class {
+				return &earlier[i]
+			}
+		}
+	}
+	return nil
+}
+
+// resistanceClass returns the classification of a normalized group, such as FRAC.
+func resistanceClass(group string) string {
+	class, _, _ := strings.Cut(group, " ")
+	return class
+}
+
+// sameGround reports whether records of two blocks may cover the same vines. A record without a block
+// may cover any block.
+func sameGround(a, b *int) bool {
+	return a == nil || b == nil || *a == *b
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSprayApplication checks an application and normalizes its resistance groups to the form
+// "FRAC 3".
+func validateSprayApplication(app *model.SprayApplication) error {
+	app.Product = strings.TrimSpace(app.Product)
+	app.Applicator = strings.TrimSpace(app.Applicator)
+	app.RateUnit = strings.TrimSpace(app.RateUnit)
+	switch {
+	case app.AppliedAt.IsZero():
+		return fmt.Errorf("%w: appliedAt is required", ErrInvalidSprayApplication)
+	case app.Product == "":
+		return fmt.Errorf("%w: product is required", ErrInvalidSprayApplication)
+	case app.Applicator == "":
+		return fmt.Errorf("%w: applicator is required", ErrInvalidSprayApplication)
+	case app.Rate <= 0 || app.RateUnit == "":
+		return fmt.Errorf("%w: a positive rate and its rateUnit are required", ErrInvalidSprayApplication)
+	case app.Acres != nil && *app.Acres <= 0:
+		return fmt.Errorf("%w: acres must be positive", ErrInvalidSprayApplication)
+	case app.REIHours != nil && (*app.REIHours < 0 || *app.REIHours > MaxREIHours):
+		return fmt.Errorf("%w: reiHours must be between 0 and %d", ErrInvalidSprayApplication, MaxREIHours)
+	case app.PHIDays != nil && (*app.PHIDays < 0 || *app.PHIDays > MaxPHIDays):
+		return fmt.Errorf("%w: phiDays must be between 0 and %d", ErrInvalidSprayApplication, MaxPHIDays)
+	}
+	if err := geo.ValidateField("area", app.Area); err != nil {
+		return err
+	}
+	groups := make([]string, 0, len(app.ResistanceGroups))
+	for _, g := range app.ResistanceGroups {
+		m := resistanceGroupPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(g)))
+		if m == nil {
+			return fmt.Errorf("%w: resistance group %q is not a FRAC, IRAC or HRAC code such as \"FRAC 3\"", ErrInvalidSprayApplication, g)
+		}
+		if group := m[1] + " " + m[2]; !containsString(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	app.ResistanceGroups = groups
+	return nil
+}
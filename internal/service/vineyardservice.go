@@ -13,6 +13,7 @@ import (
 	"errors"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
 )
 
@@ -23,20 +24,27 @@ type VineyardService interface {
 	DeleteVineyard(ctx context.Context, id int) error
 	ListVineyards(ctx context.Context) ([]model.Vineyard, error)
 	GetVineyardWithEnvironmentalData(ctx context.Context, id int) (*model.Vineyard, error)
+	GetVineyardGeometry(ctx context.Context, id int) (*model.VineyardGeometry, error)
 }
 
 type vineyardServiceImpl struct {
-	db *db.DB
+	db        *db.DB
+	phenology PhenologyService
 }
 
-func NewVineyardService(db *db.DB) VineyardService {
-	return &vineyardServiceImpl{db: db}
+// NewVineyardService creates the vineyard service. Environmental data includes the current growth stage
+// of each block from the phenology service.
+func NewVineyardService(db *db.DB, phenology PhenologyService) VineyardService {
+	return &vineyardServiceImpl{db: db, phenology: phenology}
 }
 
 func (vs *vineyardServiceImpl) CreateVineyard(ctx context.Context, vineyard *model.Vineyard) error {
 	if vineyard == nil {
 		return errors.New("cannot create a nil vineyard")
 	}
+	if err := geo.ValidateField("boundingBox", vineyard.BoundingBox); err != nil {
+		return err
+	}
 	return vs.db.SaveVineyard(ctx, vineyard)
 }
 
@@ -54,6 +62,9 @@ func (vs *vineyardServiceImpl) UpdateVineyard(ctx context.Context, vineyard *mod
 	if vineyard.ID == 0 {
 		return errors.New("invalid vineyard ID")
 	}
+	if err := geo.ValidateField("boundingBox", vineyard.BoundingBox); err != nil {
+		return err
+	}
 	return vs.db.UpdateVineyard(ctx, vineyard)
 }
 
@@ -72,5 +83,19 @@ func (vs *vineyardServiceImpl) GetVineyardWithEnvironmentalData(ctx context.Cont
 	if id <= 0 {
 		return nil, errors.New("invalid vineyard ID")
 	}
-	return vs.db.GetVineyardWithEnvironmentalData(ctx, id)
+	vineyard, err := vs.db.GetVineyardWithEnvironmentalData(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if vineyard.Phenology, err = vs.phenology.CurrentStages(ctx, id); err != nil {
+		return nil, err
+	}
+	return vineyard, nil
+}
+
+func (vs *vineyardServiceImpl) GetVineyardGeometry(ctx context.Context, id int) (*model.VineyardGeometry, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid vineyard ID")
+	}
+	return vs.db.GetVineyardGeometry(ctx, id)
 }
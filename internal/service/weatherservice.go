@@ -11,10 +11,13 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/geo"
 	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/webhook"
 )
 
 type WeatherService interface {
@@ -27,18 +30,38 @@ type WeatherService interface {
 }
 
 type weatherServiceImpl struct {
-	db *db.DB
+	db        *db.DB
+	alerts    AlertService
+	webhooks  WebhookService
+	forecasts HarvestForecastService
 }
 
-func NewWeatherService(db *db.DB) WeatherService {
-	return &weatherServiceImpl{db: db}
+// NewWeatherService creates the weather service. New records are sent to webhooks, evaluated against
+// the vineyard's alert rules and schedule a harvest forecast recompute.
+func NewWeatherService(db *db.DB, alerts AlertService, webhooks WebhookService, forecasts HarvestForecastService) WeatherService {
+	return &weatherServiceImpl{db: db, alerts: alerts, webhooks: webhooks, forecasts: forecasts}
 }
 
 func (ws *weatherServiceImpl) CreateWeatherData(ctx context.Context, weather *model.WeatherData) error {
 	if weather == nil {
 		return errors.New("cannot create nil weather data")
 	}
-	return ws.db.SaveWeatherData(ctx, weather)
+	if err := geo.ValidateField("location", weather.Location); err != nil {
+		return err
+	}
+	if err := checkRecordBlock(ctx, ws.db, weather.VineyardID, weather.BlockID); err != nil {
+		return err
+	}
+	if err := ws.db.SaveWeatherData(ctx, weather); err != nil {
+		return err
+	}
+	ws.webhooks.Emit(ctx, webhook.EventWeatherCreated, &weather.VineyardID, weather)
+	ws.forecasts.Schedule(weather.VineyardID, model.ForecastTriggerWeather)
+	// The record is saved either way; a failed evaluation only loses its alerts
+	if err := ws.alerts.EvaluateWeather(ctx, weather); err != nil {
+		log.Printf("Failed to evaluate alert rules for weather data %d: %v", weather.ID, err)
+	}
+	return nil
 }
 
 func (ws *weatherServiceImpl) GetWeatherData(ctx context.Context, id int) (*model.WeatherData, error) {
@@ -55,6 +78,18 @@ func (ws *weatherServiceImpl) UpdateWeatherData(ctx context.Context, weather *mo
 	if weather.ID == 0 {
 		return errors.New("invalid weather data ID")
 	}
+	if err := geo.ValidateField("location", weather.Location); err != nil {
+		return err
+	}
+	if weather.BlockID != nil {
+		existing, err := ws.db.GetWeatherData(ctx, weather.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkRecordBlock(ctx, ws.db, existing.VineyardID, weather.BlockID); err != nil {
+			return err
+		}
+	}
 	return ws.db.UpdateWeatherData(ctx, weather)
 }
 
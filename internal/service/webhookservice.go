@@ -0,0 +1,316 @@
+/*
+ * webhookservice.go: Webhook subscriptions and delivery of data events to them.
+ * Usage: The pest, weather, satellite, alert and ingestion run services call Emit when records are created,
+ *        alerts raised or runs fail. Emit stores a delivery for every enabled webhook subscribed to the
+ *        event, and RunDeliveries POSTs due deliveries in the background. Failed attempts are retried
+ *        with exponential backoff from 30 seconds up to an hour until webhooks.maxAttempts is reached;
+ *        every attempt is logged. Any delivery can be replayed as a new one.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+	"github.com/sthompson732/viticulture-harvester-app/internal/db"
+	"github.com/sthompson732/viticulture-harvester-app/internal/model"
+	"github.com/sthompson732/viticulture-harvester-app/internal/webhook"
+)
+
+// ErrInvalidWebhook is returned for webhooks with a malformed URL, no secret or unknown events.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Delivery retry backoff, doubled for each failed attempt.
+const (
+	webhookMinBackoff = 30 * time.Second
+	webhookMaxBackoff = time.Hour
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, hook *model.Webhook) error
+	GetWebhook(ctx context.Context, id int) (*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, hook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id int) error
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	ListDeliveries(ctx context.Context, webhookID int, status string, limit int) ([]model.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, error)
+	Emit(ctx context.Context, event string, vineyardID *int, data interface{})
+	RunDeliveries(ctx context.Context)
+}
+
+type webhookServiceImpl struct {
+	db           *db.DB
+	sender       *webhook.Sender
+	timeout      time.Duration
+	pollInterval time.Duration
+	maxAttempts  int
+	batchSize    int
+}
+
+// NewWebhookService creates the webhook service from the notifications.webhooks settings.
+func NewWebhookService(db *db.DB, cfg config.WebhookConfig) (WebhookService, error) {
+	interval, err := cfg.Interval()
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := cfg.RequestTimeout()
+	if err != nil {
+		return nil, err
+	}
+	ws := &webhookServiceImpl{
+		db:           db,
+		sender:       webhook.NewSender(timeout),
+		timeout:      timeout,
+		pollInterval: interval,
+		maxAttempts:  cfg.MaxAttempts,
+		batchSize:    cfg.BatchSize,
+	}
+	if ws.maxAttempts <= 0 {
+		ws.maxAttempts = config.DefaultWebhookMaxAttempts
+	}
+	if ws.batchSize <= 0 {
+		ws.batchSize = config.DefaultWebhookBatchSize
+	}
+	return ws, nil
+}
+
+// CreateWebhook stores a webhook. Its secret is not returned.
+func (ws *webhookServiceImpl) CreateWebhook(ctx context.Context, hook *model.Webhook) error {
+	if hook == nil {
+		return errors.New("cannot create a nil webhook")
+	}
+	if hook.Secret == "" {
+		return fmt.Errorf("%w: a secret is required", ErrInvalidWebhook)
+	}
+	if err := ws.validateWebhook(ctx, hook); err != nil {
+		return err
+	}
+	if err := ws.db.SaveWebhook(ctx, hook); err != nil {
+		return err
+	}
+	hook.Secret = ""
+	return nil
+}
+
+func (ws *webhookServiceImpl) GetWebhook(ctx context.Context, id int) (*model.Webhook, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid webhook ID")
+	}
+	hook, err := ws.db.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return hook, nil
+}
+
+// UpdateWebhook replaces a webhook's settings, keeping its secret when none is given.
+func (ws *webhookServiceImpl) UpdateWebhook(ctx context.Context, hook *model.Webhook) error {
+	if hook == nil {
+		return errors.New("cannot update a nil webhook")
+	}
+	if hook.ID <= 0 {
+		return errors.New("invalid webhook ID")
+	}
+	if err := ws.validateWebhook(ctx, hook); err != nil {
+		return err
+	}
+	if err := ws.db.UpdateWebhook(ctx, hook); err != nil {
+		return err
+	}
+	hook.Secret = ""
+	return nil
+}
+
+func (ws *webhookServiceImpl) DeleteWebhook(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.New("invalid webhook ID")
+	}
+	return ws.db.DeleteWebhook(ctx, id)
+}
+
+func (ws *webhookServiceImpl) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	hooks, err := ws.db.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+func (ws *webhookServiceImpl) validateWebhook(ctx context.Context, hook *model.Webhook) error {
+	hook.URL = strings.TrimSpace(hook.URL)
+	if err := webhook.ValidateURL(hook.URL); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if len(hook.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	for _, event := range hook.Events {
+		if !knownEvent(event) {
+			return fmt.Errorf("%w: unknown event %q, expected one of %s", ErrInvalidWebhook, event,
+				strings.Join(webhook.Events, ", "))
+		}
+	}
+	if hook.VineyardID != nil {
+		_, err := ws.db.GetVineyard(ctx, *hook.VineyardID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: vineyard %d does not exist", ErrInvalidWebhook, *hook.VineyardID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func knownEvent(event string) bool {
+	for _, e := range webhook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ListDeliveries returns a webhook's deliveries newest first, optionally of one status. limit
+// defaults to DefaultRunListLimit and is capped at MaxRunListLimit.
+func (ws *webhookServiceImpl) ListDeliveries(ctx context.Context, webhookID int, status string, limit int) ([]model.WebhookDelivery, error) {
+	if webhookID <= 0 {
+		return nil, errors.New("invalid webhook ID")
+	}
+	if _, err := ws.db.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultRunListLimit
+	}
+	if limit > MaxRunListLimit {
+		limit = MaxRunListLimit
+	}
+	return ws.db.ListWebhookDeliveries(ctx, webhookID, status, limit)
+}
+
+// GetDelivery returns a delivery with its attempt log.
+func (ws *webhookServiceImpl) GetDelivery(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, error) {
+	if webhookID <= 0 || id <= 0 {
+		return nil, errors.New("invalid webhook delivery ID")
+	}
+	return ws.db.GetWebhookDelivery(ctx, webhookID, id)
+}
+
+// ReplayDelivery queues a delivery's payload again as a new delivery, whatever became of the original.
+func (ws *webhookServiceImpl) ReplayDelivery(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, error) {
+	if webhookID <= 0 || id <= 0 {
+		return nil, errors.New("invalid webhook delivery ID")
+	}
+	return ws.db.ReplayWebhookDelivery(ctx, webhookID, id)
+}
+
+// Emit queues an event for the subscribed webhooks. A failure is logged and loses the event rather
+// than failing the change that caused it.
+func (ws *webhookServiceImpl) Emit(ctx context.Context, event string, vineyardID *int, data interface{}) {
+	payload, err := json.Marshal(webhook.Envelope{
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		VineyardID: vineyardID,
+		Data:       data,
+	})
+	if err == nil {
+		_, err = ws.db.EnqueueWebhookEvent(ctx, event, vineyardID, payload)
+	}
+	if err != nil {
+		log.Printf("Failed to queue %s webhook deliveries: %v", event, err)
+	}
+}
+
+// RunDeliveries sends due deliveries every poll interval until ctx is cancelled.
+func (ws *webhookServiceImpl) RunDeliveries(ctx context.Context) {
+	ticker := time.NewTicker(ws.pollInterval)
+	defer ticker.Stop()
+	for {
+		ws.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue sends one batch of due deliveries. Deliveries queued before their webhook was disabled
+// are still sent.
+func (ws *webhookServiceImpl) deliverDue(ctx context.Context) {
+	// A claim must outlast the slowest possible batch so that no other instance sends it meanwhile
+	lease := time.Duration(ws.batchSize)*ws.timeout + time.Minute
+	deliveries, err := ws.db.ClaimDueDeliveries(ctx, ws.batchSize, lease)
+	if err != nil {
+		log.Printf("Failed to read due webhook deliveries: %v", err)
+		return
+	}
+	hooks := make(map[int]*model.Webhook)
+	for _, delivery := range deliveries {
+		hook, ok := hooks[delivery.WebhookID]
+		if !ok {
+			if hook, err = ws.db.GetWebhook(ctx, delivery.WebhookID); err != nil {
+				log.Printf("Failed to read webhook %d: %v", delivery.WebhookID, err)
+				continue
+			}
+			hooks[delivery.WebhookID] = hook
+		}
+		ws.attempt(ctx, hook, delivery)
+	}
+}
+
+// attempt sends a delivery once and logs the outcome.
+func (ws *webhookServiceImpl) attempt(ctx context.Context, hook *model.Webhook, delivery model.WebhookDelivery) {
+	started := time.Now()
+	resp, sendErr := ws.sender.Send(ctx, hook.URL, hook.Secret, delivery.Event, delivery.ID, delivery.Payload)
+	attempt := &model.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if resp != nil {
+		attempt.StatusCode = &resp.StatusCode
+		attempt.ResponseBody = resp.Body
+	}
+
+	status, next := model.DeliverySucceeded, time.Now()
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		status, next = model.DeliveryPending, time.Now().Add(webhookBackoff(attempt.Attempt))
+		if attempt.Attempt >= ws.maxAttempts {
+			status = model.DeliveryFailed
+			log.Printf("Giving up on webhook delivery %d after %d attempts: %v", delivery.ID, attempt.Attempt, sendErr)
+		}
+	}
+	if err := ws.db.RecordDeliveryAttempt(ctx, attempt, status, next); err != nil {
+		log.Printf("Failed to record attempt of webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// webhookBackoff returns the delay after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookMinBackoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay
+}
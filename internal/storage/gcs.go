@@ -0,0 +1,136 @@
+/*
+ * gcs.go: Google Cloud Storage implementation of BlobStore.
+ * Implements file saving, retrieval, and deletion within Google Cloud Storage.
+ * Usage: Default backend for imageservice and satelliteservice in GCP deployments.
+ * Author(s): Shannon Thompson
+ * Created on: 04/12/2024
+ */
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCSStore encapsulates the Google Cloud Storage client and related operations.
+type GCSStore struct {
+	Client     *storage.Client
+	BucketName string
+}
+
+// NewGCSStore initializes a new storage service with the provided Google Cloud Storage bucket.
+func NewGCSStore(ctx context.Context, bucketName, credentialsPath string) (*GCSStore, error) {
+	// If credentials path is provided, use it to authenticate the client.
+	var client *storage.Client
+	var err error
+	if credentialsPath != "" {
+		client, err = storage.NewClient(ctx, option.WithCredentialsFile(credentialsPath))
+	} else {
+		// Otherwise, use the default credentials.
+		client, err = storage.NewClient(ctx)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &GCSStore{
+		Client:     client,
+		BucketName: bucketName,
+	}, nil
+}
+
+// UploadFile uploads any file to the cloud storage and returns its URL.
+func (s *GCSStore) UploadFile(ctx context.Context, filePath string, fileData io.Reader) (string, error) {
+	bucket := s.Client.Bucket(s.BucketName)
+	obj := bucket.Object(filePath)
+
+	contentType, fileData, err := detectContentType(fileData)
+	if err != nil {
+		return "", err
+	}
+
+	w := obj.NewWriter(ctx)
+	w.ContentType = contentType
+
+	if _, err := io.Copy(w, fileData); err != nil {
+		return "", fmt.Errorf("failed to write file to bucket: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize file upload: %w", err)
+	}
+
+	if err := obj.ACL().Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
+		return "", fmt.Errorf("failed to set file public: %w", err)
+	}
+
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get uploaded file attributes: %w", err)
+	}
+
+	return attrs.MediaLink, nil
+}
+
+// Get opens a stream over the contents of a file in cloud storage.
+func (s *GCSStore) Get(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	r, err := s.Client.Bucket(s.BucketName).Object(filePath).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return r, nil
+}
+
+// DeleteFile deletes a file from cloud storage.
+func (s *GCSStore) DeleteFile(ctx context.Context, filePath string) error {
+	bucket := s.Client.Bucket(s.BucketName)
+	obj := bucket.Object(filePath)
+
+	err := obj.Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// GetFileMetadata retrieves metadata of a file stored in cloud storage.
+func (s *GCSStore) GetFileMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	bucket := s.Client.Bucket(s.BucketName)
+	obj := bucket.Object(filePath)
+
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file attributes: %w", err)
+	}
+	return &FileMetadata{
+		Name:        attrs.Name,
+		Size:        attrs.Size,
+		ContentType: attrs.ContentType,
+		Updated:     attrs.Updated,
+		URL:         attrs.MediaLink,
+	}, nil
+}
+
+// ListImages retrieves a list of image URLs from the cloud storage.
+func (s *GCSStore) ListImages(ctx context.Context) ([]string, error) {
+	var urls []string
+	it := s.Client.Bucket(s.BucketName).Objects(ctx, nil)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images: %w", err)
+		}
+		urls = append(urls, attrs.MediaLink)
+	}
+	return urls, nil
+}
@@ -0,0 +1,172 @@
+/*
+ * local.go: Local filesystem implementation of BlobStore.
+ * Stores uploaded files beneath a root directory on disk.
+ * Usage: Lets development machines and field laptops run without cloud credentials.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps files in a directory tree rooted at RootDir.
+type LocalStore struct {
+	RootDir string
+	BaseURL string // Optional public URL prefix; file:// URLs are returned when empty
+}
+
+// NewLocalStore creates the root directory if needed and returns a store backed by it.
+func NewLocalStore(rootDir, baseURL string) (*LocalStore, error) {
+	if rootDir == "" {
+		return nil, fmt.Errorf("local storage root directory is not set")
+	}
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage root: %w", err)
+	}
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+	return &LocalStore{
+		RootDir: absRoot,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// UploadFile writes the file beneath the root directory and returns its URL.
+func (s *LocalStore) UploadFile(ctx context.Context, filePath string, fileData io.Reader) (string, error) {
+	if fileData == nil {
+		return "", fmt.Errorf("no file data provided")
+	}
+	fullPath := s.resolve(filePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never observe a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, &ctxReader{ctx: ctx, r: fileData}); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize file upload: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return "", fmt.Errorf("failed to finalize file upload: %w", err)
+	}
+
+	return s.url(fullPath), nil
+}
+
+// Get opens the stored file for reading.
+func (s *LocalStore) Get(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	f, err := os.Open(s.resolve(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return f, nil
+}
+
+// DeleteFile removes a file from the root directory.
+func (s *LocalStore) DeleteFile(ctx context.Context, filePath string) error {
+	if err := os.Remove(s.resolve(filePath)); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// GetFileMetadata retrieves metadata of a stored file.
+func (s *LocalStore) GetFileMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	fullPath := s.resolve(filePath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file attributes: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(fullPath))
+	if contentType == "" {
+		f, err := os.Open(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		contentType, _, err = detectContentType(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileMetadata{
+		Name:        filepath.ToSlash(strings.TrimPrefix(fullPath, s.RootDir+string(filepath.Separator))),
+		Size:        info.Size(),
+		ContentType: contentType,
+		Updated:     info.ModTime(),
+		URL:         s.url(fullPath),
+	}, nil
+}
+
+// ListImages returns the URLs of every file beneath the root directory.
+func (s *LocalStore) ListImages(ctx context.Context) ([]string, error) {
+	var urls []string
+	err := filepath.WalkDir(s.RootDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.Type().IsRegular() && !strings.HasPrefix(d.Name(), ".upload-") {
+			urls = append(urls, s.url(p))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	return urls, nil
+}
+
+// resolve maps an object path onto the filesystem, keeping it inside the root directory.
+func (s *LocalStore) resolve(filePath string) string {
+	clean := path.Clean("/" + filepath.ToSlash(filePath))
+	return filepath.Join(s.RootDir, filepath.FromSlash(clean))
+}
+
+func (s *LocalStore) url(fullPath string) string {
+	rel, err := filepath.Rel(s.RootDir, fullPath)
+	if err != nil || s.BaseURL == "" {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(fullPath)}).String()
+	}
+	return s.BaseURL + "/" + (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+}
+
+// ctxReader stops a copy once the context has been cancelled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
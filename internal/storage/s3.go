@@ -0,0 +1,320 @@
+/*
+ * s3.go: S3-compatible implementation of BlobStore.
+ * Talks to Amazon S3, MinIO or any other S3 API using AWS Signature Version 4.
+ * Usage: Lets on-prem and development deployments point file storage at a local MinIO.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+)
+
+// S3Store stores files in a bucket of an S3-compatible object store.
+type S3Store struct {
+	Endpoint        *url.URL
+	Region          string
+	BucketName      string
+	AccessKeyID     string
+	SecretAccessKey string
+	UsePathStyle    bool
+	Client          *http.Client
+}
+
+// NewS3Store initializes an S3 store for the given bucket and connection settings.
+func NewS3Store(bucketName string, cfg config.S3StorageConfig) (*S3Store, error) {
+	if bucketName == "" {
+		return nil, fmt.Errorf("s3 bucket name is not set")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Store{
+		Endpoint:        endpoint,
+		Region:          region,
+		BucketName:      bucketName,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		UsePathStyle:    cfg.UsePathStyle,
+		Client:          &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// UploadFile stores the file under the given key and returns its URL.
+func (s *S3Store) UploadFile(ctx context.Context, filePath string, fileData io.Reader) (string, error) {
+	contentType, fileData, err := detectContentType(fileData)
+	if err != nil {
+		return "", err
+	}
+
+	// S3 requires a known content length, so the payload is buffered before signing
+	payload, err := io.ReadAll(fileData)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file data: %w", err)
+	}
+
+	objectURL := s.objectURL(filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("creating upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = int64(len(payload))
+
+	resp, err := s.do(req, payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	resp.Body.Close()
+
+	return objectURL.String(), nil
+}
+
+// Get opens a stream over the contents of an object.
+func (s *S3Store) Get(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(filePath).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating get request: %w", err)
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// DeleteFile deletes an object from the bucket.
+func (s *S3Store) DeleteFile(ctx context.Context, filePath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(filePath).String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating delete request: %w", err)
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetFileMetadata retrieves metadata of an object using a HEAD request.
+func (s *S3Store) GetFileMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	objectURL := s.objectURL(filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, objectURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating head request: %w", err)
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file attributes: %w", err)
+	}
+	resp.Body.Close()
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	updated, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &FileMetadata{
+		Name:        filePath,
+		Size:        size,
+		ContentType: resp.Header.Get("Content-Type"),
+		Updated:     updated,
+		URL:         objectURL.String(),
+	}, nil
+}
+
+// listBucketResult is the subset of the ListObjectsV2 response we rely on.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ListImages retrieves the URLs of every object in the bucket.
+func (s *S3Store) ListImages(ctx context.Context) ([]string, error) {
+	var urls []string
+	token := ""
+	for {
+		listURL := s.objectURL("")
+		query := url.Values{"list-type": {"2"}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		listURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating list request: %w", err)
+		}
+		resp, err := s.do(req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images: %w", err)
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding list response: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			urls = append(urls, s.objectURL(obj.Key).String())
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	return urls, nil
+}
+
+// objectURL builds the URL of an object, or of the bucket itself when key is empty.
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.Endpoint
+	basePath := strings.TrimRight(u.Path, "/")
+	key = strings.TrimLeft(key, "/")
+	if s.UsePathStyle {
+		u.Path = basePath + "/" + s.BucketName + "/" + key
+	} else {
+		u.Host = s.BucketName + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	u.RawPath = awsURIEncode(u.Path, false)
+	return &u
+}
+
+// do signs and sends a request, turning non-2xx responses into errors.
+func (s *S3Store) do(req *http.Request, payload []byte) (*http.Response, error) {
+	s.sign(req, payload, time.Now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+func (s *S3Store) sign(req *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHex,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsURIEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+
+	scope := shortDate + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), shortDate)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode percent-encodes everything except the unreserved characters, as SigV4 requires.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -1,6 +1,6 @@
 /*
- * storage.go: Manages file operations in cloud storage.
- * Implements file saving, retrieval, and deletion within Google Cloud Storage.
+ * storage.go: Defines the blob storage abstraction used for images and imagery.
+ * Selects a Google Cloud Storage, local filesystem or S3-compatible backend from configuration.
  * Usage: Supports imageservice and satelliteservice with file management capabilities.
  * Author(s): Shannon Thompson
  * Created on: 04/12/2024
@@ -9,125 +9,70 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
-	"cloud.google.com/go/storage"
-	"google.golang.org/api/iterator"
-	"google.golang.org/api/option"
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
 )
 
-// StorageService encapsulates the Google Cloud Storage client and related operations.
-type StorageService struct {
-	Client     *storage.Client
-	BucketName string
-}
-
-// NewStorageService initializes a new storage service with the provided Google Cloud Storage bucket.
-func NewStorageService(ctx context.Context, bucketName, credentialsPath string) (*StorageService, error) {
-	// If credentials path is provided, use it to authenticate the client.
-	var client *storage.Client
-	var err error
-	if credentialsPath != "" {
-		client, err = storage.NewClient(ctx, option.WithCredentialsFile(credentialsPath))
-	} else {
-		// Otherwise, use the default credentials.
-		client, err = storage.NewClient(ctx)
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to create storage client: %w", err)
-	}
+// Supported values for CloudStorageConfig.Backend.
+const (
+	BackendGCS   = "gcs"
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
 
-	return &StorageService{
-		Client:     client,
-		BucketName: bucketName,
-	}, nil
+// BlobStore is implemented by every file storage backend.
+type BlobStore interface {
+	UploadFile(ctx context.Context, filePath string, fileData io.Reader) (string, error)
+	Get(ctx context.Context, filePath string) (io.ReadCloser, error)
+	DeleteFile(ctx context.Context, filePath string) error
+	GetFileMetadata(ctx context.Context, filePath string) (*FileMetadata, error)
+	ListImages(ctx context.Context) ([]string, error)
 }
 
-// UploadFile uploads any file to the cloud storage and returns its URL.
-func (s *StorageService) UploadFile(ctx context.Context, filePath string, fileData io.Reader) (string, error) {
-	bucket := s.Client.Bucket(s.BucketName)
-	obj := bucket.Object(filePath)
-
-	w := obj.NewWriter(ctx)
-
-	// Create a buffer to store a snippet of the file data for content type detection
-	buf := make([]byte, 512) // 512 bytes should be enough for content type detection
-	n, err := fileData.Read(buf)
-	if err != nil && err != io.EOF {
-		return "", fmt.Errorf("failed to read file for content type detection: %w", err)
-	}
-
-	// Detect content type
-	contentType := http.DetectContentType(buf)
-	w.ContentType = contentType
-
-	// Write the buffer read for detection
-	if _, err := w.Write(buf[:n]); err != nil {
-		return "", fmt.Errorf("failed to write initial data to bucket: %w", err)
-	}
-
-	// Continue writing the rest of the file
-	if _, err := io.Copy(w, fileData); err != nil {
-		return "", fmt.Errorf("failed to write file to bucket: %w", err)
-	}
-
-	if err := w.Close(); err != nil {
-		return "", fmt.Errorf("failed to finalize file upload: %w", err)
-	}
-
-	if err := obj.ACL().Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
-		return "", fmt.Errorf("failed to set file public: %w", err)
-	}
-
-	attrs, err := obj.Attrs(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get uploaded file attributes: %w", err)
-	}
-
-	return attrs.MediaLink, nil
+// FileMetadata describes a stored file independently of the backend holding it.
+type FileMetadata struct {
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType"`
+	Updated     time.Time `json:"updated"`
+	URL         string    `json:"url"`
 }
 
-// DeleteFile deletes a file from cloud storage.
-func (s *StorageService) DeleteFile(ctx context.Context, filePath string) error {
-	bucket := s.Client.Bucket(s.BucketName)
-	obj := bucket.Object(filePath)
-
-	err := obj.Delete(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to delete file: %w", err)
+// NewBlobStore creates the backend selected in the cloud storage configuration.
+// An empty backend defaults to Google Cloud Storage.
+func NewBlobStore(ctx context.Context, cfg config.CloudStorageConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", BackendGCS:
+		return NewGCSStore(ctx, cfg.BucketName, cfg.CredentialsPath)
+	case BackendLocal:
+		return NewLocalStore(cfg.Local.RootDir, cfg.Local.BaseURL)
+	case BackendS3:
+		return NewS3Store(cfg.BucketName, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.Backend)
 	}
-	return nil
 }
 
-// GetFileMetadata retrieves metadata of a file stored in cloud storage.
-func (s *StorageService) GetFileMetadata(ctx context.Context, filePath string) (*storage.ObjectAttrs, error) {
-	bucket := s.Client.Bucket(s.BucketName)
-	obj := bucket.Object(filePath)
-
-	attrs, err := obj.Attrs(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve file attributes: %w", err)
+// detectContentType sniffs the content type from the first bytes of fileData and
+// returns a reader that still yields the complete stream.
+func detectContentType(fileData io.Reader) (string, io.Reader, error) {
+	if fileData == nil {
+		return "", nil, fmt.Errorf("no file data provided")
 	}
-	return attrs, nil
-}
 
-// ListImages retrieves a list of image URLs from the cloud storage.
-func (s *StorageService) ListImages(ctx context.Context) ([]string, error) {
-	var urls []string
-	it := s.Client.Bucket(s.BucketName).Objects(ctx, nil)
-	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to list images: %w", err)
-		}
-		urls = append(urls, attrs.MediaLink)
+	// 512 bytes is all http.DetectContentType considers
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(fileData, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, fmt.Errorf("failed to read file for content type detection: %w", err)
 	}
-	return urls, nil
+
+	contentType := http.DetectContentType(buf[:n])
+	return contentType, io.MultiReader(bytes.NewReader(buf[:n]), fileData), nil
 }
@@ -0,0 +1,157 @@
+/*
+ * breaker.go: Per-source circuit breaker for outbound provider calls.
+ * Usage:
+ *   - Closed: requests flow and consecutive failures are counted.
+ *   - Open: after FailureThreshold consecutive failures requests fail fast with ErrCircuitOpen
+ *     until OpenTimeout has passed.
+ *   - Half-open: a single trial request is let through; its outcome closes or re-opens the circuit.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit states reported in BreakerState.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is a point-in-time view of a source's circuit breaker.
+type BreakerState struct {
+	Source              string     `json:"source"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	TotalRequests       int64      `json:"totalRequests"`
+	TotalFailures       int64      `json:"totalFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"` // When the next trial request is allowed
+}
+
+// Breaker tracks the health of one provider.
+type Breaker struct {
+	source      string
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	trialInFlight bool
+	openedAt      time.Time
+	lastFailureAt time.Time
+	lastError     string
+	totalRequests int64
+	totalFailures int64
+}
+
+func newBreaker(source string, threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		source:      source,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+		state:       StateClosed,
+	}
+}
+
+// Allow reports whether a request may be sent. Every nil result must be followed by exactly one
+// call to Success, Failure or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		retryAt := b.openedAt.Add(b.openTimeout)
+		if b.now().Before(retryAt) {
+			return fmt.Errorf("%w for %s until %s", ErrCircuitOpen, b.source, retryAt.Format(time.RFC3339))
+		}
+		b.state = StateHalfOpen
+	}
+	if b.state == StateHalfOpen {
+		if b.trialInFlight {
+			return fmt.Errorf("%w for %s: trial request in progress", ErrCircuitOpen, b.source)
+		}
+		b.trialInFlight = true
+	}
+	b.totalRequests++
+	return nil
+}
+
+// Success records a request that reached a healthy provider and closes the circuit.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = StateClosed
+	b.failures = 0
+	b.trialInFlight = false
+}
+
+// Failure records a failed request, opening the circuit at the threshold or after a failed trial.
+func (b *Breaker) Failure(reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.failures++
+	b.totalFailures++
+	b.lastError = reason
+	b.lastFailureAt = now
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = now
+	}
+	b.trialInFlight = false
+}
+
+// Release ends a request whose outcome says nothing about the provider, e.g. a cancelled context.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trialInFlight = false
+}
+
+// Reset closes the circuit and clears the failure count.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = StateClosed
+	b.failures = 0
+	b.trialInFlight = false
+}
+
+// State returns a snapshot of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := BreakerState{
+		Source:              b.source,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		TotalRequests:       b.totalRequests,
+		TotalFailures:       b.totalFailures,
+		LastError:           b.lastError,
+	}
+	if !b.lastFailureAt.IsZero() {
+		t := b.lastFailureAt
+		state.LastFailureAt = &t
+	}
+	if b.state != StateClosed {
+		opened, retry := b.openedAt, b.openedAt.Add(b.openTimeout)
+		state.OpenedAt, state.RetryAt = &opened, &retry
+	}
+	return state
+}
@@ -0,0 +1,323 @@
+/*
+ * transport.go: Resilient HTTP transport shared by every outbound provider call.
+ * Usage:
+ *   - Create one Registry from IngestionSettings at startup and ask it for a Client per data source.
+ *   - Failed attempts (network errors, 429 and 5xx) are retried up to RetryPolicy.MaxRetries times
+ *     with exponential backoff and jitter. Retry-After is honoured on 429 and 503.
+ *   - Each source has its own circuit breaker; see breaker.go. States are exposed through States
+ *     for the admin API.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sthompson732/viticulture-harvester-app/internal/config"
+)
+
+// Defaults applied when IngestionSettings leaves a value unset.
+const (
+	DefaultRequestTimeout   = 10 * time.Second
+	DefaultBackoffInterval  = time.Second
+	DefaultMaxBackoff       = 2 * time.Minute
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = time.Minute
+)
+
+// Policy holds the parsed retry and circuit breaker settings.
+type Policy struct {
+	MaxRetries       int
+	BackoffInterval  time.Duration
+	MaxBackoff       time.Duration
+	RequestTimeout   time.Duration
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+// Registry hands out per-source HTTP clients that share one policy and keep one breaker per source.
+type Registry struct {
+	policy Policy
+	base   http.RoundTripper
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+	clients  map[string]*http.Client
+}
+
+// NewRegistry parses the ingestion settings into a Policy.
+func NewRegistry(settings config.IngestionSettingsConfig) (*Registry, error) {
+	policy, err := ParsePolicy(settings)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{
+		policy:   policy,
+		base:     http.DefaultTransport,
+		breakers: make(map[string]*Breaker),
+		clients:  make(map[string]*http.Client),
+	}, nil
+}
+
+// ParsePolicy converts the YAML settings, applying defaults for unset values.
+func ParsePolicy(settings config.IngestionSettingsConfig) (Policy, error) {
+	policy := Policy{
+		MaxRetries:       settings.RetryPolicy.MaxRetries,
+		BackoffInterval:  DefaultBackoffInterval,
+		MaxBackoff:       DefaultMaxBackoff,
+		RequestTimeout:   DefaultRequestTimeout,
+		FailureThreshold: settings.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      DefaultOpenTimeout,
+	}
+	if policy.MaxRetries < 0 {
+		return Policy{}, fmt.Errorf("invalid maxRetries %d", policy.MaxRetries)
+	}
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = DefaultFailureThreshold
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"backoffInterval", settings.RetryPolicy.BackoffInterval, &policy.BackoffInterval},
+		{"maxBackoff", settings.RetryPolicy.MaxBackoff, &policy.MaxBackoff},
+		{"requestTimeout", settings.RequestTimeout, &policy.RequestTimeout},
+		{"openTimeout", settings.CircuitBreaker.OpenTimeout, &policy.OpenTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil || parsed <= 0 {
+			return Policy{}, fmt.Errorf("invalid %s %q", d.name, d.value)
+		}
+		*d.dst = parsed
+	}
+	return policy, nil
+}
+
+// Client returns the HTTP client for a data source. Clients are cached, so callers may ask for one per request.
+func (r *Registry) Client(source string) *http.Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clients[source]; ok {
+		return c
+	}
+	c := &http.Client{
+		// Timeouts are applied per attempt by the transport, so retries are not cut short
+		Transport: &retryTransport{
+			base:    r.base,
+			source:  source,
+			policy:  r.policy,
+			breaker: r.breakerLocked(source),
+		},
+	}
+	r.clients[source] = c
+	return c
+}
+
+// Breaker returns the circuit breaker for a data source, creating it if needed.
+func (r *Registry) Breaker(source string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.breakerLocked(source)
+}
+
+func (r *Registry) breakerLocked(source string) *Breaker {
+	b, ok := r.breakers[source]
+	if !ok {
+		b = newBreaker(source, r.policy.FailureThreshold, r.policy.OpenTimeout)
+		r.breakers[source] = b
+	}
+	return b
+}
+
+// States returns every known breaker, ordered by source.
+func (r *Registry) States() []BreakerState {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	states := make([]BreakerState, 0, len(breakers))
+	for _, b := range breakers {
+		states = append(states, b.State())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Source < states[j].Source })
+	return states
+}
+
+// Reset closes the circuit for a source. It reports false for sources that have no breaker yet.
+func (r *Registry) Reset(source string) bool {
+	r.mu.Lock()
+	b, ok := r.breakers[source]
+	r.mu.Unlock()
+	if ok {
+		b.Reset()
+	}
+	return ok
+}
+
+// retryTransport wraps a base RoundTripper with retries and the source's circuit breaker.
+type retryTransport struct {
+	base    http.RoundTripper
+	source  string
+	policy  Policy
+	breaker *Breaker
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				t.breaker.Release()
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.attempt(req)
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about the provider
+			t.breaker.Release()
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		failure := failureReason(resp, err)
+		if failure == "" {
+			t.breaker.Success()
+			return resp, nil
+		}
+
+		wait, retry := t.backoff(resp, attempt)
+		if !retry || !replayable {
+			t.breaker.Failure(failure)
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		log.Printf("Request to %s failed (%s), retry %d/%d in %s", t.source, failure, attempt+1,
+			t.policy.MaxRetries, wait.Round(time.Millisecond))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			t.breaker.Release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends one request with the per-attempt timeout. The timeout stays active until the body is closed.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.policy.RequestTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff decides whether to retry after a failed attempt and how long to wait first.
+func (t *retryTransport) backoff(resp *http.Response, attempt int) (time.Duration, bool) {
+	if attempt >= t.policy.MaxRetries {
+		return 0, false
+	}
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			// A provider asking for a longer pause than we allow is treated as down for now
+			if wait > t.policy.MaxBackoff {
+				return 0, false
+			}
+			return wait, true
+		}
+	}
+
+	// Exponential backoff with equal jitter: half the delay is fixed, half is random
+	delay := t.policy.BackoffInterval << attempt
+	if delay <= 0 || delay > t.policy.MaxBackoff {
+		delay = t.policy.MaxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)), true
+}
+
+// failureReason classifies an attempt. An empty result means the provider handled the request,
+// including 4xx responses other than 429, which retrying would not fix.
+func failureReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return resp.Status
+	}
+	return ""
+}
+
+// parseRetryAfter accepts both forms of the header: delay seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// cancelOnClose releases the per-attempt context once the caller is done with the body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
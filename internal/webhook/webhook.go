@@ -0,0 +1,146 @@
+/*
+ * webhook.go: Signs and sends webhook event deliveries.
+ * Usage: Every delivery is POSTed as JSON with the event name and delivery ID in headers and an HMAC-SHA256
+ *        signature over "<unix time>.<body>" keyed with the webhook's secret, sent as
+ *        X-Harvester-Signature: t=<unix time>,v1=<hex digest>. Receivers recompute the digest, e.g. with
+ *        Verify, and reject old timestamps to guard against replayed requests.
+ * Author(s): Shannon Thompson
+ * Created on: 10/16/2026
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events webhooks can subscribe to.
+const (
+	EventPestCreated       = "pest.created"
+	EventWeatherCreated    = "weather.created"
+	EventSatelliteIngested = "satellite.ingested"
+	EventAlertRaised       = "alert.raised"
+	EventIngestionFailed   = "ingestion.failed"
+)
+
+// Events lists every event.
+var Events = []string{EventPestCreated, EventWeatherCreated, EventSatelliteIngested, EventAlertRaised, EventIngestionFailed}
+
+// Request headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Harvester-Event"
+	HeaderDelivery  = "X-Harvester-Delivery"
+	HeaderSignature = "X-Harvester-Signature"
+)
+
+// maxResponseBody caps how much of a receiver's response is kept in the attempt log.
+const maxResponseBody = 1024
+
+// Envelope is the JSON body of a delivery. Data is the record the event is about.
+type Envelope struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	VineyardID *int        `json:"vineyard_id,omitempty"`
+	Data       interface{} `json:"data"`
+}
+
+// Response is what a receiver answered.
+type Response struct {
+	StatusCode int
+	Body       string // At most the first 1 KiB
+}
+
+// Sender POSTs deliveries to webhook URLs.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender whose requests give up after timeout.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{Timeout: timeout}}
+}
+
+// Send posts body to target. Responses outside 2xx are returned along with an error.
+func (s *Sender) Send(ctx context.Context, target, secret, event string, deliveryID int, body []byte) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(deliveryID))
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, resp.Body) // Let the connection be reused
+	result := &Response{StatusCode: resp.StatusCode, Body: string(snippet)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return result, nil
+}
+
+// Sign returns the signature header value for body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + digest(secret, ts, body)
+}
+
+// Verify checks a signature header against body, rejecting signatures older than tolerance.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("malformed signature header")
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(sig), []byte(digest(secret, ts, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func digest(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateURL checks that target is an absolute http or https URL.
+func ValidateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q, expected an absolute http or https URL", target)
+	}
+	return nil
+}